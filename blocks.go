@@ -1,13 +1,11 @@
 package gokeepasslib
 
 import (
-	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/sha512"
 	"crypto/subtle"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
 )
@@ -15,9 +13,34 @@ import (
 // Block size of 1MB - https://keepass.info/help/kb/kdbx_4.html#dataauth
 const blockSplitRate = 1048576
 
-var (
-	errHMACVerificationFailed = errors.New("failed to verify HMAC")
-)
+// ErrBlockHMACMismatch is the error returned if the HMAC of a content block (Kdbx v4) does not match its data
+type ErrBlockHMACMismatch struct {
+	Index uint64
+}
+
+func (e ErrBlockHMACMismatch) Error() string {
+	return fmt.Sprintf("gokeepasslib: HMAC of block %d mismatching", e.Index)
+}
+
+// ErrBlockHashMismatch is the error returned if the SHA-256 hash of a content block (Kdbx v3.1)
+// does not match its data
+type ErrBlockHashMismatch struct {
+	Index uint32
+}
+
+func (e ErrBlockHashMismatch) Error() string {
+	return fmt.Sprintf("gokeepasslib: hash of block %d mismatching", e.Index)
+}
+
+// ErrBlockIndexMismatch is the error returned if a content block (Kdbx v3.1) is out of order
+type ErrBlockIndexMismatch struct {
+	Index    uint32 // Index of the block read
+	Expected uint32 // Index of the block expected at its position
+}
+
+func (e ErrBlockIndexMismatch) Error() string {
+	return fmt.Sprintf("gokeepasslib: block index %d read, expected %d", e.Index, e.Expected)
+}
 
 type BlockHMACBuilder struct {
 	baseKey []byte
@@ -48,169 +71,274 @@ func (b *BlockHMACBuilder) BuildHMAC(index uint64, length uint32, data []byte) [
 	return mac.Sum(nil)
 }
 
-// decomposeContentBlocks4 decodes the content data block by block (Kdbx v4)
-// Used to extract data blocks from the entire content
-func decomposeContentBlocks4(
-	r io.Reader,
-	masterSeed []byte,
-	transformedKey []byte,
-) ([]byte, error) {
-	var contentData []byte
-	// Get all the content
-	content, err := io.ReadAll(r)
-	if err != nil {
-		return nil, err
+// blockReader4 reads the content data block by block
+// from a HMAC-LENGTH-DATA block scheme (Kdbx v4) and verifies the HMAC of every block
+type blockReader4 struct {
+	r           io.Reader
+	hmacBuilder *BlockHMACBuilder
+	index       uint64
+	data        []byte
+	done        bool
+}
+
+func newBlockReader4(r io.Reader, masterSeed []byte, transformedKey []byte) *blockReader4 {
+	return &blockReader4{
+		r:           r,
+		hmacBuilder: NewBlockHMACBuilder(masterSeed, transformedKey),
 	}
+}
 
-	hmacBuilder := NewBlockHMACBuilder(masterSeed, transformedKey)
+// Read returns the verified data of the blocks
+func (br *blockReader4) Read(p []byte) (int, error) {
+	for len(br.data) == 0 {
+		if br.done {
+			return 0, io.EOF
+		}
+		if err := br.readBlock(); err != nil {
+			return 0, err
+		}
+	}
 
-	index := uint64(0)
-	offset := uint32(0)
-	for {
-		var blockHMAC [32]byte
-		var length uint32
+	n := copy(p, br.data)
+	br.data = br.data[n:]
+	return n, nil
+}
 
-		copy(blockHMAC[:], content[offset:offset+32])
-		offset += 32
+func (br *blockReader4) readBlock() error {
+	var blockHMAC [32]byte
+	var length uint32
 
-		buf := bytes.NewBuffer(content[offset : offset+4])
-		binary.Read(buf, binary.LittleEndian, &length)
+	if _, err := io.ReadFull(br.r, blockHMAC[:]); err != nil {
+		return noEOF(err)
+	}
+	if err := binary.Read(br.r, binary.LittleEndian, &length); err != nil {
+		return noEOF(err)
+	}
 
-		offset += 4
+	data, err := readData(br.r, uint64(length))
+	if err != nil {
+		return err
+	}
 
-		data := make([]byte, length)
-		endOfData := offset + length
-		copy(data, content[offset:endOfData])
-		offset = endOfData
+	calculatedHMAC := br.hmacBuilder.BuildHMAC(br.index, length, data)
+	if subtle.ConstantTimeCompare(calculatedHMAC, blockHMAC[:]) == 0 {
+		return ErrBlockHMACMismatch{Index: br.index}
+	}
 
-		calculatedHMAC := hmacBuilder.BuildHMAC(index, length, data)
+	br.data = data
+	br.done = length == 0
+	br.index++
+	return nil
+}
 
-		if subtle.ConstantTimeCompare(calculatedHMAC, blockHMAC[:]) == 0 {
-			return nil, fmt.Errorf("%w for block %d", errHMACVerificationFailed, index)
-		}
+// blockReader31 reads the content data block by block
+// from a INDEX-SHA-LENGTH-DATA block scheme (Kdbx v3.1) and optionally verifies the hash of every block
+type blockReader31 struct {
+	r        io.Reader
+	validate bool
+	index    uint32
+	data     []byte
+	done     bool
+}
 
-		// Add to blocks
-		contentData = append(contentData, data...)
+func newBlockReader31(r io.Reader, validate bool) *blockReader31 {
+	return &blockReader31{r: r, validate: validate}
+}
 
-		if length == 0 {
-			break
+// Read returns the data of the blocks
+func (br *blockReader31) Read(p []byte) (int, error) {
+	for len(br.data) == 0 {
+		if br.done {
+			return 0, io.EOF
+		}
+		if err := br.readBlock(); err != nil {
+			return 0, err
 		}
-
-		index++
 	}
-	return contentData, nil
-}
 
-// decomposeContentBlocks31 decodes the content data block by block (Kdbx v3.1)
-// Used to extract data blocks from the entire content
-func decomposeContentBlocks31(r io.Reader) ([]byte, error) {
-	var contentData []byte
-	// Get all the content
-	content, err := io.ReadAll(r)
-	if err != nil {
-		return nil, err
-	}
+	n := copy(p, br.data)
+	br.data = br.data[n:]
+	return n, nil
+}
 
-	offset := uint32(0)
-	for {
-		var hash [32]byte
-		var length uint32
-		var data []byte
+func (br *blockReader31) readBlock() error {
+	var index uint32
+	var hash [32]byte
+	var length uint32
 
-		// Skipping Index, uint32
-		offset += 4
+	if err := binary.Read(br.r, binary.LittleEndian, &index); err != nil {
+		return noEOF(err)
+	}
+	if _, err := io.ReadFull(br.r, hash[:]); err != nil {
+		return noEOF(err)
+	}
+	if err := binary.Read(br.r, binary.LittleEndian, &length); err != nil {
+		return noEOF(err)
+	}
+	if br.validate && index != br.index {
+		return ErrBlockIndexMismatch{Index: index, Expected: br.index}
+	}
 
-		copy(hash[:], content[offset:offset+32])
-		offset += 32
+	if length == 0 {
+		// The final block has no data and an empty hash
+		if br.validate && hash != [32]byte{} {
+			return ErrBlockHashMismatch{Index: index}
+		}
+		br.done = true
+		return nil
+	}
 
-		length = binary.LittleEndian.Uint32(content[offset : offset+4])
-		offset += 4
+	data, err := readData(br.r, uint64(length))
+	if err != nil {
+		return err
+	}
+	if br.validate && sha256.Sum256(data) != hash {
+		return ErrBlockHashMismatch{Index: index}
+	}
 
-		if length > 0 {
-			data = make([]byte, length)
-			copy(data, content[offset:offset+length])
-			offset += length
+	br.data = data
+	br.index++
+	return nil
+}
 
-			// Add to decoded blocks
-			contentData = append(contentData, data...)
-		} else {
-			break
-		}
+// noEOF returns io.ErrUnexpectedEOF instead of io.EOF,
+// the blocks have to end with the final block instead of the end of the data
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
 	}
-	return contentData, nil
+	return err
 }
 
-// composeContentBlocks4 composes every content block into a HMAC-LENGTH-DATA block scheme (Kdbx v4)
-func composeContentBlocks4(
-	w io.Writer,
-	contentData []byte,
-	masterSeed []byte,
-	transformedKey []byte,
-) {
-	hmacBuilder := NewBlockHMACBuilder(masterSeed, transformedKey)
+// blockWriter4 composes the written content into a HMAC-LENGTH-DATA block scheme (Kdbx v4).
+// Close has to be called to write the remaining data and the final block.
+type blockWriter4 struct {
+	w           io.Writer
+	hmacBuilder *BlockHMACBuilder
+	index       uint64
+	buffer      []byte
+}
 
-	var offset int
-	var endOffset int
-	var index = uint64(0)
-	for {
-		remainingLength := len(contentData[offset:])
+func newBlockWriter4(w io.Writer, masterSeed []byte, transformedKey []byte) *blockWriter4 {
+	return &blockWriter4{
+		w:           w,
+		hmacBuilder: NewBlockHMACBuilder(masterSeed, transformedKey),
+		buffer:      make([]byte, 0, blockSplitRate),
+	}
+}
 
-		if remainingLength >= blockSplitRate {
-			endOffset = offset + blockSplitRate
-		} else {
-			endOffset = offset + remainingLength
+// Write writes every full block of data to the underlying writer
+func (bw *blockWriter4) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := min(blockSplitRate-len(bw.buffer), len(p))
+		bw.buffer = append(bw.buffer, p[:n]...)
+		p = p[n:]
+
+		if len(bw.buffer) == blockSplitRate {
+			if err := bw.writeBlock(); err != nil {
+				return 0, err
+			}
 		}
+	}
+	return written, nil
+}
 
-		length := endOffset - offset
-		data := make([]byte, length)
-		copy(data, contentData[offset:endOffset])
-		uLength := uint32(length)
+// Close writes the remaining data and the final empty block
+func (bw *blockWriter4) Close() error {
+	if len(bw.buffer) > 0 {
+		if err := bw.writeBlock(); err != nil {
+			return err
+		}
+	}
+	return bw.writeBlock()
+}
 
-		blockHMAC := hmacBuilder.BuildHMAC(index, uLength, data)
+func (bw *blockWriter4) writeBlock() error {
+	length := uint32(len(bw.buffer))
+	blockHMAC := bw.hmacBuilder.BuildHMAC(bw.index, length, bw.buffer)
 
-		w.Write(blockHMAC)
-		binary.Write(w, binary.LittleEndian, uLength)
-		w.Write(data)
+	if _, err := bw.w.Write(blockHMAC); err != nil {
+		return err
+	}
+	if err := binary.Write(bw.w, binary.LittleEndian, length); err != nil {
+		return err
+	}
+	if _, err := bw.w.Write(bw.buffer); err != nil {
+		return err
+	}
 
-		offset = endOffset
+	bw.buffer = bw.buffer[:0]
+	bw.index++
+	return nil
+}
 
-		if length == 0 {
-			break
-		}
+// blockWriter31 composes the written content into a INDEX-SHA-LENGTH-DATA block scheme (Kdbx v3.1).
+// Close has to be called to write the remaining data and the final block.
+type blockWriter31 struct {
+	w      io.Writer
+	index  uint32
+	buffer []byte
+}
 
-		index++
+func newBlockWriter31(w io.Writer) *blockWriter31 {
+	return &blockWriter31{
+		w:      w,
+		buffer: make([]byte, 0, blockSplitRate),
 	}
-	binary.Write(w, binary.LittleEndian, [32]byte{})
-	binary.Write(w, binary.LittleEndian, uint32(0))
 }
 
-// composeBlocks31 composes every content block
-// into a INDEX-SHA-LENGTH-DATA block scheme (Kdbx v3.1)
-func composeContentBlocks31(w io.Writer, contentData []byte) {
-	index := uint32(0)
-	offset := 0
-	for offset < len(contentData) {
-		var hash [32]byte
-		var endOffset int
+// Write writes every full block of data to the underlying writer
+func (bw *blockWriter31) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := min(blockSplitRate-len(bw.buffer), len(p))
+		bw.buffer = append(bw.buffer, p[:n]...)
+		p = p[n:]
+
+		if len(bw.buffer) == blockSplitRate {
+			if err := bw.writeBlock(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return written, nil
+}
 
-		if len(contentData[offset:]) >= blockSplitRate {
-			endOffset = offset + blockSplitRate
-		} else {
-			endOffset = len(contentData)
+// Close writes the remaining data and the final block, which has no hash and no data
+func (bw *blockWriter31) Close() error {
+	if len(bw.buffer) > 0 {
+		if err := bw.writeBlock(); err != nil {
+			return err
 		}
+	}
+
+	if err := binary.Write(bw.w, binary.LittleEndian, bw.index); err != nil {
+		return err
+	}
+	if err := binary.Write(bw.w, binary.LittleEndian, [32]byte{}); err != nil {
+		return err
+	}
+	return binary.Write(bw.w, binary.LittleEndian, uint32(0))
+}
 
-		data := contentData[offset:endOffset]
-		length := uint32(len(data))
-		hash = sha256.Sum256(data)
+func (bw *blockWriter31) writeBlock() error {
+	hash := sha256.Sum256(bw.buffer)
 
-		binary.Write(w, binary.LittleEndian, index)
-		binary.Write(w, binary.LittleEndian, hash)
-		binary.Write(w, binary.LittleEndian, length)
-		binary.Write(w, binary.LittleEndian, data)
-		index++
-		offset = endOffset
+	if err := binary.Write(bw.w, binary.LittleEndian, bw.index); err != nil {
+		return err
 	}
-	binary.Write(w, binary.LittleEndian, index)
-	binary.Write(w, binary.LittleEndian, [32]byte{})
-	binary.Write(w, binary.LittleEndian, uint32(0))
+	if err := binary.Write(bw.w, binary.LittleEndian, hash); err != nil {
+		return err
+	}
+	if err := binary.Write(bw.w, binary.LittleEndian, uint32(len(bw.buffer))); err != nil {
+		return err
+	}
+	if _, err := bw.w.Write(bw.buffer); err != nil {
+		return err
+	}
+
+	bw.buffer = bw.buffer[:0]
+	bw.index++
+	return nil
 }
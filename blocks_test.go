@@ -2,6 +2,8 @@ package gokeepasslib
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"testing"
 )
 
@@ -183,3 +185,130 @@ func TestBlockHMACBuilderBuildHMAC(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockReaderWriter(t *testing.T) {
+	masterSeed := bytes.Repeat([]byte{0x01}, 32)
+	transformedKey := bytes.Repeat([]byte{0x02}, 32)
+
+	cases := []struct {
+		title     string
+		newWriter func(w io.Writer) io.WriteCloser
+		newReader func(r io.Reader) io.Reader
+	}{
+		{
+			title: "Kdbx v4",
+			newWriter: func(w io.Writer) io.WriteCloser {
+				return newBlockWriter4(w, masterSeed, transformedKey)
+			},
+			newReader: func(r io.Reader) io.Reader {
+				return newBlockReader4(r, masterSeed, transformedKey)
+			},
+		},
+		{
+			title: "Kdbx v3.1",
+			newWriter: func(w io.Writer) io.WriteCloser {
+				return newBlockWriter31(w)
+			},
+			newReader: func(r io.Reader) io.Reader {
+				return newBlockReader31(r, true)
+			},
+		},
+	}
+
+	lengths := []int{0, 100, blockSplitRate, 2*blockSplitRate + 100}
+
+	for _, c := range cases {
+		for _, length := range lengths {
+			t.Run(fmt.Sprintf("%s with %d bytes", c.title, length), func(t *testing.T) {
+				data := make([]byte, length)
+				for i := range data {
+					data[i] = byte(i)
+				}
+
+				var buffer bytes.Buffer
+				writer := c.newWriter(&buffer)
+				if _, err := writer.Write(data); err != nil {
+					t.Fatalf("Received unexpected error %v", err)
+				}
+				if err := writer.Close(); err != nil {
+					t.Fatalf("Received unexpected error %v", err)
+				}
+
+				// Trailing data after the final block must not be read
+				buffer.Write([]byte{0x10, 0x10})
+
+				result, err := io.ReadAll(c.newReader(&buffer))
+				if err != nil {
+					t.Fatalf("Received unexpected error %v", err)
+				}
+
+				if !bytes.Equal(result, data) {
+					t.Errorf("Received %d bytes, expected %d bytes", len(result), len(data))
+				}
+				if buffer.Len() != 2 {
+					t.Errorf("Expected the trailing data to remain, %d bytes left", buffer.Len())
+				}
+			})
+		}
+	}
+}
+
+func TestBlockReader31Validation(t *testing.T) {
+	data := make([]byte, blockSplitRate+100)
+	var buffer bytes.Buffer
+	writer := newBlockWriter31(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	blocks := buffer.Bytes()
+
+	// Every block starts with a 4 byte index, a 32 byte hash and a 4 byte length
+	const blockHeaderSize = 40
+	secondBlock := blockHeaderSize + blockSplitRate
+
+	cases := []struct {
+		title    string
+		modify   func(blocks []byte)
+		validate bool
+		expected error
+	}{
+		{
+			title:    "data of the second block modified",
+			modify:   func(blocks []byte) { blocks[secondBlock+blockHeaderSize] ^= 0x01 },
+			validate: true,
+			expected: ErrBlockHashMismatch{Index: 1},
+		},
+		{
+			title:    "hash of the final block modified",
+			modify:   func(blocks []byte) { blocks[len(blocks)-5] ^= 0x01 },
+			validate: true,
+			expected: ErrBlockHashMismatch{Index: 2},
+		},
+		{
+			title:    "index of the second block modified",
+			modify:   func(blocks []byte) { blocks[secondBlock] = 0x05 },
+			validate: true,
+			expected: ErrBlockIndexMismatch{Index: 5, Expected: 1},
+		},
+		{
+			title:    "data modified without validation",
+			modify:   func(blocks []byte) { blocks[secondBlock+blockHeaderSize] ^= 0x01 },
+			validate: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			modified := bytes.Clone(blocks)
+			c.modify(modified)
+
+			_, err := io.ReadAll(newBlockReader31(bytes.NewReader(modified), c.validate))
+			if err != c.expected {
+				t.Errorf("Expected error %v, received %v", c.expected, err)
+			}
+		})
+	}
+}
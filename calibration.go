@@ -0,0 +1,217 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/tobischo/argon2"
+)
+
+const (
+	// DefaultKdfDuration is the time the key derivation is calibrated to take by default, like in KeePass
+	DefaultKdfDuration = time.Second
+
+	// DefaultKdfMaxMemory is the maximum memory in bytes used by a calibrated Argon2 by default
+	DefaultKdfMaxMemory = 64 * 1024 * 1024
+
+	// kdfBenchmarkDuration limits the time spent benchmarking,
+	// the results are extrapolated to the target duration
+	kdfBenchmarkDuration = 100 * time.Millisecond
+
+	// aesBenchmarkRounds is the number of AES-KDF rounds computed between checking the elapsed time
+	aesBenchmarkRounds = 10000
+)
+
+// ErrUnsupportedKdf is returned when calibrating an unknown key derivation function
+var ErrUnsupportedKdf = errors.New("gokeepasslib: unsupported key derivation function")
+
+// KdfCalibrationOption is the option function type for calibrating key derivation functions
+type KdfCalibrationOption func(*kdfCalibration)
+
+type kdfCalibration struct {
+	duration    time.Duration
+	maxMemory   uint64
+	parallelism uint32
+}
+
+// WithKdfDuration sets the time the key derivation should take on the current machine
+func WithKdfDuration(duration time.Duration) KdfCalibrationOption {
+	return func(c *kdfCalibration) {
+		c.duration = duration
+	}
+}
+
+// WithKdfMaxMemory sets the maximum memory in bytes used by Argon2,
+// less memory is used if a single iteration would take longer than the target duration
+func WithKdfMaxMemory(memory uint64) KdfCalibrationOption {
+	return func(c *kdfCalibration) {
+		c.maxMemory = memory
+	}
+}
+
+// WithKdfParallelism sets the number of threads used by Argon2
+func WithKdfParallelism(parallelism uint32) KdfCalibrationOption {
+	return func(c *kdfCalibration) {
+		c.parallelism = parallelism
+	}
+}
+
+// WithFileHeadersKdfCalibration can be passed to NewKDBX4FileHeaders
+// to calibrate the key derivation function, see FileHeaders.CalibrateKdf.
+// It has to be passed after options changing the key derivation function.
+// If the calibration fails, the default parameters are kept and encoding the database returns its error
+// until the key derivation parameters are changed or calibrated again.
+func WithFileHeadersKdfCalibration(options ...KdfCalibrationOption) FileHeadersOption {
+	return func(fh *FileHeaders) {
+		if err := fh.CalibrateKdf(options...); err != nil && fh.KdfParameters != nil {
+			fh.calibrationFailure = &kdfCalibrationFailure{err: err, parameters: *fh.KdfParameters}
+		}
+	}
+}
+
+// kdfCalibrationFailure is the error of a calibration together with the parameters which were not calibrated
+type kdfCalibrationFailure struct {
+	err        error
+	parameters KdfParameters
+}
+
+// calibrationErr returns the error of WithFileHeadersKdfCalibration
+// as long as the key derivation parameters are unchanged since
+func (fh *FileHeaders) calibrationErr() error {
+	if fh.calibrationFailure == nil || fh.KdfParameters == nil ||
+		!reflect.DeepEqual(*fh.KdfParameters, fh.calibrationFailure.parameters) {
+		return nil
+	}
+	return fh.calibrationFailure.err
+}
+
+func newKdfCalibration(options []KdfCalibrationOption) kdfCalibration {
+	c := kdfCalibration{
+		duration:    DefaultKdfDuration,
+		maxMemory:   DefaultKdfMaxMemory,
+		parallelism: defaultParallelism,
+	}
+	for _, option := range options {
+		option(&c)
+	}
+	return c
+}
+
+// CalibrateKdf benchmarks the key derivation function on the current machine
+// and sets its parameters so that deriving the key takes the target duration:
+// the TransformRounds of KDBX 3.1 headers, or the KdfParameters of KDBX 4 headers.
+func (fh *FileHeaders) CalibrateKdf(options ...KdfCalibrationOption) error {
+	if fh.KdfParameters == nil {
+		fh.TransformRounds = newKdfCalibration(options).aesRounds()
+		return nil
+	}
+	if err := fh.KdfParameters.Calibrate(options...); err != nil {
+		return err
+	}
+	fh.calibrationFailure = nil
+	return nil
+}
+
+// Calibrate benchmarks the key derivation function on the current machine
+// and sets its parameters so that deriving the key takes the target duration:
+// the Rounds of AES-KDF, or the Memory, Iterations and Parallelism of Argon2.
+// ErrInvalidKdfParameters is returned if the parallelism is not within 1 and 255
+// or the maximum memory is lower than 1 MiB or 8 KiB per thread.
+func (k *KdfParameters) Calibrate(options ...KdfCalibrationOption) error {
+	c := newKdfCalibration(options)
+	switch {
+	case k.IsArgon2():
+		if err := c.validateArgon2(); err != nil {
+			return err
+		}
+		k.Memory, k.Iterations = c.argon2(bytes.Equal(k.UUID, KdfArgon2id))
+		k.Parallelism = c.parallelism
+		if k.Version == 0 {
+			k.Version = defaultVersion
+		}
+	case bytes.Equal(k.UUID, KdfAES4), bytes.Equal(k.UUID, KdfAES3):
+		k.Rounds = c.aesRounds()
+	default:
+		return ErrUnsupportedKdf
+	}
+	return nil
+}
+
+// benchmarkDuration returns how long the key derivation function is benchmarked
+func (c kdfCalibration) benchmarkDuration() time.Duration {
+	return min(c.duration, kdfBenchmarkDuration)
+}
+
+// aesRounds returns the number of AES-KDF rounds taking the target duration
+func (c kdfCalibration) aesRounds() uint64 {
+	key := make([]byte, 32)
+	seed := make([]byte, 32)
+
+	var rounds uint64
+	start := time.Now()
+	for time.Since(start) < c.benchmarkDuration() {
+		if _, err := cryptAESKey(key, seed, aesBenchmarkRounds); err != nil {
+			return defaultTransformRounds
+		}
+		rounds += aesBenchmarkRounds
+	}
+	return max(extrapolate(rounds, time.Since(start), c.duration), defaultTransformRounds)
+}
+
+// validateArgon2 checks that Argon2 can be calibrated with the parallelism and maximum memory
+func (c kdfCalibration) validateArgon2() error {
+	if c.parallelism == 0 || c.parallelism > math.MaxUint8 {
+		return fmt.Errorf("%w: argon2 parallelism of %d", ErrInvalidKdfParameters, c.parallelism)
+	}
+	if c.maxMemory < c.minMemory() {
+		return fmt.Errorf(
+			"%w: %d bytes of argon2 memory, at least %d are required",
+			ErrInvalidKdfParameters,
+			c.maxMemory,
+			c.minMemory(),
+		)
+	}
+	return nil
+}
+
+// minMemory returns the lowest memory in bytes of a calibrated Argon2,
+// Argon2 requires at least 8 KiB per thread
+func (c kdfCalibration) minMemory() uint64 {
+	return max(memorySize, 8*1024*uint64(c.parallelism))
+}
+
+// argon2 returns the memory in bytes and the number of iterations of Argon2 taking the target duration.
+// The memory is halved until a single iteration is faster than the target duration.
+func (c kdfCalibration) argon2(id bool) (uint64, uint64) {
+	derive := argon2.DKey
+	if id {
+		derive = argon2.IDKey
+	}
+
+	key := make([]byte, 32)
+	salt := make([]byte, 32)
+	memory := c.maxMemory / 1024 * 1024
+	for {
+		start := time.Now()
+		derive(key, salt, 1, uint32(memory/1024), uint8(c.parallelism), 32)
+		elapsed := time.Since(start)
+
+		if elapsed <= c.duration || memory/2 < c.minMemory() {
+			return memory, max(extrapolate(1, elapsed, c.duration), defaultIterations)
+		}
+		memory /= 2
+	}
+}
+
+// extrapolate returns the count of operations taking the target duration,
+// if count operations took elapsed
+func extrapolate(count uint64, elapsed, target time.Duration) uint64 {
+	if elapsed <= 0 {
+		elapsed = 1
+	}
+	return uint64(float64(count) * float64(target) / float64(elapsed))
+}
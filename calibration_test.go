@@ -0,0 +1,184 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKdfParametersCalibrate(t *testing.T) {
+	cases := []struct {
+		title string
+		uuid  []byte
+	}{
+		{title: "argon2d", uuid: KdfArgon2},
+		{title: "argon2id", uuid: KdfArgon2id},
+		{title: "aes", uuid: KdfAES4},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			k := &KdfParameters{UUID: c.uuid}
+			err := k.Calibrate(
+				WithKdfDuration(20*time.Millisecond),
+				WithKdfMaxMemory(4*memorySize),
+				WithKdfParallelism(1),
+			)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			if !k.IsArgon2() {
+				if k.Rounds < defaultTransformRounds {
+					t.Errorf("Expected at least %d rounds, received %d", defaultTransformRounds, k.Rounds)
+				}
+				return
+			}
+			if k.Memory < memorySize || k.Memory > 4*memorySize {
+				t.Errorf("Expected the memory to be within the limits, received %d", k.Memory)
+			}
+			if k.Iterations < defaultIterations {
+				t.Errorf("Expected at least %d iterations, received %d", defaultIterations, k.Iterations)
+			}
+			if k.Parallelism != 1 || k.Version != defaultVersion {
+				t.Errorf("Expected parallelism 1 and version %d, received %d and %d", defaultVersion, k.Parallelism, k.Version)
+			}
+		})
+	}
+}
+
+func TestKdfParametersCalibrateUnsupported(t *testing.T) {
+	k := &KdfParameters{UUID: []byte{0x01}}
+	if err := k.Calibrate(); !errors.Is(err, ErrUnsupportedKdf) {
+		t.Errorf("Expected error %v, received %v", ErrUnsupportedKdf, err)
+	}
+}
+
+func TestKdfParametersCalibrateInvalid(t *testing.T) {
+	cases := []struct {
+		title   string
+		options []KdfCalibrationOption
+	}{
+		{title: "no parallelism", options: []KdfCalibrationOption{WithKdfParallelism(0)}},
+		{title: "parallelism above 255", options: []KdfCalibrationOption{WithKdfParallelism(256)}},
+		{title: "memory below 1 MiB", options: []KdfCalibrationOption{WithKdfMaxMemory(512)}},
+		{
+			title:   "memory below 8 KiB per thread",
+			options: []KdfCalibrationOption{WithKdfMaxMemory(2000 * 1024), WithKdfParallelism(255)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			k := &KdfParameters{UUID: KdfArgon2, Memory: memorySize, Iterations: 2, Parallelism: 2}
+			if err := k.Calibrate(c.options...); !errors.Is(err, ErrInvalidKdfParameters) {
+				t.Errorf("Expected error %v, received %v", ErrInvalidKdfParameters, err)
+			}
+			if k.Memory != memorySize || k.Iterations != 2 || k.Parallelism != 2 {
+				t.Errorf("Expected the parameters to be unchanged, received %+v", k)
+			}
+		})
+	}
+}
+
+func TestFileHeadersCalibrateKdf(t *testing.T) {
+	fh := NewKDBX3FileHeaders()
+	fh.TransformRounds = 0
+	if err := fh.CalibrateKdf(WithKdfDuration(10 * time.Millisecond)); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if fh.TransformRounds < defaultTransformRounds {
+		t.Errorf("Expected at least %d rounds, received %d", defaultTransformRounds, fh.TransformRounds)
+	}
+
+	fh = NewKDBX4FileHeaders(
+		WithFileHeadersKdfArgon2id(),
+		WithFileHeadersKdfCalibration(WithKdfDuration(10*time.Millisecond), WithKdfMaxMemory(2*memorySize)),
+	)
+	if fh.KdfParameters.Memory > 2*memorySize || fh.KdfParameters.Iterations < defaultIterations {
+		t.Errorf(
+			"Expected calibrated parameters, received memory %d and %d iterations",
+			fh.KdfParameters.Memory,
+			fh.KdfParameters.Iterations,
+		)
+	}
+}
+
+func TestWithFileHeadersKdfCalibrationError(t *testing.T) {
+	cases := []struct {
+		title    string
+		change   func(t *testing.T, fh *FileHeaders)
+		expected error
+	}{
+		{
+			title:    "unchanged parameters",
+			change:   func(t *testing.T, fh *FileHeaders) {},
+			expected: ErrInvalidKdfParameters,
+		},
+		{
+			title: "calibrated again",
+			change: func(t *testing.T, fh *FileHeaders) {
+				err := fh.CalibrateKdf(WithKdfDuration(10*time.Millisecond), WithKdfMaxMemory(memorySize))
+				if err != nil {
+					t.Fatalf("Received unexpected error %v", err)
+				}
+			},
+		},
+		{
+			title: "parameters changed by hand",
+			change: func(t *testing.T, fh *FileHeaders) {
+				fh.KdfParameters.Memory = memorySize
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := NewDatabase(WithDatabaseKDBXVersion4(WithFileHeadersKdfCalibration(WithKdfParallelism(0))))
+			db.Credentials = NewPasswordCredentials(password)
+			if db.Header.FileHeaders.KdfParameters.Parallelism != defaultParallelism {
+				t.Errorf("Expected the default parameters, received %+v", db.Header.FileHeaders.KdfParameters)
+			}
+
+			c.change(t, db.Header.FileHeaders)
+
+			var buffer bytes.Buffer
+			err := NewEncoder(&buffer).Encode(db)
+			if !errors.Is(err, c.expected) {
+				t.Errorf("Expected error %v, received %v", c.expected, err)
+			}
+			if err != nil && buffer.Len() > 0 {
+				t.Errorf("Expected nothing to be written, received %d bytes", buffer.Len())
+			}
+		})
+	}
+}
+
+func TestEncodeWithoutHeader(t *testing.T) {
+	db := NewDatabase()
+	db.Header = nil
+
+	var buffer bytes.Buffer
+	err := NewEncoder(&buffer).Encode(db)
+	if !errors.Is(err, ErrRequiredAttributeMissing("Header")) {
+		t.Errorf("Expected error %v, received %v", ErrRequiredAttributeMissing("Header"), err)
+	}
+}
+
+func TestNewFileHeadersDefaults(t *testing.T) {
+	if rounds := NewKDBX3FileHeaders().TransformRounds; rounds != 60000 {
+		t.Errorf("Expected 60000 rounds, received %d", rounds)
+	}
+
+	k := NewKDBX4FileHeaders().KdfParameters
+	if k.Memory != 64*1024*1024 || k.Iterations != 2 || k.Parallelism != 2 {
+		t.Errorf("Expected 64 MiB, 2 iterations and parallelism 2, received %+v", k)
+	}
+}
+
+func TestExtrapolate(t *testing.T) {
+	if count := extrapolate(1000, 10*time.Millisecond, time.Second); count != 100000 {
+		t.Errorf("Expected 100000, received %d", count)
+	}
+}
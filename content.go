@@ -1,7 +1,6 @@
 package gokeepasslib
 
 import (
-	"bytes"
 	"crypto/rand"
 	"encoding/binary"
 	"encoding/xml"
@@ -29,7 +28,7 @@ type InnerHeader struct {
 
 // DBContent is a container for all elements of a keepass database
 type DBContent struct {
-	RawData     []byte       `xml:"-"` // XML encoded original data
+	RawData     []byte       `xml:"-"` // XML encoded original data, only kept by Decode with WithDecoderRawData
 	InnerHeader *InnerHeader `xml:"-"`
 	XMLName     xml.Name     `xml:"KeePassFile"`
 	Meta        *MetaData    `xml:"Meta"`
@@ -75,14 +74,13 @@ func NewContent(options ...DBContentOption) *DBContent {
 	return content
 }
 
-// readFrom reads the InnerHeader from an io.Reader
-func (ih *InnerHeader) readFrom(r io.Reader) error {
+// readFrom reads the InnerHeader from an io.Reader, the size of the fields is limited by opts
+func (ih *InnerHeader) readFrom(r io.Reader, opts *DBOptions) error {
 	binaryCount := 0 // Var used to count and index every binary
 ForLoop:
 	for {
 		var headerType byte
 		var length int32
-		var data []byte
 
 		if err := binary.Read(r, binary.LittleEndian, &headerType); err != nil {
 			return err
@@ -90,8 +88,18 @@ ForLoop:
 		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
 			return err
 		}
-		data = make([]byte, length)
-		if err := binary.Read(r, binary.LittleEndian, &data); err != nil {
+		if length < 0 {
+			return ErrInvalidHeaderFieldLength{ID: headerType, Length: int(length)}
+		}
+		limitName, limit := "MaxHeaderFieldSize", opts.MaxHeaderFieldSize
+		if headerType == InnerHeaderBinary {
+			limitName, limit = "MaxBinarySize", opts.MaxBinarySize
+		}
+		if err := checkLimit(limitName, uint64(length), limit); err != nil {
+			return err
+		}
+		data, err := readData(r, uint64(length))
+		if err != nil {
 			return err
 		}
 
@@ -101,17 +109,19 @@ ForLoop:
 			break ForLoop
 		case InnerHeaderIRSID:
 			// Found InnerRandomStream ID
+			if len(data) != 4 {
+				return ErrInvalidHeaderFieldLength{ID: headerType, Length: len(data)}
+			}
 			ih.InnerRandomStreamID = binary.LittleEndian.Uint32(data)
 		case InnerHeaderIRSKey:
 			// Found InnerRandomStream Key
 			ih.InnerRandomStreamKey = data
 		case InnerHeaderBinary:
-			// Found a binary
-			var protection byte
-			reader := bytes.NewReader(data)
-
-			binary.Read(reader, binary.LittleEndian, &protection) // Read memory protection flag
-			content, _ := io.ReadAll(reader)                      // Read content
+			// Found a binary, starting with the memory protection flag
+			if len(data) == 0 {
+				return ErrInvalidHeaderFieldLength{ID: headerType, Length: len(data)}
+			}
+			protection, content := data[0], data[1:]
 
 			ih.Binaries = append(
 				ih.Binaries,
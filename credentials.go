@@ -1,6 +1,7 @@
 package gokeepasslib
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -17,6 +18,9 @@ import (
 	"github.com/tobischo/argon2"
 )
 
+// aesKdfCheckRounds is the number of AES-KDF rounds after which the context is checked
+const aesKdfCheckRounds = 1 << 16
+
 var (
 	errUnsupportedKeyFileXMLFormat = errors.New("Unsupported key file XML format")
 )
@@ -51,30 +55,52 @@ func (c *DBCredentials) buildCompositeKey() ([]byte, error) {
 	return hash.Sum(nil), nil
 }
 
-func (c *DBCredentials) buildTransformedKey(db *Database) ([]byte, error) {
+// buildTransformedKey derives the transformed key, checking ctx between AES-KDF rounds and around Argon2
+func (c *DBCredentials) buildTransformedKey(
+	ctx context.Context,
+	db *Database,
+	progress ProgressFunc,
+) ([]byte, error) {
 	transformedKey, err := c.buildCompositeKey()
 	if err != nil {
 		return nil, err
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	if db.Header.IsKdbx4() {
-		if reflect.DeepEqual(db.Header.FileHeaders.KdfParameters.UUID, KdfArgon2) {
-			// Argon 2
-			transformedKey = argon2.DKey(
-				transformedKey, // Master key
-				db.Header.FileHeaders.KdfParameters.Salt[:],             // Salt
-				uint32(db.Header.FileHeaders.KdfParameters.Iterations),  // Time cost
-				uint32(db.Header.FileHeaders.KdfParameters.Memory)/1024, // Memory cost
-				uint8(db.Header.FileHeaders.KdfParameters.Parallelism),  // Parallelism
-				32, // Hash length
+		kdfParameters := db.Header.FileHeaders.KdfParameters
+		if kdfParameters == nil {
+			return nil, ErrRequiredAttributeMissing("KdfParameters")
+		}
+		if err := kdfParameters.validate(nil); err != nil {
+			return nil, err
+		}
+
+		if kdfParameters.IsArgon2() {
+			derive := argon2.DKey
+			if reflect.DeepEqual(kdfParameters.UUID, KdfArgon2id) {
+				derive = argon2.IDKey
+			}
+
+			// Argon2 can not be interrupted, it is only reported as started and completed
+			progress.report(ProgressKdf, 0, kdfParameters.Iterations)
+			transformedKey = derive(
+				transformedKey,                    // Master key
+				kdfParameters.Salt[:],             // Salt
+				uint32(kdfParameters.Iterations),  // Time cost
+				uint32(kdfParameters.Memory/1024), // Memory cost
+				uint8(kdfParameters.Parallelism),  // Parallelism
+				32,                                // Hash length
 			)
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			progress.report(ProgressKdf, kdfParameters.Iterations, kdfParameters.Iterations)
 		} else {
 			// AES
-			key, err := cryptAESKey(
-				transformedKey,
-				db.Header.FileHeaders.KdfParameters.Salt[:],
-				db.Header.FileHeaders.KdfParameters.Rounds,
-			)
+			key, err := cryptAESKeyContext(ctx, transformedKey, kdfParameters.Salt[:], kdfParameters.Rounds, progress)
 			if err != nil {
 				return nil, err
 			}
@@ -82,10 +108,12 @@ func (c *DBCredentials) buildTransformedKey(db *Database) ([]byte, error) {
 		}
 	} else {
 		// AES
-		key, err := cryptAESKey(
+		key, err := cryptAESKeyContext(
+			ctx,
 			transformedKey,
 			db.Header.FileHeaders.TransformSeed,
 			db.Header.FileHeaders.TransformRounds,
+			progress,
 		)
 		if err != nil {
 			return nil, err
@@ -114,6 +142,17 @@ func buildHmacKey(db *Database, transformedKey []byte) []byte {
 }
 
 func cryptAESKey(masterKey []byte, seed []byte, rounds uint64) ([]byte, error) {
+	return cryptAESKeyContext(context.Background(), masterKey, seed, rounds, nil)
+}
+
+// cryptAESKeyContext is cryptAESKey checking ctx and reporting the progress every aesKdfCheckRounds rounds
+func cryptAESKeyContext(
+	ctx context.Context,
+	masterKey []byte,
+	seed []byte,
+	rounds uint64,
+	progress ProgressFunc,
+) ([]byte, error) {
 	block, err := aes.NewCipher(seed)
 	if err != nil {
 		return nil, err
@@ -122,10 +161,17 @@ func cryptAESKey(masterKey []byte, seed []byte, rounds uint64) ([]byte, error) {
 	newKey := make([]byte, len(masterKey))
 	copy(newKey, masterKey)
 
-	for range rounds {
+	for round := range rounds {
+		if round%aesKdfCheckRounds == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			progress.report(ProgressKdf, round, rounds)
+		}
 		block.Encrypt(newKey, newKey)
 		block.Encrypt(newKey[16:], newKey[16:])
 	}
+	progress.report(ProgressKdf, rounds, rounds)
 
 	hash := sha256.Sum256(newKey)
 	return hash[:], nil
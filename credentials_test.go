@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+
+	"github.com/tobischo/argon2"
 )
 
 func TestCryptAESKey(t *testing.T) {
@@ -200,3 +202,48 @@ func BenchmarkCryptAESKey(b *testing.B) {
 		})
 	}
 }
+
+func TestBuildTransformedKeyArgon2(t *testing.T) {
+	cases := []struct {
+		title   string
+		options []FileHeadersOption
+		derive  func(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte
+	}{
+		{
+			title:  "with Argon2d",
+			derive: argon2.DKey,
+		},
+		{
+			title:   "with Argon2id",
+			options: []FileHeadersOption{WithFileHeadersKdfArgon2id()},
+			derive:  argon2.IDKey,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := NewDatabase(WithDatabaseKDBXVersion4(c.options...))
+			db.Credentials = NewPasswordCredentials(password)
+
+			result, err := db.getTransformedKey()
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			compositeKey, _ := db.Credentials.buildCompositeKey()
+			kdfParameters := db.Header.FileHeaders.KdfParameters
+			expectedResult := c.derive(
+				compositeKey,
+				kdfParameters.Salt[:],
+				uint32(kdfParameters.Iterations),
+				uint32(kdfParameters.Memory)/1024,
+				uint8(kdfParameters.Parallelism),
+				32,
+			)
+
+			if !bytes.Equal(result, expectedResult) {
+				t.Errorf("Received % X, expected % X", result, expectedResult)
+			}
+		})
+	}
+}
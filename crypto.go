@@ -3,6 +3,7 @@ package gokeepasslib
 import (
 	"bytes"
 	"errors"
+	"io"
 
 	"github.com/tobischo/gokeepasslib/v3/crypto"
 )
@@ -26,6 +27,14 @@ type Encrypter interface {
 	Encrypt(data []byte) []byte
 }
 
+// StreamEncrypter is an Encrypter which is able to decrypt and encrypt streams of data
+// without holding all of it in memory
+type StreamEncrypter interface {
+	Encrypter
+	DecryptReader(r io.Reader) io.Reader
+	EncryptWriter(w io.Writer) io.WriteCloser
+}
+
 // StreamManager is the manager to handle a Stream
 type StreamManager struct {
 	Stream Stream
@@ -119,6 +128,58 @@ func (em *EncrypterManager) Encrypt(data []byte) []byte {
 	return em.Encrypter.Encrypt(data)
 }
 
+// DecryptReader returns a reader which decrypts the data read from r.
+// Encrypters which do not implement StreamEncrypter decrypt all data at once.
+func (em *EncrypterManager) DecryptReader(r io.Reader) io.Reader {
+	if encrypter, ok := em.Encrypter.(StreamEncrypter); ok {
+		return encrypter.DecryptReader(r)
+	}
+	return &bufferedDecryptReader{encrypter: em.Encrypter, r: r}
+}
+
+// EncryptWriter returns a writer which encrypts the data written to it into w.
+// Encrypters which do not implement StreamEncrypter encrypt all data at once on Close.
+func (em *EncrypterManager) EncryptWriter(w io.Writer) io.WriteCloser {
+	if encrypter, ok := em.Encrypter.(StreamEncrypter); ok {
+		return encrypter.EncryptWriter(w)
+	}
+	return &bufferedEncryptWriter{encrypter: em.Encrypter, w: w}
+}
+
+// bufferedDecryptReader reads all data on the first Read and decrypts it at once
+type bufferedDecryptReader struct {
+	encrypter Encrypter
+	r         io.Reader
+	decrypted io.Reader
+}
+
+func (br *bufferedDecryptReader) Read(p []byte) (int, error) {
+	if br.decrypted == nil {
+		data, err := io.ReadAll(br.r)
+		if err != nil {
+			return 0, err
+		}
+		br.decrypted = bytes.NewReader(br.encrypter.Decrypt(data))
+	}
+	return br.decrypted.Read(p)
+}
+
+// bufferedEncryptWriter collects all data and encrypts it at once on Close
+type bufferedEncryptWriter struct {
+	encrypter Encrypter
+	w         io.Writer
+	buffer    bytes.Buffer
+}
+
+func (bw *bufferedEncryptWriter) Write(p []byte) (int, error) {
+	return bw.buffer.Write(p)
+}
+
+func (bw *bufferedEncryptWriter) Close() error {
+	_, err := bw.w.Write(bw.encrypter.Encrypt(bw.buffer.Bytes()))
+	return err
+}
+
 // Unpack returns the payload as unencrypted byte array
 func (cs *StreamManager) Unpack(payload string) []byte {
 	return cs.Stream.Unpack(payload)
@@ -3,6 +3,7 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"io"
 )
 
 // AESEncrypter is an AES cipher that implements Encrypter interface
@@ -17,6 +18,9 @@ func NewAESEncrypter(key []byte, iv []byte) (*AESEncrypter, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(iv) != block.BlockSize() {
+		return nil, ErrInvalidIVLength
+	}
 
 	e := AESEncrypter{
 		block:        block,
@@ -40,3 +44,14 @@ func (ae *AESEncrypter) Encrypt(data []byte) []byte {
 	mode.CryptBlocks(ret, data)
 	return ret
 }
+
+// DecryptReader returns a reader which decrypts the data read from r and removes its padding
+func (ae *AESEncrypter) DecryptReader(r io.Reader) io.Reader {
+	return newCBCReader(cipher.NewCBCDecrypter(ae.block, ae.encryptionIV), r)
+}
+
+// EncryptWriter returns a writer which encrypts the data written to it into w.
+// The data has to be padded to the block size before closing the writer.
+func (ae *AESEncrypter) EncryptWriter(w io.Writer) io.WriteCloser {
+	return newCBCWriter(cipher.NewCBCEncrypter(ae.block, ae.encryptionIV), w)
+}
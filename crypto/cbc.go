@@ -0,0 +1,145 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+)
+
+// cbcChunkSize is the amount of data which is read and decrypted at once
+const cbcChunkSize = 64 * 1024
+
+// ErrIncompleteBlock is returned if the encrypted stream does not end on a block boundary
+var ErrIncompleteBlock = errors.New("crypto: input not a multiple of the block size")
+
+// ErrInvalidPadding is returned if the decrypted data does not end with a valid PKCS#7 padding
+var ErrInvalidPadding = errors.New("crypto: invalid padding")
+
+// ErrInvalidIVLength is returned if the IV of a cipher does not match its block or nonce size
+var ErrInvalidIVLength = errors.New("crypto: invalid IV length")
+
+// cbcReader decrypts the data of a reader block by block
+type cbcReader struct {
+	mode    cipher.BlockMode
+	r       io.Reader
+	buffer  []byte
+	pending []byte // Read but not yet decrypted data, less than one block
+	out     []byte // Decrypted but not yet returned data
+	err     error
+}
+
+func newCBCReader(mode cipher.BlockMode, r io.Reader) *cbcReader {
+	return &cbcReader{
+		mode:   mode,
+		r:      r,
+		buffer: make([]byte, cbcChunkSize),
+	}
+}
+
+// Read returns the decrypted data without the PKCS#7 padding, which is verified at the end of the data
+func (cr *cbcReader) Read(p []byte) (int, error) {
+	blockSize := cr.mode.BlockSize()
+
+	for len(cr.out) == 0 {
+		if cr.err != nil {
+			if errors.Is(cr.err, io.EOF) && len(cr.pending) > 0 {
+				return 0, ErrIncompleteBlock
+			}
+			return 0, cr.err
+		}
+
+		n := copy(cr.buffer, cr.pending)
+		read, err := cr.r.Read(cr.buffer[n:])
+		n += read
+		cr.err = err
+
+		complete := n - n%blockSize
+		if err == nil && complete > 0 {
+			// Keep the last block until the end of the data is known, it contains the padding
+			complete -= blockSize
+		}
+		cr.pending = append(cr.pending[:0], cr.buffer[complete:n]...)
+		cr.mode.CryptBlocks(cr.buffer[:complete], cr.buffer[:complete])
+		cr.out = cr.buffer[:complete]
+
+		if errors.Is(err, io.EOF) && len(cr.pending) == 0 {
+			if cr.out, err = unpad(cr.out, blockSize); err != nil {
+				cr.err = err
+			}
+		}
+	}
+
+	n := copy(p, cr.out)
+	cr.out = cr.out[n:]
+	return n, nil
+}
+
+// unpad returns data without its PKCS#7 padding
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > blockSize || padding > len(data) {
+		return nil, ErrInvalidPadding
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return data[:len(data)-padding], nil
+}
+
+// cbcWriter encrypts the written data block by block.
+// The written data has to be padded to the block size before closing the writer.
+type cbcWriter struct {
+	mode    cipher.BlockMode
+	w       io.Writer
+	pending []byte
+}
+
+func newCBCWriter(mode cipher.BlockMode, w io.Writer) *cbcWriter {
+	return &cbcWriter{
+		mode: mode,
+		w:    w,
+	}
+}
+
+// Write encrypts all complete blocks of p and writes them to the underlying writer
+func (cw *cbcWriter) Write(p []byte) (int, error) {
+	blockSize := cw.mode.BlockSize()
+
+	cw.pending = append(cw.pending, p...)
+	complete := len(cw.pending) - len(cw.pending)%blockSize
+	if complete == 0 {
+		return len(p), nil
+	}
+
+	encrypted := make([]byte, complete)
+	cw.mode.CryptBlocks(encrypted, cw.pending[:complete])
+	cw.pending = append(cw.pending[:0], cw.pending[complete:]...)
+
+	if _, err := cw.w.Write(encrypted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close returns an error if the written data did not end on a block boundary
+func (cw *cbcWriter) Close() error {
+	if len(cw.pending) > 0 {
+		return ErrIncompleteBlock
+	}
+	return nil
+}
+
+// streamWriteCloser adds a no-op Close to a cipher.StreamWriter,
+// which would otherwise close the underlying writer
+type streamWriteCloser struct {
+	cipher.StreamWriter
+}
+
+func (sw streamWriteCloser) Close() error {
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/cipher"
 	"crypto/sha512"
 	"encoding/base64"
+	"io"
 
 	"golang.org/x/crypto/chacha20"
 )
@@ -15,6 +16,10 @@ type ChaChaStream struct {
 
 // NewChaChaEncrypter initialize a new ChaChaStream interfaced with Encrypter
 func NewChaChaEncrypter(key []byte, iv []byte) (*ChaChaStream, error) {
+	if len(iv) != chacha20.NonceSize {
+		return nil, ErrInvalidIVLength
+	}
+
 	cipher, err := chacha20.NewUnauthenticatedCipher(key, iv)
 	if err != nil {
 		return nil, err
@@ -53,6 +58,16 @@ func (cs *ChaChaStream) Encrypt(data []byte) []byte {
 	return cs.Decrypt(data)
 }
 
+// DecryptReader returns a reader which decrypts the data read from r
+func (cs *ChaChaStream) DecryptReader(r io.Reader) io.Reader {
+	return cipher.StreamReader{S: cs.cipher, R: r}
+}
+
+// EncryptWriter returns a writer which encrypts the data written to it into w
+func (cs *ChaChaStream) EncryptWriter(w io.Writer) io.WriteCloser {
+	return streamWriteCloser{cipher.StreamWriter{S: cs.cipher, W: w}}
+}
+
 // Unpack returns the payload as unencrypted byte array
 func (cs *ChaChaStream) Unpack(payload string) []byte {
 	decoded, _ := base64.StdEncoding.DecodeString(payload)
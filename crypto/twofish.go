@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"crypto/cipher"
+	"io"
 
 	"golang.org/x/crypto/twofish" //nolint:staticcheck
 )
@@ -18,6 +19,9 @@ func NewTwoFishEncrypter(key []byte, iv []byte) (*TwoFishEncrypter, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(iv) != block.BlockSize() {
+		return nil, ErrInvalidIVLength
+	}
 
 	e := TwoFishEncrypter{
 		block:        block,
@@ -41,3 +45,14 @@ func (tfe *TwoFishEncrypter) Encrypt(data []byte) []byte {
 	mode.CryptBlocks(ret, data)
 	return ret
 }
+
+// DecryptReader returns a reader which decrypts the data read from r and removes its padding
+func (tfe *TwoFishEncrypter) DecryptReader(r io.Reader) io.Reader {
+	return newCBCReader(cipher.NewCBCDecrypter(tfe.block, tfe.encryptionIV), r)
+}
+
+// EncryptWriter returns a writer which encrypts the data written to it into w.
+// The data has to be padded to the block size before closing the writer.
+func (tfe *TwoFishEncrypter) EncryptWriter(w io.Writer) io.WriteCloser {
+	return newCBCWriter(cipher.NewCBCEncrypter(tfe.block, tfe.encryptionIV), w)
+}
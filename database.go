@@ -1,6 +1,7 @@
 package gokeepasslib
 
 import (
+	"context"
 	"errors"
 )
 
@@ -16,11 +17,22 @@ type Database struct {
 	Header      *DBHeader
 	Hashes      *DBHashes
 	Content     *DBContent
+
+	transformedKey    *transformedKeyCache // Kept after decoding and encoding, see Wipe
+	protectedUnlocked bool                 // Whether the protected values are unlocked by UnlockProtectedEntries
 }
 
 // DBOptions stores options for database decoding/encoding
 type DBOptions struct {
 	ValidateHashes bool // True to validate header hash
+	ReuseSeeds     bool // True to keep the seeds, IVs and stream keys when encoding, e.g. for deterministic tests
+
+	// Limits of values read from a database when decoding, 0 means no limit
+	MaxHeaderFieldSize uint64 // Maximum size of a header or inner header field in bytes, except binaries
+	MaxBinarySize      uint64 // Maximum size of a binary in the inner header (Kdbx v4) in bytes
+	MaxKdfMemory       uint64 // Maximum memory of Argon2 in bytes
+	MaxKdfIterations   uint64 // Maximum iterations of Argon2
+	MaxKdfRounds       uint64 // Maximum rounds of AES-KDF
 }
 
 type DatabaseOption func(*Database)
@@ -37,9 +49,16 @@ func WithDatabaseKDBXVersion3() DatabaseOption {
 	}
 }
 
-func WithDatabaseKDBXVersion4() DatabaseOption {
+func WithDatabaseKDBXVersion4(options ...FileHeadersOption) DatabaseOption {
+	return func(db *Database) {
+		db.Header = NewKDBX4Header(options...)
+		withDBContentKDBX4InnerHeader(db.Content)
+	}
+}
+
+func WithDatabaseKDBXVersion41(options ...FileHeadersOption) DatabaseOption {
 	return func(db *Database) {
-		db.Header = NewKDBX4Header()
+		db.Header = NewKDBX41Header(options...)
 		withDBContentKDBX4InnerHeader(db.Content)
 	}
 }
@@ -71,7 +90,12 @@ func NewDatabase(options ...DatabaseOption) *Database {
 // NewOptions creates new options with default values
 func NewOptions() *DBOptions {
 	return &DBOptions{
-		ValidateHashes: true,
+		ValidateHashes:     true,
+		MaxHeaderFieldSize: DefaultMaxHeaderFieldSize,
+		MaxBinarySize:      DefaultMaxBinarySize,
+		MaxKdfMemory:       DefaultMaxKdfMemory,
+		MaxKdfIterations:   DefaultMaxKdfIterations,
+		MaxKdfRounds:       DefaultMaxKdfRounds,
 	}
 }
 
@@ -83,10 +107,33 @@ func (db *Database) ensureKdbxFormatVersion() {
 
 // getTransformedKey returns the transformed key Credentials
 func (db *Database) getTransformedKey() ([]byte, error) {
+	return db.getTransformedKeyContext(context.Background(), nil)
+}
+
+// getTransformedKeyContext returns the transformed key Credentials, the key derivation is canceled with ctx.
+// The key is kept and reused as long as the credentials and key derivation parameters are unchanged.
+func (db *Database) getTransformedKeyContext(ctx context.Context, progress ProgressFunc) ([]byte, error) {
 	if db.Credentials == nil {
 		return nil, ErrRequiredAttributeMissing("Credentials")
 	}
-	return db.Credentials.buildTransformedKey(db)
+
+	source, err := db.transformedKeySource()
+	if err != nil {
+		return nil, err
+	}
+	if key := db.transformedKey.get(source); key != nil {
+		total := db.Header.kdfProgressTotal()
+		progress.report(ProgressKdf, total, total)
+		return key, nil
+	}
+
+	key, err := db.Credentials.buildTransformedKey(ctx, db, progress)
+	if err != nil {
+		return nil, err
+	}
+	db.Wipe()
+	db.transformedKey = newTransformedKeyCache(key, source)
+	return key, nil
 }
 
 // GetEncrypterManager returns an EncryptManager based on the master key and EncryptionIV,
@@ -144,6 +191,7 @@ func (db *Database) UnlockProtectedEntries() error {
 		return ErrUnsupportedStreamType
 	}
 	manager.UnlockProtectedGroups(db.Content.Root.Groups)
+	db.protectedUnlocked = true
 	return nil
 }
 
@@ -157,6 +205,7 @@ func (db *Database) LockProtectedEntries() error {
 		return err
 	}
 	manager.LockProtectedGroups(db.Content.Root.Groups)
+	db.protectedUnlocked = false
 	return nil
 }
 
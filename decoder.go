@@ -1,39 +1,97 @@
 package gokeepasslib
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
-	"reflect"
 )
 
+// ErrHeaderHashMismatch is the error returned if the header hash stored in the meta data (Kdbx v3.1)
+// does not match the header
+var ErrHeaderHashMismatch = errors.New("gokeepasslib: header hash in meta data mismatching")
+
 var (
 	errInvalidHMACKey          = errors.New("Wrong password? HMAC-SHA256 of header mismatching")
 	errDatabaseIntegrityFailed = errors.New("Wrong password? Database integrity check failed")
+	errTrailingContent         = errors.New("gokeepasslib: unexpected data after the end of the content")
 )
 
 // Decoder stores a reader which is expected to be in kdbx format
 type Decoder struct {
-	r io.Reader
+	r        io.Reader
+	progress ProgressFunc
+	rawData  bool
+}
+
+// DecoderOption is the option function type for use with NewDecoder
+type DecoderOption func(*Decoder)
+
+// WithDecoderProgress sets a function which is called with the progress of decoding
+func WithDecoderProgress(progress ProgressFunc) DecoderOption {
+	return func(d *Decoder) {
+		d.progress = progress
+	}
 }
 
-// NewDecoder creates a new decoder with reader r, identical to gokeepasslib.Decoder{r}
-func NewDecoder(r io.Reader) *Decoder {
-	return &Decoder{r: r}
+// WithDecoderRawData keeps the decrypted and decompressed content in DBContent.RawData.
+// It is not kept by default, as the content is decoded while it is read.
+func WithDecoderRawData(keep bool) DecoderOption {
+	return func(d *Decoder) {
+		d.rawData = keep
+	}
 }
 
-// Decode populates given database with the data of Decoder reader
+// NewDecoder creates a new decoder with reader r
+func NewDecoder(r io.Reader, options ...DecoderOption) *Decoder {
+	d := &Decoder{r: r}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// Decode populates given database with the data of Decoder reader.
+// Errors reading the database are returned as DecodeError, see ErrInvalidCredentials for its kinds.
+// The transformed key is kept in db for encoding until Database.Wipe is called.
 func (d *Decoder) Decode(db *Database) error {
+	return d.DecodeContext(context.Background(), db)
+}
+
+// DecodeContext is Decode, which returns the error of ctx once it is done.
+// ctx is checked during the key derivation and between the reads of the content.
+func (d *Decoder) DecodeContext(ctx context.Context, db *Database) error {
+	if err := d.decode(ctx, db); err != nil {
+		// Keep no key derived from credentials which failed to decode the database
+		db.Wipe()
+		return newDecodeError(err)
+	}
+	return nil
+}
+
+func (d *Decoder) decode(ctx context.Context, db *Database) (err error) {
+	if db.Options == nil {
+		db.Options = NewOptions()
+	}
+
 	// Read header
 	db.Header = new(DBHeader)
-	if err := db.Header.readFrom(d.r); err != nil {
+	if err := db.Header.readFrom(d.r, db.Options); err != nil {
+		return err
+	}
+
+	if err := db.Header.checkKdfLimits(db.Options); err != nil {
 		return err
 	}
 
 	// Calculate transformed key to decrypt and calculate HMAC
-	transformedKey, err := db.getTransformedKey()
+	transformedKey, err := db.getTransformedKeyContext(ctx, d.progress)
 	if err != nil {
 		return err
 	}
@@ -41,112 +99,225 @@ func (d *Decoder) Decode(db *Database) error {
 	// Read hashes and validate them (Kdbx v4)
 	if db.Header.IsKdbx4() {
 		db.Hashes = new(DBHashes)
-		err := db.Hashes.readFrom(d.r)
+		err = db.Hashes.readFrom(d.r)
 		if err != nil {
 			return err
 		}
 
+		hmacKey := buildHmacKey(db, transformedKey)
+		hmacErr := db.Header.ValidateHmacSha256(hmacKey, db.Hashes.Hmac)
 		if db.Options.ValidateHashes {
 			err = db.Header.ValidateSha256(db.Hashes.Sha256)
 			if err != nil {
 				return err
 			}
-
-			hmacKey := buildHmacKey(db, transformedKey)
-			err = db.Header.ValidateHmacSha256(hmacKey, db.Hashes.Hmac)
-			if err != nil {
+			if hmacErr != nil {
 				return errInvalidHMACKey
 			}
+		} else if hmacErr != nil {
+			// The header HMAC is not validated, but if the HMAC of the first block mismatches too,
+			// the key is wrong instead of the content being corrupted
+			defer func() {
+				var blockHMAC ErrBlockHMACMismatch
+				if errors.As(err, &blockHMAC) && blockHMAC.Index == 0 {
+					err = fmt.Errorf("%w: %w", errInvalidHMACKey, err)
+				}
+			}()
 		}
 	}
 
-	// Decode raw content
-	rawContent, _ := io.ReadAll(d.r)
+	// Initialize content
+	db.Content = new(DBContent)
+	db.protectedUnlocked = false
+
+	// Decode the content while reading it
+	contentReader, err := newContentReader(ctx, db, d.r, transformedKey, d.progress)
 	if err != nil {
 		return err
 	}
 
-	if err := decodeRawContent(db, rawContent, transformedKey); err != nil {
-		return err
+	var rawData bytes.Buffer
+	if d.rawData {
+		contentReader.Reader = io.TeeReader(contentReader.Reader, &rawData)
 	}
 
-	contentReader := bytes.NewReader(db.Content.RawData)
-
 	// Read InnerHeader (Kdbx v4)
 	if db.Header.IsKdbx4() {
 		db.Content.InnerHeader = new(InnerHeader)
-		err = db.Content.InnerHeader.readFrom(contentReader)
+		err = db.Content.InnerHeader.readFrom(contentReader, db.Options)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Check the inner stream before decoding the protected values
+	if _, err := db.GetStreamManager(); err != nil {
+		return err
+	}
+
 	// Decode xml
-	xmlDecoder := xml.NewDecoder(contentReader)
-	return xmlDecoder.Decode(db.Content)
+	xmlDecoder := xml.NewDecoder(contentReader.track(ProgressParse, 0))
+	if err := xmlDecoder.Decode(db.Content); err != nil {
+		// Errors of the underlying readers and ctx keep their kind
+		if decodeErrorKind(err) != nil || ctx.Err() != nil {
+			return err
+		}
+		return &DecodeError{Kind: ErrInvalidXML, Err: err}
+	}
+
+	// Verify the end of the content, which is not required to decode the XML
+	if err := contentReader.drain(); err != nil {
+		return err
+	}
+	contentReader.finish()
+
+	if d.rawData {
+		db.Content.RawData = rawData.Bytes()
+	}
+
+	if db.Options.ValidateHashes {
+		return db.validateHeaderHash()
+	}
+	return nil
 }
 
-func decodeRawContent(db *Database, content []byte, transformedKey []byte) error {
-	var err error
-	// Initialize content
-	db.Content = new(DBContent)
+// validateHeaderHash compares the header hash stored in the meta data (Kdbx v3.1) with the hash of the header,
+// it is not checked if the meta data does not contain a header hash
+func (db *Database) validateHeaderHash() error {
+	if db.Content.Meta == nil || db.Content.Meta.HeaderHash == "" {
+		return nil
+	}
 
-	if db.Header.IsKdbx4() {
-		// Decompose content blocks
-		// In Kdbx v4 you must parse blocks before decrypt
-		reader := bytes.NewReader(content)
-		content, err = decomposeContentBlocks4(reader, db.Header.FileHeaders.MasterSeed, transformedKey)
+	hash, err := base64.StdEncoding.DecodeString(db.Content.Meta.HeaderHash)
+	if err != nil || len(hash) != sha256.Size {
+		return ErrHeaderHashMismatch
+	}
+	if db.Header.GetSha256() != [sha256.Size]byte(hash) {
+		return ErrHeaderHashMismatch
+	}
+	return nil
+}
+
+// contentReader is the chain of readers which are necessary to decode the content
+type contentReader struct {
+	io.Reader
+	ctx      context.Context
+	progress ProgressFunc
+	trackers []*progressReader
+	trailers []io.Reader // Readers which must not have any data left once the reader above them ends
+	padded   bool        // Whether the first trailer may end with padding, which is not removed by stream ciphers
+}
+
+// track reports the progress of the data read from the current reader as phase
+func (cr *contentReader) track(phase ProgressPhase, total uint64) io.Reader {
+	tracker := newProgressReader(cr.ctx, cr.Reader, phase, total, cr.progress)
+	cr.Reader = tracker
+	cr.trackers = append(cr.trackers, tracker)
+	return cr
+}
+
+// finish reports every tracked phase as completed
+func (cr *contentReader) finish() {
+	for _, tracker := range cr.trackers {
+		tracker.finish()
+	}
+}
+
+// drain reads the rest of the content, so that its end is verified:
+// the gzip trailer, the final block, the padding and that there is no data after them
+func (cr *contentReader) drain() error {
+	if _, err := io.Copy(io.Discard, cr.Reader); err != nil {
+		return err
+	}
+	for i := len(cr.trailers) - 1; i >= 0; i-- {
+		rest, err := io.ReadAll(io.LimitReader(cr.trailers[i], maxPaddingLength+1))
 		if err != nil {
 			return err
 		}
-	} else {
-		// In Kdbx v3.1 you must decrypt before parse blocks
-		reader := bytes.NewReader(content)
-		content, err = io.ReadAll(reader)
-		if err != nil {
-			return err
+		if len(rest) > 0 && !(i == 0 && cr.padded && isPadding(rest)) {
+			return errTrailingContent
 		}
 	}
+	return nil
+}
+
+// isPadding returns whether data is the padding written by the encoder
+func isPadding(data []byte) bool {
+	if len(data) > maxPaddingLength {
+		return false
+	}
+	for _, b := range data {
+		if int(b) != len(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// newContentReader chains the readers which are necessary to decode the content:
+// blocks (Kdbx v4), decryption, blocks (Kdbx v3.1) and decompression
+func newContentReader(
+	ctx context.Context,
+	db *Database,
+	r io.Reader,
+	transformedKey []byte,
+	progress ProgressFunc,
+) (*contentReader, error) {
+	cr := &contentReader{
+		Reader:   r,
+		ctx:      ctx,
+		progress: progress,
+		padded:   bytes.Equal(db.Header.FileHeaders.CipherID, CipherChaCha20),
+	}
+	cr.track(ProgressDecrypt, remainingSize(r))
+	r = cr.Reader
+
+	// In Kdbx v4 you must parse blocks before decrypt
+	if db.Header.IsKdbx4() {
+		r = newBlockReader4(r, db.Header.FileHeaders.MasterSeed, transformedKey)
+	}
 
 	// Decrypt content
 	encrypter, err := db.GetEncrypterManager(transformedKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	decryptedContent := encrypter.Decrypt(content)
+	r = encrypter.DecryptReader(r)
 
-	// Check for StreamStartBytes (Kdbx v3.1)
+	// In Kdbx v3.1 you must decrypt before parse blocks
 	if !db.Header.IsKdbx4() {
+		// Check for StreamStartBytes
 		startBytes := db.Header.FileHeaders.StreamStartBytes
-		if !reflect.DeepEqual(decryptedContent[0:len(startBytes)], startBytes) {
-			return errDatabaseIntegrityFailed
+		decryptedStartBytes := make([]byte, len(startBytes))
+		if _, err := io.ReadFull(r, decryptedStartBytes); err != nil {
+			return nil, err
 		}
-
-		decryptedContent = decryptedContent[len(startBytes):]
-	}
-
-	// Decompose content blocks and update reader
-	// Kdbx v3.1 content must be read after decryption
-	if !db.Header.IsKdbx4() {
-		reader := bytes.NewReader(decryptedContent)
-		decryptedContent, err = decomposeContentBlocks31(reader)
-		if err != nil {
-			return err
+		if !bytes.Equal(decryptedStartBytes, startBytes) {
+			return nil, errDatabaseIntegrityFailed
 		}
+
+		cr.trailers = append(cr.trailers, r)
+		r = newBlockReader31(r, db.Options.ValidateHashes)
 	}
 
 	// Decompress if the header compression flag is 1 (gzip)
 	if db.Header.FileHeaders.CompressionFlags == GzipCompressionFlag {
-		reader := bytes.NewReader(decryptedContent)
-		r, err := gzip.NewReader(reader)
+		cr.Reader = r
+		cr.track(ProgressDecompress, 0)
+		// gzip reads ahead from a reader which is no io.ByteReader,
+		// buffering it here keeps the data read after the gzip member to be checked as trailer
+		buffered := bufio.NewReader(cr.Reader)
+		cr.trailers = append(cr.trailers, buffered)
+		gzipReader, err := gzip.NewReader(buffered)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer r.Close()
+		// Data after the compressed data must not be read as another gzip member
+		gzipReader.Multistream(false)
 
-		decryptedContent, _ = io.ReadAll(r)
+		r = gzipReader
 	}
 
-	db.Content.RawData = decryptedContent
-	return nil
+	cr.Reader = r
+	return cr, nil
 }
@@ -1,9 +1,16 @@
 package gokeepasslib
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
 	"io"
 	"os"
+	"slices"
 	"testing"
+
+	"github.com/tobischo/gokeepasslib/v3/crypto"
 )
 
 func TestDecodeFile(t *testing.T) {
@@ -192,3 +199,275 @@ func TestDecodeFile(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeHeaderHash(t *testing.T) {
+	db := NewDatabase()
+	db.Credentials = NewPasswordCredentials(password)
+	db.Header.FileHeaders.Comment = []byte("comment")
+
+	var buffer bytes.Buffer
+	if err := NewEncoder(&buffer).Encode(db); err != nil {
+		t.Fatalf("Failed to encode database: %s", err)
+	}
+
+	// Modify the header, which is only protected by the header hash in the meta data
+	encoded := buffer.Bytes()
+	encoded[bytes.Index(encoded, []byte("comment"))] = 'C'
+
+	cases := []struct {
+		title          string
+		validateHashes bool
+		expected       error
+	}{
+		{title: "with validation", validateHashes: true, expected: ErrHeaderHashMismatch},
+		{title: "without validation", validateHashes: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			decoded := NewDatabase()
+			decoded.Credentials = NewPasswordCredentials(password)
+			decoded.Options.ValidateHashes = c.validateHashes
+
+			err := NewDecoder(bytes.NewReader(encoded)).Decode(decoded)
+			if !errors.Is(err, c.expected) {
+				t.Errorf("Expected error %v, received %v", c.expected, err)
+			}
+		})
+	}
+}
+
+// rewriteContent31 decrypts the content of a Kdbx v3.1 database, lets modify change it
+// and encrypts it again with a new padding
+func rewriteContent31(t *testing.T, data []byte, modify func(content []byte) []byte) []byte {
+	t.Helper()
+
+	db := NewDatabase()
+	db.Credentials = NewPasswordCredentials("abcdefg12345678")
+	header, err := ReadHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	db.Header = header
+
+	transformedKey, err := db.getTransformedKey()
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	encrypter, err := db.GetEncrypterManager(transformedKey)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	content := encrypter.Decrypt(data[len(header.RawData):])
+	content = modify(content[:len(content)-int(content[len(content)-1])])
+	padding := 16 - len(content)%16
+	content = append(content, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	return append(bytes.Clone(data[:len(header.RawData)]), encrypter.Encrypt(content)...)
+}
+
+func TestDecodeContentEnd(t *testing.T) {
+	// Every Kdbx v3.1 block starts with a 4 byte index, a 32 byte hash and a 4 byte length
+	const blockHeaderSize = 40
+
+	cases := []struct {
+		title          string
+		dbFilePath     string
+		modify         func(t *testing.T, data []byte) []byte
+		validateHashes bool
+		expected       error
+	}{
+		{
+			title:      "final block removed for Database Format v4",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				return data[:len(data)-36]
+			},
+			validateHashes: true,
+			expected:       io.ErrUnexpectedEOF,
+		},
+		{
+			title:      "final block modified for Database Format v4",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				data[len(data)-36] ^= 0x01
+				return data
+			},
+			validateHashes: true,
+			expected:       ErrBlockHMACMismatch{Index: 1},
+		},
+		{
+			title:      "final block modified for Database Format v3.1",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				return rewriteContent31(t, data, func(content []byte) []byte {
+					// Last byte of the empty hash of the final block
+					content[len(content)-5] ^= 0x01
+					return content
+				})
+			},
+			validateHashes: true,
+			expected:       ErrBlockHashMismatch{Index: 1},
+		},
+		{
+			title:      "final block removed for Database Format v3.1",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				return rewriteContent31(t, data, func(content []byte) []byte {
+					return content[:len(content)-blockHeaderSize]
+				})
+			},
+			validateHashes: true,
+			expected:       io.ErrUnexpectedEOF,
+		},
+		{
+			title:      "last block modified for Database Format v3.1",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				return rewriteContent31(t, data, func(content []byte) []byte {
+					content[len(content)-blockHeaderSize-1] ^= 0x01
+					return content
+				})
+			},
+			validateHashes: true,
+			expected:       ErrBlockHashMismatch{Index: 0},
+		},
+		{
+			title:      "padding modified",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				// Flips the bit of the last decrypted byte, which belongs to the padding
+				data[len(data)-17] ^= 0x01
+				return data
+			},
+			expected: crypto.ErrInvalidPadding,
+		},
+		{
+			title:      "padding truncated",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				return data[:len(data)-4]
+			},
+			validateHashes: true,
+			expected:       crypto.ErrIncompleteBlock,
+		},
+		{
+			title:      "gzip trailer modified",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				return rewriteContent31(t, data, func(content []byte) []byte {
+					// Last byte of the data of the last block before the final block
+					content[len(content)-blockHeaderSize-1] ^= 0x01
+					return content
+				})
+			},
+			expected: gzip.ErrChecksum,
+		},
+		{
+			title:      "data after the gzip member",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				return rewriteContent31(t, data, func(content []byte) []byte {
+					// Appends data to the only block after the stream start bytes, which holds the gzip member
+					lengthOffset := 32 + blockHeaderSize - 4
+					length := binary.LittleEndian.Uint32(content[lengthOffset:])
+					end := lengthOffset + 4 + int(length)
+					binary.LittleEndian.PutUint32(content[lengthOffset:], length+16)
+					return slices.Concat(content[:end], make([]byte, 16), content[end:])
+				})
+			},
+			expected: errTrailingContent,
+		},
+		{
+			title:      "data after the final block",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			modify: func(t *testing.T, data []byte) []byte {
+				return rewriteContent31(t, data, func(content []byte) []byte {
+					return append(content, 0x00)
+				})
+			},
+			validateHashes: true,
+			expected:       errTrailingContent,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			data, err := os.ReadFile(c.dbFilePath)
+			if err != nil {
+				t.Fatalf("Failed to read keepass file: %s", err)
+			}
+
+			db := NewDatabase()
+			db.Credentials = NewPasswordCredentials("abcdefg12345678")
+			db.Options.ValidateHashes = c.validateHashes
+
+			err = NewDecoder(bytes.NewReader(c.modify(t, data))).Decode(db)
+			if !errors.Is(err, c.expected) {
+				t.Errorf("Expected error %v, received %v", c.expected, err)
+			}
+		})
+	}
+}
+
+func TestContentReaderDrain(t *testing.T) {
+	cases := []struct {
+		title    string
+		trailer  []byte
+		padded   bool
+		expected error
+	}{
+		{title: "nothing after the content", padded: true},
+		{title: "padding of a stream cipher", trailer: []byte{0x03, 0x03, 0x03}, padded: true},
+		{title: "full block of padding", trailer: bytes.Repeat([]byte{0x10}, 16), padded: true},
+		{title: "data after the padding", trailer: []byte{0x02, 0x02, 0x00}, padded: true, expected: errTrailingContent},
+		{title: "padding too long", trailer: bytes.Repeat([]byte{0x11}, 17), padded: true, expected: errTrailingContent},
+		{title: "padding of a block cipher", trailer: []byte{0x01}, expected: errTrailingContent},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			cr := &contentReader{
+				Reader:   bytes.NewReader([]byte("content")),
+				trailers: []io.Reader{bytes.NewReader(c.trailer)},
+				padded:   c.padded,
+			}
+			if err := cr.drain(); !errors.Is(err, c.expected) {
+				t.Errorf("Expected error %v, received %v", c.expected, err)
+			}
+		})
+	}
+}
+
+func TestDecodeRawData(t *testing.T) {
+	cases := []struct {
+		title      string
+		dbFilePath string
+		keep       bool
+	}{
+		{title: "Database Format v3.1", dbFilePath: "tests/kdbx3/example.kdbx", keep: true},
+		{title: "Database Format v4", dbFilePath: "tests/kdbx4/example.kdbx", keep: true},
+		{title: "not kept", dbFilePath: "tests/kdbx4/example.kdbx", keep: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			file, err := os.Open(c.dbFilePath)
+			if err != nil {
+				t.Fatalf("Failed to open keepass file: %s", err)
+			}
+			defer file.Close()
+
+			db := NewDatabase()
+			db.Credentials = NewPasswordCredentials("abcdefg12345678")
+			if err := NewDecoder(file, WithDecoderRawData(c.keep)).Decode(db); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			if kept := bytes.Contains(db.Content.RawData, []byte("</KeePassFile>")); kept != c.keep {
+				t.Errorf("Expected the raw data to be kept: %t, received %d bytes", c.keep, len(db.Content.RawData))
+			}
+		})
+	}
+}
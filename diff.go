@@ -0,0 +1,447 @@
+package gokeepasslib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// redactedValue is shown instead of protected values when they are redacted
+const redactedValue = "[redacted]"
+
+// DiffOptions stores options for comparing databases
+type DiffOptions struct {
+	RedactProtected bool // True to only report that a protected value changed, without its content
+}
+
+// DiffChangeType describes how an object differs between two databases
+type DiffChangeType int
+
+const (
+	DiffAdded    DiffChangeType = iota // Object only exists in the updated database
+	DiffRemoved                        // Object only exists in the old database
+	DiffMoved                          // Object has a different parent group
+	DiffModified                       // Object has different field values
+)
+
+func (t DiffChangeType) String() string {
+	switch t {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffMoved:
+		return "moved"
+	case DiffModified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// DiffObjectType describes the kind of object a DiffItem refers to
+type DiffObjectType int
+
+const (
+	DiffObjectEntry DiffObjectType = iota
+	DiffObjectGroup
+)
+
+func (t DiffObjectType) String() string {
+	switch t {
+	case DiffObjectEntry:
+		return "entry"
+	case DiffObjectGroup:
+		return "group"
+	}
+	return "unknown"
+}
+
+// FieldChange is the change of a single field.
+// Field is a path like `Times.ExpiryTime` or `String[Password]`,
+// an empty Old or New value with Redacted=false means the field did not exist.
+type FieldChange struct {
+	Field    string
+	Old      string
+	New      string
+	Redacted bool // True if the values have been left out as they are protected
+}
+
+func (c FieldChange) String() string {
+	if c.Redacted {
+		return fmt.Sprintf("%s: %s", c.Field, redactedValue)
+	}
+	return fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// DiffItem describes a group or entry which differs between two databases
+type DiffItem struct {
+	Type      DiffChangeType
+	Object    DiffObjectType
+	UUID      UUID
+	Name      string        // Title of an entry or name of a group
+	OldParent UUID          // Parent group in the old database, zero for added objects
+	NewParent UUID          // Parent group in the updated database, zero for removed objects
+	Fields    []FieldChange // Changed fields of modified objects
+}
+
+func (i DiffItem) String() string {
+	text := fmt.Sprintf("%s %s %q (%s)", i.Object, i.Type, i.Name, diffUUID(i.UUID))
+	for _, field := range i.Fields {
+		text += "\n\t" + field.String()
+	}
+	return text
+}
+
+// DatabaseDiff is the structural difference between two databases
+type DatabaseDiff struct {
+	Meta  []FieldChange
+	Items []DiffItem
+}
+
+// IsEmpty returns whether the databases do not differ
+func (d DatabaseDiff) IsEmpty() bool {
+	return len(d.Meta) == 0 && len(d.Items) == 0
+}
+
+func (d DatabaseDiff) String() string {
+	var lines []string
+	for _, field := range d.Meta {
+		lines = append(lines, "meta data modified "+field.String())
+	}
+	for _, item := range d.Items {
+		lines = append(lines, item.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff compares db as the old version with other as the updated version.
+// Groups and entries are matched by their UUID.
+// The protected values of both databases have to be unlocked to be compared.
+func (db *Database) Diff(other *Database, opts DiffOptions) (DatabaseDiff, error) {
+	if db == nil || db.Content == nil || db.Content.Meta == nil || db.Content.Root == nil {
+		return DatabaseDiff{}, ErrRequiredAttributeMissing("Content")
+	}
+	if other == nil || other.Content == nil || other.Content.Meta == nil || other.Content.Root == nil {
+		return DatabaseDiff{}, ErrRequiredAttributeMissing("Content")
+	}
+
+	d := &differ{
+		old:     db,
+		updated: other,
+		opts:    opts,
+	}
+
+	diff := DatabaseDiff{
+		Meta: d.diffMetaData(db.Content.Meta, other.Content.Meta),
+	}
+
+	oldNodes := diffNodes{}
+	oldNodes.addGroups(db.Content.Root.Groups, UUID{})
+	newNodes := diffNodes{}
+	newNodes.addGroups(other.Content.Root.Groups, UUID{})
+
+	for _, uuid := range newNodes.order {
+		node := newNodes.nodes[uuid]
+		oldNode, ok := oldNodes.nodes[uuid]
+		if !ok {
+			diff.Items = append(diff.Items, node.item(DiffAdded, UUID{}, node.parent))
+			continue
+		}
+
+		if !oldNode.parent.Compare(node.parent) {
+			diff.Items = append(diff.Items, node.item(DiffMoved, oldNode.parent, node.parent))
+		}
+
+		var fields []FieldChange
+		if node.entry != nil && oldNode.entry != nil {
+			fields = d.diffEntry(oldNode.entry, node.entry)
+		} else if node.group != nil && oldNode.group != nil {
+			fields = d.diffGroup(oldNode.group, node.group)
+		}
+		if len(fields) > 0 {
+			item := node.item(DiffModified, oldNode.parent, node.parent)
+			item.Fields = fields
+			diff.Items = append(diff.Items, item)
+		}
+	}
+
+	for _, uuid := range oldNodes.order {
+		if _, ok := newNodes.nodes[uuid]; !ok {
+			node := oldNodes.nodes[uuid]
+			diff.Items = append(diff.Items, node.item(DiffRemoved, node.parent, UUID{}))
+		}
+	}
+
+	return diff, nil
+}
+
+// diffNode is a group or entry of a database along with its parent group
+type diffNode struct {
+	group  *Group
+	entry  *Entry
+	parent UUID
+}
+
+func (n diffNode) item(changeType DiffChangeType, oldParent, newParent UUID) DiffItem {
+	if n.entry != nil {
+		return DiffItem{
+			Type:      changeType,
+			Object:    DiffObjectEntry,
+			UUID:      n.entry.UUID,
+			Name:      n.entry.GetTitle(),
+			OldParent: oldParent,
+			NewParent: newParent,
+		}
+	}
+	return DiffItem{
+		Type:      changeType,
+		Object:    DiffObjectGroup,
+		UUID:      n.group.UUID,
+		Name:      n.group.Name,
+		OldParent: oldParent,
+		NewParent: newParent,
+	}
+}
+
+// diffNodes indexes the groups and entries of a database by their UUID, keeping the tree order
+type diffNodes struct {
+	nodes map[UUID]diffNode
+	order []UUID
+}
+
+func (n *diffNodes) add(uuid UUID, node diffNode) {
+	if n.nodes == nil {
+		n.nodes = map[UUID]diffNode{}
+	}
+	if _, ok := n.nodes[uuid]; ok {
+		return
+	}
+	n.nodes[uuid] = node
+	n.order = append(n.order, uuid)
+}
+
+func (n *diffNodes) addGroups(groups []Group, parent UUID) {
+	for i := range groups {
+		group := &groups[i]
+		n.add(group.UUID, diffNode{group: group, parent: parent})
+		for j := range group.Entries {
+			n.add(group.Entries[j].UUID, diffNode{entry: &group.Entries[j], parent: group.UUID})
+		}
+		n.addGroups(group.Groups, group.UUID)
+	}
+}
+
+type differ struct {
+	old     *Database
+	updated *Database
+	opts    DiffOptions
+}
+
+// fieldChanges collects the changes of fields
+type fieldChanges []FieldChange
+
+func (c *fieldChanges) compare(field, old, updated string) {
+	if old != updated {
+		*c = append(*c, FieldChange{Field: field, Old: old, New: updated})
+	}
+}
+
+func (c *fieldChanges) compareTime(field string, old, updated *w.TimeWrapper) {
+	c.compare(field, diffTime(old), diffTime(updated))
+}
+
+func (c *fieldChanges) compareTimes(old, updated TimeData) {
+	c.compareTime("Times.CreationTime", old.CreationTime, updated.CreationTime)
+	c.compareTime("Times.LastModificationTime", old.LastModificationTime, updated.LastModificationTime)
+	c.compareTime("Times.LastAccessTime", old.LastAccessTime, updated.LastAccessTime)
+	c.compareTime("Times.ExpiryTime", old.ExpiryTime, updated.ExpiryTime)
+	c.compare("Times.Expires", strconv.FormatBool(old.Expires.Bool), strconv.FormatBool(updated.Expires.Bool))
+	c.compare("Times.UsageCount", strconv.FormatInt(old.UsageCount, 10), strconv.FormatInt(updated.UsageCount, 10))
+	c.compareTime("Times.LocationChanged", old.LocationChanged, updated.LocationChanged)
+}
+
+func (c *fieldChanges) compareCustomData(field string, old, updated []CustomData) {
+	oldValues := map[string]string{}
+	for _, item := range old {
+		oldValues[item.Key] = item.Value
+	}
+	newValues := map[string]string{}
+	for _, item := range updated {
+		newValues[item.Key] = item.Value
+	}
+
+	for _, item := range old {
+		if _, ok := newValues[item.Key]; !ok {
+			c.compare(field+"["+item.Key+"]", item.Value, "")
+		}
+	}
+	for _, item := range updated {
+		c.compare(field+"["+item.Key+"]", oldValues[item.Key], item.Value)
+	}
+}
+
+func diffTime(t *w.TimeWrapper) string {
+	if t == nil {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+func (d *differ) diffEntry(old, updated *Entry) []FieldChange {
+	var changes fieldChanges
+
+	for _, value := range old.Values {
+		if updated.Get(value.Key) == nil {
+			changes = append(changes, d.valueChange(&value, nil))
+		}
+	}
+	for _, value := range updated.Values {
+		oldValue := old.Get(value.Key)
+		if oldValue == nil || oldValue.Value.Content != value.Value.Content {
+			changes = append(changes, d.valueChange(oldValue, &value))
+		}
+	}
+
+	changes.compare("Tags", old.Tags, updated.Tags)
+	changes.compareTimes(old.Times, updated.Times)
+
+	oldBinaries := map[string]string{}
+	for _, ref := range old.Binaries {
+		oldBinaries[ref.Name] = describeBinary(d.old, ref)
+	}
+	newBinaries := map[string]string{}
+	for _, ref := range updated.Binaries {
+		newBinaries[ref.Name] = describeBinary(d.updated, ref)
+	}
+	for _, ref := range old.Binaries {
+		if _, ok := newBinaries[ref.Name]; !ok {
+			changes.compare("Binary["+ref.Name+"]", oldBinaries[ref.Name], "")
+		}
+	}
+	for _, ref := range updated.Binaries {
+		changes.compare("Binary["+ref.Name+"]", oldBinaries[ref.Name], newBinaries[ref.Name])
+	}
+
+	changes.compareCustomData("CustomData", old.CustomData, updated.CustomData)
+
+	return changes
+}
+
+// valueChange returns the change of a string value, one of old and updated may be nil
+func (d *differ) valueChange(old, updated *ValueData) FieldChange {
+	change := FieldChange{}
+	protected := false
+	if old != nil {
+		change.Field = "String[" + old.Key + "]"
+		change.Old = old.Value.Content
+		protected = old.Value.Protected.Bool
+	}
+	if updated != nil {
+		change.Field = "String[" + updated.Key + "]"
+		change.New = updated.Value.Content
+		protected = protected || updated.Value.Protected.Bool
+	}
+
+	if protected && d.opts.RedactProtected {
+		change.Old = ""
+		change.New = ""
+		change.Redacted = true
+	}
+	return change
+}
+
+// describeBinary returns the size and a hash of the referenced binary content
+func describeBinary(db *Database, ref BinaryReference) string {
+	if db.Header == nil {
+		return fmt.Sprintf("binary %d", ref.Value.ID)
+	}
+	binary := db.FindBinary(ref.Value.ID)
+	if binary == nil {
+		return fmt.Sprintf("missing binary %d", ref.Value.ID)
+	}
+	content, err := binary.GetContentBytes()
+	if err != nil {
+		return fmt.Sprintf("invalid binary %d", ref.Value.ID)
+	}
+	hash := sha256.Sum256(content)
+	return fmt.Sprintf("%d bytes, sha256 %s", len(content), hex.EncodeToString(hash[:]))
+}
+
+func (d *differ) diffGroup(old, updated *Group) []FieldChange {
+	var changes fieldChanges
+
+	changes.compare("Name", old.Name, updated.Name)
+	changes.compare("Notes", old.Notes, updated.Notes)
+	changes.compare("IconID", strconv.FormatInt(old.IconID, 10), strconv.FormatInt(updated.IconID, 10))
+	changes.compare("Tags", old.Tags, updated.Tags)
+	changes.compareTimes(old.Times, updated.Times)
+
+	return changes
+}
+
+func (d *differ) diffMetaData(old, updated *MetaData) []FieldChange {
+	var changes fieldChanges
+
+	changes.compare("Generator", old.Generator, updated.Generator)
+	changes.compare("DatabaseName", old.DatabaseName, updated.DatabaseName)
+	changes.compare("DatabaseDescription", old.DatabaseDescription, updated.DatabaseDescription)
+	changes.compare("DefaultUserName", old.DefaultUserName, updated.DefaultUserName)
+	changes.compare("MaintenanceHistoryDays",
+		strconv.FormatInt(old.MaintenanceHistoryDays, 10), strconv.FormatInt(updated.MaintenanceHistoryDays, 10))
+	changes.compare("Color", old.Color, updated.Color)
+	changes.compareTime("MasterKeyChanged", old.MasterKeyChanged, updated.MasterKeyChanged)
+	changes.compare("MasterKeyChangeRec",
+		strconv.FormatInt(old.MasterKeyChangeRec, 10), strconv.FormatInt(updated.MasterKeyChangeRec, 10))
+	changes.compare("MasterKeyChangeForce",
+		strconv.FormatInt(old.MasterKeyChangeForce, 10), strconv.FormatInt(updated.MasterKeyChangeForce, 10))
+	changes.compare("MemoryProtection", fmt.Sprintf("%+v", old.MemoryProtection), fmt.Sprintf("%+v", updated.MemoryProtection))
+	changes.compare("RecycleBinEnabled",
+		strconv.FormatBool(old.RecycleBinEnabled.Bool), strconv.FormatBool(updated.RecycleBinEnabled.Bool))
+	changes.compare("RecycleBinUUID", diffUUID(old.RecycleBinUUID), diffUUID(updated.RecycleBinUUID))
+	changes.compare("EntryTemplatesGroup", old.EntryTemplatesGroup, updated.EntryTemplatesGroup)
+	changes.compare("HistoryMaxItems",
+		strconv.FormatInt(old.HistoryMaxItems, 10), strconv.FormatInt(updated.HistoryMaxItems, 10))
+	changes.compare("HistoryMaxSize",
+		strconv.FormatInt(old.HistoryMaxSize, 10), strconv.FormatInt(updated.HistoryMaxSize, 10))
+
+	oldIcons := map[UUID]CustomIcon{}
+	for _, icon := range old.CustomIcons {
+		oldIcons[icon.UUID] = icon
+	}
+	newIcons := map[UUID]CustomIcon{}
+	for _, icon := range updated.CustomIcons {
+		newIcons[icon.UUID] = icon
+	}
+	for _, icon := range old.CustomIcons {
+		if _, ok := newIcons[icon.UUID]; !ok {
+			changes.compare("CustomIcons["+diffUUID(icon.UUID)+"]", describeIcon(icon), "")
+		}
+	}
+	for _, icon := range updated.CustomIcons {
+		oldIcon, ok := oldIcons[icon.UUID]
+		if !ok {
+			changes.compare("CustomIcons["+diffUUID(icon.UUID)+"]", "", describeIcon(icon))
+			continue
+		}
+		changes.compare("CustomIcons["+diffUUID(icon.UUID)+"]", describeIcon(oldIcon), describeIcon(icon))
+	}
+
+	changes.compareCustomData("CustomData", old.CustomData, updated.CustomData)
+
+	return changes
+}
+
+func describeIcon(icon CustomIcon) string {
+	hash := sha256.Sum256([]byte(icon.Data))
+	return fmt.Sprintf("%s, sha256 %s", icon.Name, hex.EncodeToString(hash[:]))
+}
+
+func diffUUID(uuid UUID) string {
+	text, _ := uuid.MarshalText()
+	return string(text)
+}
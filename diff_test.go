@@ -0,0 +1,184 @@
+package gokeepasslib
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+func TestDatabase_Diff(t *testing.T) {
+	cases := []struct {
+		title         string
+		opts          DiffOptions
+		prepare       func(old, updated *Database)
+		expectedItems []DiffItem
+		expectedMeta  []FieldChange
+	}{
+		{
+			title:   "without changes",
+			prepare: func(old, updated *Database) {},
+		},
+		{
+			title: "with added and removed entries",
+			prepare: func(old, updated *Database) {
+				updated.Content.Root.Groups[0].Groups[0].Entries = []Entry{
+					newMergeEntry(mergeNewUUID, "new", 0),
+				}
+			},
+			expectedItems: []DiffItem{
+				{Type: DiffAdded, Object: DiffObjectEntry, UUID: mergeNewUUID, Name: "new", NewParent: mergeGroupUUID},
+				{Type: DiffRemoved, Object: DiffObjectEntry, UUID: mergeEntryUUID, Name: "entry", OldParent: mergeGroupUUID},
+			},
+		},
+		{
+			title: "with a moved group",
+			prepare: func(old, updated *Database) {
+				root := &updated.Content.Root.Groups[0]
+				root.Groups[0].Groups = []Group{root.Groups[1]}
+				root.Groups = root.Groups[:1]
+			},
+			expectedItems: []DiffItem{
+				{
+					Type:      DiffMoved,
+					Object:    DiffObjectGroup,
+					UUID:      mergeOtherUUID,
+					Name:      "other",
+					OldParent: mergeRootUUID,
+					NewParent: mergeGroupUUID,
+				},
+			},
+		},
+		{
+			title: "with modified entry fields",
+			prepare: func(old, updated *Database) {
+				entry := &updated.Content.Root.Groups[0].Groups[0].Entries[0]
+				entry.Tags = "tag"
+				entry.Times.ExpiryTime = &w.TimeWrapper{Time: mergeBaseTime.Add(time.Hour)}
+				entry.Values = append(entry.Values, ValueData{
+					Key:   "Password",
+					Value: V{Content: "secret", Protected: w.NewBoolWrapper(true)},
+				})
+				entry.CustomData = []CustomData{{Key: "key", Value: "value"}}
+			},
+			expectedItems: []DiffItem{
+				{
+					Type:      DiffModified,
+					Object:    DiffObjectEntry,
+					UUID:      mergeEntryUUID,
+					Name:      "entry",
+					OldParent: mergeGroupUUID,
+					NewParent: mergeGroupUUID,
+					Fields: []FieldChange{
+						{Field: "String[Password]", New: "secret"},
+						{Field: "Tags", New: "tag"},
+						{Field: "Times.ExpiryTime", New: "2024-01-01T01:00:00Z"},
+						{Field: "CustomData[key]", New: "value"},
+					},
+				},
+			},
+		},
+		{
+			title: "with redacted protected values",
+			opts:  DiffOptions{RedactProtected: true},
+			prepare: func(old, updated *Database) {
+				entry := &updated.Content.Root.Groups[0].Groups[0].Entries[0]
+				entry.Values = append(entry.Values, ValueData{
+					Key:   "Password",
+					Value: V{Content: "secret", Protected: w.NewBoolWrapper(true)},
+				})
+			},
+			expectedItems: []DiffItem{
+				{
+					Type:      DiffModified,
+					Object:    DiffObjectEntry,
+					UUID:      mergeEntryUUID,
+					Name:      "entry",
+					OldParent: mergeGroupUUID,
+					NewParent: mergeGroupUUID,
+					Fields: []FieldChange{
+						{Field: "String[Password]", Redacted: true},
+					},
+				},
+			},
+		},
+		{
+			title: "with modified binaries",
+			prepare: func(old, updated *Database) {
+				binary := updated.AddBinary([]byte("some content"))
+				entry := &updated.Content.Root.Groups[0].Groups[0].Entries[0]
+				entry.Binaries = []BinaryReference{binary.CreateReference("file.txt")}
+			},
+			expectedItems: []DiffItem{
+				{
+					Type:      DiffModified,
+					Object:    DiffObjectEntry,
+					UUID:      mergeEntryUUID,
+					Name:      "entry",
+					OldParent: mergeGroupUUID,
+					NewParent: mergeGroupUUID,
+					Fields: []FieldChange{
+						{
+							Field: "Binary[file.txt]",
+							New:   "12 bytes, sha256 290f493c44f5d63d06b374d0a5abd292fae38b92cab2fae5efefe1b0e9347f56",
+						},
+					},
+				},
+			},
+		},
+		{
+			title: "with modified meta data",
+			prepare: func(old, updated *Database) {
+				updated.Content.Meta.DatabaseName = "name"
+				updated.Content.Meta.HistoryMaxItems = 5
+			},
+			expectedMeta: []FieldChange{
+				{Field: "DatabaseName", New: "name"},
+				{Field: "HistoryMaxItems", Old: "10", New: "5"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			old := newMergeDatabase()
+			updated := newMergeDatabase()
+			meta := *old.Content.Meta
+			updated.Content.Meta = &meta
+			c.prepare(old, updated)
+
+			diff, err := old.Diff(updated, c.opts)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			if len(diff.Items) != len(c.expectedItems) {
+				t.Fatalf("Expected items %v, received %v", c.expectedItems, diff.Items)
+			}
+			for i, item := range diff.Items {
+				if item.String() != c.expectedItems[i].String() ||
+					item.OldParent != c.expectedItems[i].OldParent ||
+					item.NewParent != c.expectedItems[i].NewParent {
+					t.Errorf("Expected item %v, received %v", c.expectedItems[i], item)
+				}
+			}
+
+			if len(diff.Meta) != len(c.expectedMeta) {
+				t.Fatalf("Expected meta data changes %v, received %v", c.expectedMeta, diff.Meta)
+			}
+			for i, change := range diff.Meta {
+				if change != c.expectedMeta[i] {
+					t.Errorf("Expected meta data change %v, received %v", c.expectedMeta[i], change)
+				}
+			}
+
+			if diff.IsEmpty() != (len(c.expectedItems) == 0 && len(c.expectedMeta) == 0) {
+				t.Errorf("Unexpected IsEmpty result for %v", diff)
+			}
+			if c.opts.RedactProtected && strings.Contains(diff.String(), "secret") {
+				t.Errorf("Expected protected values to be redacted, received %s", diff)
+			}
+		})
+	}
+}
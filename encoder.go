@@ -1,8 +1,8 @@
 package gokeepasslib
 
 import (
-	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"encoding/xml"
 	"io"
@@ -13,36 +13,79 @@ var xmlHeader = []byte(`<?xml version="1.0" encoding="utf-8" standalone="yes"?>`
 
 // Encoder is used to automaticaly encrypt and write a database to a file, network, etc
 type Encoder struct {
-	w io.Writer
+	w        io.Writer
+	progress ProgressFunc
 }
 
-// NewEncoder creates a new encoder with writer w, identical to gokeepasslib.Encoder{w}
-func NewEncoder(w io.Writer) *Encoder {
-	return &Encoder{w: w}
+// EncoderOption is the option function type for use with NewEncoder
+type EncoderOption func(*Encoder)
+
+// WithEncoderProgress sets a function which is called with the progress of encoding
+func WithEncoderProgress(progress ProgressFunc) EncoderOption {
+	return func(e *Encoder) {
+		e.progress = progress
+	}
+}
+
+// NewEncoder creates a new encoder with writer w
+func NewEncoder(w io.Writer, options ...EncoderOption) *Encoder {
+	e := &Encoder{w: w}
+	for _, option := range options {
+		option(e)
+	}
+	return e
 }
 
-// Encode writes db to e's internal writer
+// Encode writes db to e's internal writer.
+// The transformed key kept from decoding or encoding before is reused, unless the credentials
+// or key derivation parameters have been changed.
 func (e *Encoder) Encode(db *Database) error {
-	db.cleanupBinaries()
+	return e.EncodeContext(context.Background(), db)
+}
 
-	// Unlock protected entries ensuring that we have them prepared in the order that is matching
-	// the xml unmarshalling order
-	err := db.UnlockProtectedEntries()
-	if err != nil {
+// EncodeContext is Encode, which returns the error of ctx once it is done.
+// ctx is checked during the key derivation and between the writes of the content,
+// the data written before is incomplete then.
+func (e *Encoder) EncodeContext(ctx context.Context, db *Database) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	// Re-Lock the protected values mapping to ensure that they are locked in memory and
-	// follow the order in which they would be written again
-	err = db.LockProtectedEntries()
-	if err != nil {
+	if db.Header == nil || db.Header.FileHeaders == nil {
+		return ErrRequiredAttributeMissing("Header")
+	}
+	if err := db.Header.FileHeaders.calibrationErr(); err != nil {
 		return err
 	}
 
+	db.cleanupBinaries()
+
+	if db.Options == nil || !db.Options.ReuseSeeds {
+		// Generate a new master seed, encryption IV and stream key for every save,
+		// which also re-locks locked protected values with the new stream key in the order
+		// in which they will be written
+		if err := db.renewSeeds(nil); err != nil {
+			return err
+		}
+	} else if !db.protectedUnlocked {
+		// Unlock protected entries ensuring that we have them prepared in the order that is matching
+		// the xml unmarshalling order
+		if err := db.UnlockProtectedEntries(); err != nil {
+			return err
+		}
+	}
+	if db.protectedUnlocked {
+		// Lock the protected values mapping to ensure that they are locked in memory and
+		// follow the order in which they would be written
+		if err := db.LockProtectedEntries(); err != nil {
+			return err
+		}
+	}
+
 	// ensure timestamps will be formatted correctly
 	db.ensureKdbxFormatVersion()
 
 	// Calculate transformed key to make HMAC and encrypt
-	transformedKey, err := db.getTransformedKey()
+	transformedKey, err := db.getTransformedKeyContext(ctx, e.progress)
 	if err != nil {
 		return err
 	}
@@ -80,88 +123,125 @@ func (e *Encoder) Encode(db *Database) error {
 		db.Content.Meta.HeaderHash = base64.StdEncoding.EncodeToString(hash[:])
 	}
 
-	// Encode xml and append header to the top
-	rawContent, err := xml.MarshalIndent(db.Content, "", "\t")
+	// Encode the content while writing it
+	output := newProgressWriter(ctx, e.w, ProgressEncrypt, e.progress)
+	contentWriter, err := newContentWriter(db, output, transformedKey)
 	if err != nil {
 		return err
 	}
-	rawContent = append(xmlHeader, rawContent...)
 
 	// Write InnerHeader (Kdbx v4)
 	if db.Header.IsKdbx4() {
-		var ih bytes.Buffer
-		if err = db.Content.InnerHeader.writeTo(&ih); err != nil {
+		if err = db.Content.InnerHeader.writeTo(contentWriter); err != nil {
 			return err
 		}
-
-		rawContent = append(ih.Bytes(), rawContent...)
 	}
 
-	// Encode raw content
-	encodedContent, err := encodeRawContent(db, rawContent, transformedKey)
-	if err != nil {
+	// Write the xml header and encode xml
+	xmlOutput := newProgressWriter(ctx, contentWriter, ProgressSerialize, e.progress)
+	if _, err = xmlOutput.Write(xmlHeader); err != nil {
+		return err
+	}
+	xmlEncoder := xml.NewEncoder(xmlOutput)
+	xmlEncoder.Indent("", "\t")
+	if err = xmlEncoder.Encode(db.Content); err != nil {
 		return err
 	}
+	xmlOutput.finish()
 
-	// Writes the encrypted database content
-	_, err = e.w.Write(encodedContent)
-	return err
+	// Closing the writers flushes all remaining data to e's internal writer
+	if err = contentWriter.Close(); err != nil {
+		return err
+	}
+	output.finish()
+	return nil
 }
 
-func encodeRawContent(
-	db *Database,
-	content []byte,
-	transformedKey []byte,
-) ([]byte, error) {
-	// Compress if the header compression flag is 1 (gzip)
-	if db.Header.FileHeaders.CompressionFlags == GzipCompressionFlag {
-		b := new(bytes.Buffer)
-		w := gzip.NewWriter(b)
+// contentWriter is the chain of writers which are necessary to encode the content.
+// Closing it closes every writer, beginning with the one which is written to.
+type contentWriter struct {
+	io.Writer
+	closers []io.Closer
+}
 
-		if _, err := w.Write(content); err != nil {
-			return nil, err
-		}
+func (cw *contentWriter) chain(w io.WriteCloser) {
+	cw.Writer = w
+	cw.closers = append([]io.Closer{w}, cw.closers...)
+}
 
-		// Close() needs to be explicitly called to write Gzip stream footer,
-		// Flush() is not enough. some gzip decoders treat missing footer as error
-		// while some don't). internally Close() also does flush.
-		if err := w.Close(); err != nil {
-			return nil, err
+func (cw *contentWriter) Close() error {
+	for _, closer := range cw.closers {
+		if err := closer.Close(); err != nil {
+			return err
 		}
-
-		content = b.Bytes()
 	}
+	return nil
+}
 
-	// Compose blocks (Kdbx v3.1)
-	if !db.Header.IsKdbx4() {
-		var blocks bytes.Buffer
-		composeContentBlocks31(&blocks, content)
+// maxPaddingLength is the alignment of the padded content
+const maxPaddingLength = 16
 
-		// Append blocks to StreamStartBytes
-		content = append(db.Header.FileHeaders.StreamStartBytes, blocks.Bytes()...)
-	}
+// paddingWriter counts the written data and adds padding on Close
+type paddingWriter struct {
+	w      io.Writer
+	length int
+}
 
-	// Always add padding, so that decoders that check for the last bytes can work correctly
-	padding := make([]byte, 16-(len(content)%16))
+func (pw *paddingWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.length += n
+	return n, err
+}
+
+// Close writes the padding, so that the written data is aligned to 16 bytes
+func (pw *paddingWriter) Close() error {
+	padding := make([]byte, maxPaddingLength-(pw.length%maxPaddingLength))
 	for i := range len(padding) {
 		padding[i] = byte(len(padding))
 	}
-	content = append(content, padding...)
+	_, err := pw.w.Write(padding)
+	return err
+}
+
+// newContentWriter chains the writers which are necessary to encode the content:
+// compression, blocks (Kdbx v3.1), encryption and blocks (Kdbx v4)
+func newContentWriter(
+	db *Database,
+	w io.Writer,
+	transformedKey []byte,
+) (*contentWriter, error) {
+	cw := &contentWriter{Writer: w}
+
+	// Compose blocks (Kdbx v4)
+	if db.Header.IsKdbx4() {
+		cw.chain(newBlockWriter4(cw.Writer, db.Header.FileHeaders.MasterSeed, transformedKey))
+	}
 
 	// Encrypt content
-	// Decrypt content
 	encrypter, err := db.GetEncrypterManager(transformedKey)
 	if err != nil {
 		return nil, err
 	}
-	encrypted := encrypter.Encrypt(content)
+	cw.chain(encrypter.EncryptWriter(cw.Writer))
 
-	// Compose blocks (Kdbx v4)
-	if db.Header.IsKdbx4() {
-		var blocks bytes.Buffer
-		composeContentBlocks4(&blocks, encrypted, db.Header.FileHeaders.MasterSeed, transformedKey)
+	// Always add padding, so that decoders that check for the last bytes can work correctly
+	cw.chain(&paddingWriter{w: cw.Writer})
 
-		encrypted = blocks.Bytes()
+	// Compose blocks (Kdbx v3.1) after the StreamStartBytes
+	if !db.Header.IsKdbx4() {
+		if _, err := cw.Write(db.Header.FileHeaders.StreamStartBytes); err != nil {
+			return nil, err
+		}
+		cw.chain(newBlockWriter31(cw.Writer))
+	}
+
+	// Compress if the header compression flag is 1 (gzip)
+	// Close() needs to be explicitly called to write Gzip stream footer,
+	// Flush() is not enough. some gzip decoders treat missing footer as error
+	// while some don't). internally Close() also does flush.
+	if db.Header.FileHeaders.CompressionFlags == GzipCompressionFlag {
+		cw.chain(gzip.NewWriter(cw.Writer))
 	}
-	return encrypted, nil
+
+	return cw, nil
 }
@@ -1,9 +1,12 @@
 package gokeepasslib
 
 import (
+	"bytes"
 	"os"
 	"reflect"
 	"testing"
+
+	"github.com/tobischo/gokeepasslib/v3/wrappers"
 )
 
 const urlValue = "http://github.com"
@@ -50,13 +53,13 @@ func TestEncodeFile31(t *testing.T) {
 	}
 	db.Content.Meta.CustomIcons = []CustomIcon{
 		{
-			UUID{
+			UUID: UUID{
 				0xde, 0xad, 0xbe, 0xef,
 				0xc0, 0xff, 0xee, 0xde,
 				0xed, 0x01, 0x23, 0x45,
 				0x67, 0x89, 0xab, 0xcd,
 			},
-			encodedIcon,
+			Data: encodedIcon,
 		},
 	}
 
@@ -126,13 +129,13 @@ func TestEncodeFile31(t *testing.T) {
 	if !reflect.DeepEqual(
 		db.Content.Meta.CustomIcons[0],
 		CustomIcon{
-			UUID{
+			UUID: UUID{
 				0xde, 0xad, 0xbe, 0xef,
 				0xc0, 0xff, 0xee, 0xde,
 				0xed, 0x01, 0x23, 0x45,
 				0x67, 0x89, 0xab, 0xcd,
 			},
-			encodedIcon,
+			Data: encodedIcon,
 		},
 	) {
 		t.Fatal("Failed to properly store a custom icon in the Meta block")
@@ -180,13 +183,13 @@ func TestEncodeFile4(t *testing.T) {
 	}
 	db.Content.Meta.CustomIcons = []CustomIcon{
 		{
-			UUID{
+			UUID: UUID{
 				0xde, 0xad, 0xbe, 0xef,
 				0xc0, 0xff, 0xee, 0xde,
 				0xed, 0x01, 0x23, 0x45,
 				0x67, 0x89, 0xab, 0xcd,
 			},
-			encodedIcon,
+			Data: encodedIcon,
 		},
 	}
 
@@ -255,13 +258,13 @@ func TestEncodeFile4(t *testing.T) {
 	if !reflect.DeepEqual(
 		db.Content.Meta.CustomIcons[0],
 		CustomIcon{
-			UUID{
+			UUID: UUID{
 				0xde, 0xad, 0xbe, 0xef,
 				0xc0, 0xff, 0xee, 0xde,
 				0xed, 0x01, 0x23, 0x45,
 				0x67, 0x89, 0xab, 0xcd,
 			},
-			encodedIcon,
+			Data: encodedIcon,
 		},
 	) {
 		t.Fatal("Failed to properly store a custom icon in the Meta block")
@@ -413,3 +416,179 @@ func TestCreateNewFile(t *testing.T) {
 		)
 	}
 }
+
+// Encode KDBX 4.1 elements and check that they are only kept for KDBX 4.1
+func TestEncodeKDBX41Elements(t *testing.T) {
+	cases := []struct {
+		title          string
+		option         DatabaseOption
+		expectElements bool
+	}{
+		{
+			title:          "KDBX v4.1",
+			option:         WithDatabaseKDBXVersion41(),
+			expectElements: true,
+		},
+		{
+			title:          "KDBX v4.0",
+			option:         WithDatabaseKDBXVersion4(),
+			expectElements: false,
+		},
+		{
+			title:          "KDBX v3.1",
+			option:         WithDatabaseKDBXVersion3(),
+			expectElements: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := NewDatabase(c.option)
+			db.Credentials = NewPasswordCredentials(password)
+
+			parentUUID := NewUUID()
+			modificationTime := wrappers.Now()
+
+			group := &db.Content.Root.Groups[0]
+			group.Tags = "servers"
+			group.PreviousParentGroup = &parentUUID
+
+			entry := &group.Entries[0]
+			entry.QualityCheck = &wrappers.BoolWrapper{Bool: false}
+			entry.PreviousParentGroup = &parentUUID
+			entry.CustomData = []CustomData{
+				{Key: "key", Value: "value", LastModificationTime: &modificationTime},
+			}
+
+			db.Content.Meta.CustomIcons = []CustomIcon{
+				{
+					UUID:                 NewUUID(),
+					Data:                 encodedIcon,
+					Name:                 "icon",
+					LastModificationTime: &modificationTime,
+				},
+			}
+
+			var buffer bytes.Buffer
+			if err := NewEncoder(&buffer).Encode(db); err != nil {
+				t.Fatalf("Failed to encode database: %s", err)
+			}
+
+			db = NewDatabase()
+			db.Credentials = NewPasswordCredentials(password)
+			if err := NewDecoder(&buffer).Decode(db); err != nil {
+				t.Fatalf("Failed to decode database: %s", err)
+			}
+
+			group = &db.Content.Root.Groups[0]
+			entry = &group.Entries[0]
+			icon := db.Content.Meta.CustomIcons[0]
+			customData := entry.CustomData[0]
+
+			if c.expectElements {
+				if group.Tags != "servers" {
+					t.Errorf("Expected group tags 'servers', received '%s'", group.Tags)
+				}
+				if group.PreviousParentGroup == nil || *group.PreviousParentGroup != parentUUID {
+					t.Errorf("Expected group PreviousParentGroup %x", parentUUID)
+				}
+				if entry.IsQualityCheckEnabled() {
+					t.Errorf("Expected entry QualityCheck to be disabled")
+				}
+				if entry.PreviousParentGroup == nil || *entry.PreviousParentGroup != parentUUID {
+					t.Errorf("Expected entry PreviousParentGroup %x", parentUUID)
+				}
+				if customData.LastModificationTime == nil ||
+					customData.LastModificationTime.Time.Unix() != modificationTime.Time.Unix() {
+					t.Errorf("Expected custom data LastModificationTime %v", modificationTime)
+				}
+				if icon.Name != "icon" {
+					t.Errorf("Expected custom icon name 'icon', received '%s'", icon.Name)
+				}
+				if icon.LastModificationTime == nil ||
+					icon.LastModificationTime.Time.Unix() != modificationTime.Time.Unix() {
+					t.Errorf("Expected custom icon LastModificationTime %v", modificationTime)
+				}
+				return
+			}
+
+			if group.Tags != "" || group.PreviousParentGroup != nil {
+				t.Errorf("Expected KDBX 4.1 group elements to be omitted")
+			}
+			if entry.QualityCheck != nil || entry.PreviousParentGroup != nil {
+				t.Errorf("Expected KDBX 4.1 entry elements to be omitted")
+			}
+			if customData.LastModificationTime != nil {
+				t.Errorf("Expected KDBX 4.1 custom data elements to be omitted")
+			}
+			if icon.Name != "" || icon.LastModificationTime != nil {
+				t.Errorf("Expected KDBX 4.1 custom icon elements to be omitted")
+			}
+		})
+	}
+}
+
+func TestEncodeRenewsSeeds(t *testing.T) {
+	cases := []struct {
+		title      string
+		dbFilePath string
+	}{
+		{title: "Database Format v3.1", dbFilePath: "tests/kdbx3/example.kdbx"},
+		{title: "Database Format v4", dbFilePath: "tests/kdbx4/example.kdbx"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			credentials := NewPasswordCredentials("abcdefg12345678")
+			db := decodeTestFile(t, c.dbFilePath, credentials)
+
+			var first, second bytes.Buffer
+			if err := NewEncoder(&first).Encode(db); err != nil {
+				t.Fatalf("Failed to encode database: %s", err)
+			}
+			firstHeaders, firstStreamKey := *db.Header.FileHeaders, streamKey(db)
+			if err := NewEncoder(&second).Encode(db); err != nil {
+				t.Fatalf("Failed to encode database: %s", err)
+			}
+
+			fh := db.Header.FileHeaders
+			if bytes.Equal(fh.MasterSeed, firstHeaders.MasterSeed) ||
+				bytes.Equal(fh.EncryptionIV, firstHeaders.EncryptionIV) ||
+				bytes.Equal(streamKey(db), firstStreamKey) {
+				t.Errorf("Expected new seeds, IVs and stream keys for every encode")
+			}
+
+			decoded := NewDatabase()
+			decoded.Credentials = credentials
+			if err := NewDecoder(&first).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode database: %s", err)
+			}
+			if err := decoded.UnlockProtectedEntries(); err != nil {
+				t.Fatalf("Problem unlocking entries. %s", err)
+			}
+			if pw := decoded.Content.Root.Groups[0].Groups[0].Entries[0].GetPassword(); pw != password {
+				t.Errorf("Expected the password to be locked with the new stream key, received %q", pw)
+			}
+
+			db.Options.ReuseSeeds = true
+			first.Reset()
+			second.Reset()
+			if err := NewEncoder(&first).Encode(db); err != nil {
+				t.Fatalf("Failed to encode database: %s", err)
+			}
+			if err := NewEncoder(&second).Encode(db); err != nil {
+				t.Fatalf("Failed to encode database: %s", err)
+			}
+			if !bytes.Equal(first.Bytes(), second.Bytes()) {
+				t.Errorf("Expected identical output when reusing the seeds")
+			}
+		})
+	}
+}
+
+func streamKey(db *Database) []byte {
+	if db.Header.IsKdbx4() {
+		return db.Content.InnerHeader.InnerRandomStreamKey
+	}
+	return db.Header.FileHeaders.ProtectedStreamKey
+}
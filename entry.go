@@ -16,19 +16,23 @@ func WithEntryFormattedTime(formatted bool) EntryOption {
 
 // Entry is the structure which holds information about a parsed entry in a keepass database
 type Entry struct {
-	UUID            UUID              `xml:"UUID"`
-	IconID          int64             `xml:"IconID"`
-	CustomIconUUID  UUID              `xml:"CustomIconUUID"`
-	ForegroundColor string            `xml:"ForegroundColor"`
-	BackgroundColor string            `xml:"BackgroundColor"`
-	OverrideURL     string            `xml:"OverrideURL"`
-	Tags            string            `xml:"Tags"`
-	Times           TimeData          `xml:"Times"`
-	Values          []ValueData       `xml:"String,omitempty"`
-	AutoType        AutoTypeData      `xml:"AutoType"`
-	Histories       []History         `xml:"History"`
-	Binaries        []BinaryReference `xml:"Binary,omitempty"`
-	CustomData      []CustomData      `xml:"CustomData>Item"`
+	UUID                UUID              `xml:"UUID"`
+	IconID              int64             `xml:"IconID"`
+	CustomIconUUID      UUID              `xml:"CustomIconUUID"`
+	ForegroundColor     string            `xml:"ForegroundColor"`
+	BackgroundColor     string            `xml:"BackgroundColor"`
+	OverrideURL         string            `xml:"OverrideURL"`
+	QualityCheck        *w.BoolWrapper    `xml:"QualityCheck,omitempty"` // KDBX 4.1
+	Tags                string            `xml:"Tags"`
+	PreviousParentGroup *UUID             `xml:"PreviousParentGroup,omitempty"` // KDBX 4.1
+	Times               TimeData          `xml:"Times"`
+	Values              []ValueData       `xml:"String,omitempty"`
+	AutoType            AutoTypeData      `xml:"AutoType"`
+	Histories           []History         `xml:"History"`
+	Binaries            []BinaryReference `xml:"Binary,omitempty"`
+	CustomData          []CustomData      `xml:"CustomData>Item"`
+	kdbxFormatVersion   formatVersion     `xml:"-"`
+	unknownXML          unknownXMLData    `xml:"-"`
 }
 
 // NewEntry return a new entry with time data and uuid set
@@ -45,11 +49,43 @@ func NewEntry(options ...EntryOption) Entry {
 }
 
 func (e *Entry) setKdbxFormatVersion(version formatVersion) {
+	e.kdbxFormatVersion = version
+
 	(&e.Times).setKdbxFormatVersion(version)
 
 	for i := range e.Histories {
 		(&e.Histories[i]).setKdbxFormatVersion(version)
 	}
+
+	for i := range e.CustomData {
+		(&e.CustomData[i]).setKdbxFormatVersion(version)
+	}
+}
+
+// MarshalXML marshals the entry into e, leaving out KDBX 4.1 elements for older versions
+func (e *Entry) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type entry Entry
+
+	out := entry(*e)
+	if omitKdbx41Elements(e.kdbxFormatVersion) {
+		out.QualityCheck = nil
+		out.PreviousParentGroup = nil
+	}
+
+	return marshalXMLWithUnknown(enc, start, &out, e.unknownXML)
+}
+
+// UnmarshalXML unmarshals the entry from d, keeping elements unknown to the library
+func (e *Entry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type entry Entry
+
+	unknownXML, err := unmarshalXMLWithUnknown(d, start, (*entry)(e))
+	if err != nil {
+		return err
+	}
+
+	e.unknownXML = unknownXML
+	return nil
 }
 
 // Clone creates a copy of an Entry struct including its child entities
@@ -66,6 +102,14 @@ func (e Entry) Clone() Entry {
 	copy(clone.Binaries, e.Binaries)
 	clone.CustomData = make([]CustomData, len(clone.CustomData))
 	copy(clone.CustomData, e.CustomData)
+	if e.QualityCheck != nil {
+		qualityCheck := *e.QualityCheck
+		clone.QualityCheck = &qualityCheck
+	}
+	if e.PreviousParentGroup != nil {
+		previousParentGroup := *e.PreviousParentGroup
+		clone.PreviousParentGroup = &previousParentGroup
+	}
 	return clone
 }
 
@@ -113,6 +157,12 @@ func (e *Entry) GetTitle() string {
 	return e.GetContent("Title")
 }
 
+// IsQualityCheckEnabled returns whether the password quality should be estimated for the entry.
+// Entries without the KDBX 4.1 QualityCheck element are checked.
+func (e *Entry) IsQualityCheckEnabled() bool {
+	return e.QualityCheck == nil || e.QualityCheck.Bool
+}
+
 // History stores information about changes made to an entry,
 // in the form of a list of previous versions of that entry
 type History struct {
@@ -165,7 +215,30 @@ type AutoTypeAssociation struct {
 
 // CustomData is the structure for plugins custom data
 type CustomData struct {
-	XMLName xml.Name `xml:"Item"`
-	Key     string   `xml:"Key"`
-	Value   string   `xml:"Value"`
+	XMLName              xml.Name       `xml:"Item"`
+	Key                  string         `xml:"Key"`
+	Value                string         `xml:"Value"`
+	LastModificationTime *w.TimeWrapper `xml:"LastModificationTime,omitempty"` // KDBX 4.1
+	kdbxFormatVersion    formatVersion  `xml:"-"`
+}
+
+func (cd *CustomData) setKdbxFormatVersion(version formatVersion) {
+	cd.kdbxFormatVersion = version
+
+	if cd.LastModificationTime != nil {
+		cd.LastModificationTime.Formatted = !isKdbx4(version)
+	}
+}
+
+// MarshalXML marshals the custom data item into e,
+// leaving out KDBX 4.1 elements for older versions
+func (cd *CustomData) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type customData CustomData
+
+	out := customData(*cd)
+	if omitKdbx41Elements(cd.kdbxFormatVersion) {
+		out.LastModificationTime = nil
+	}
+
+	return e.EncodeElement(&out, start)
 }
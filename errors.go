@@ -0,0 +1,118 @@
+package gokeepasslib
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tobischo/gokeepasslib/v3/crypto"
+)
+
+// Kinds of errors returned by Decoder.Decode and ReadHeader, wrapped in a DecodeError.
+// Use errors.Is to check the kind and errors.As to get the underlying error with its context,
+// e.g. ErrInvalidHeaderFieldLength with the header field ID or ErrBlockHMACMismatch with the block index.
+var (
+	// ErrInvalidCredentials is the kind of error returned if the credentials do not match the database
+	ErrInvalidCredentials = errors.New("gokeepasslib: invalid credentials")
+	// ErrCorruptedDatabase is the kind of error returned if the database is damaged or not a database
+	ErrCorruptedDatabase = errors.New("gokeepasslib: database corrupted")
+	// ErrTruncatedDatabase is the kind of error returned if the database ends unexpectedly
+	ErrTruncatedDatabase = errors.New("gokeepasslib: database truncated")
+	// ErrUnsupportedDatabase is the kind of error returned if the version, cipher, key derivation function
+	// or inner stream of the database is not supported
+	ErrUnsupportedDatabase = errors.New("gokeepasslib: database not supported")
+	// ErrInvalidXML is the kind of error returned if the decrypted content is no valid database XML
+	ErrInvalidXML = errors.New("gokeepasslib: invalid database XML")
+)
+
+// DecodeError is the error returned if a database can not be decoded.
+// Errors caused by the caller, like missing credentials or exceeded DBOptions limits, are not wrapped.
+type DecodeError struct {
+	Kind error // One of ErrInvalidCredentials, ErrCorruptedDatabase, ErrTruncatedDatabase, ErrUnsupportedDatabase or ErrInvalidXML
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+func (e *DecodeError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Is reports invalid credentials and corrupted databases as ErrInvalidDatabaseOrCredentials as well
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrInvalidDatabaseOrCredentials &&
+		(e.Kind == ErrInvalidCredentials || e.Kind == ErrCorruptedDatabase)
+}
+
+// newDecodeError wraps err in a DecodeError of its kind, errors of no known kind are returned as they are
+func newDecodeError(err error) error {
+	var decodeErr *DecodeError
+	if err == nil || errors.As(err, &decodeErr) {
+		return err
+	}
+	if kind := decodeErrorKind(err); kind != nil {
+		return &DecodeError{Kind: kind, Err: err}
+	}
+	return err
+}
+
+// decodeErrorKind returns the kind of err or nil if it is of no known kind
+func decodeErrorKind(err error) error {
+	var (
+		unsupportedVersion ErrUnsupportedVersion
+		invalidSignature   ErrInvalidSignature
+		headerFieldLength  ErrInvalidHeaderFieldLength
+		unknownHeader      ErrUnknownHeaderID
+		unknownInnerHeader ErrUnknownInnerHeaderID
+		unknownParameter   ErrUnknownParameterID
+		blockHMAC          ErrBlockHMACMismatch
+		blockHash          ErrBlockHashMismatch
+		blockIndex         ErrBlockIndexMismatch
+		corruptInput       flate.CorruptInputError
+		keySize            aes.KeySizeError
+	)
+
+	switch {
+	case errors.Is(err, errInvalidHMACKey),
+		errors.Is(err, errDatabaseIntegrityFailed):
+		return ErrInvalidCredentials
+	case errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, crypto.ErrIncompleteBlock):
+		return ErrTruncatedDatabase
+	case errors.As(err, &unsupportedVersion),
+		errors.Is(err, ErrKeePass1Database),
+		errors.Is(err, ErrPreReleaseDatabase),
+		errors.Is(err, ErrUnsupportedEncrypterType),
+		errors.Is(err, ErrUnsupportedStreamType),
+		errors.Is(err, ErrUnsupportedKdf):
+		return ErrUnsupportedDatabase
+	case errors.As(err, &invalidSignature),
+		errors.As(err, &headerFieldLength),
+		errors.As(err, &unknownHeader),
+		errors.As(err, &unknownInnerHeader),
+		errors.As(err, &unknownParameter),
+		errors.As(err, &blockHMAC),
+		errors.As(err, &blockHash),
+		errors.As(err, &blockIndex),
+		errors.As(err, &corruptInput),
+		errors.As(err, &keySize),
+		errors.Is(err, errHeaderSHA256MisMatching),
+		errors.Is(err, ErrHeaderHashMismatch),
+		errors.Is(err, ErrInvalidVariantDictionary),
+		errors.Is(err, ErrInvalidKdfParameters),
+		errors.Is(err, ErrInvalidDatabaseOrCredentials),
+		errors.Is(err, errTrailingContent),
+		errors.Is(err, crypto.ErrInvalidIVLength),
+		errors.Is(err, crypto.ErrInvalidPadding),
+		errors.Is(err, gzip.ErrHeader),
+		errors.Is(err, gzip.ErrChecksum):
+		return ErrCorruptedDatabase
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// encodeRawContent encodes a Kdbx v3.1 database with the given content instead of the database XML
+func encodeRawContent(t *testing.T, db *Database, content string) []byte {
+	t.Helper()
+
+	transformedKey, err := db.getTransformedKey()
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	var buffer bytes.Buffer
+	if err := db.Header.writeTo(&buffer); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	contentWriter, err := newContentWriter(db, &buffer, transformedKey)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if _, err := io.WriteString(contentWriter, content); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if err := contentWriter.Close(); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestDecodeErrorKinds(t *testing.T) {
+	readFile := func(t *testing.T, dbFilePath string) []byte {
+		data, err := os.ReadFile(dbFilePath)
+		if err != nil {
+			t.Fatalf("Failed to read keepass file: %s", err)
+		}
+		return data
+	}
+	headerLength := func(t *testing.T, data []byte) int {
+		header, err := ReadHeader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		return len(header.RawData)
+	}
+
+	cases := []struct {
+		title       string
+		data        func(t *testing.T) []byte
+		password    string
+		skipHashes  bool // Decode without DBOptions.ValidateHashes
+		kind        error
+		expectedErr any // Pointer to the type of the wrapped error, checked with errors.As if set
+	}{
+		{
+			title:    "wrong password for Database Format v3.1",
+			data:     func(t *testing.T) []byte { return readFile(t, "tests/kdbx3/example.kdbx") },
+			password: "wrong",
+			kind:     ErrInvalidCredentials,
+		},
+		{
+			title:    "wrong password for Database Format v4",
+			data:     func(t *testing.T) []byte { return readFile(t, "tests/kdbx4/example.kdbx") },
+			password: "wrong",
+			kind:     ErrInvalidCredentials,
+		},
+		{
+			title:       "wrong password for Database Format v4 without validating hashes",
+			data:        func(t *testing.T) []byte { return readFile(t, "tests/kdbx4/example.kdbx") },
+			password:    "wrong",
+			skipHashes:  true,
+			kind:        ErrInvalidCredentials,
+			expectedErr: &ErrBlockHMACMismatch{},
+		},
+		{
+			title: "truncated",
+			data: func(t *testing.T) []byte {
+				data := readFile(t, "tests/kdbx4/example.kdbx")
+				return data[:len(data)/2]
+			},
+			kind: ErrTruncatedDatabase,
+		},
+		{
+			title: "invalid signature",
+			data: func(t *testing.T) []byte {
+				data := readFile(t, "tests/kdbx4/example.kdbx")
+				data[0] = 0x00
+				return data
+			},
+			kind:        ErrCorruptedDatabase,
+			expectedErr: new(ErrInvalidSignature),
+		},
+		{
+			title: "modified header",
+			data: func(t *testing.T) []byte {
+				data := readFile(t, "tests/kdbx4/example.kdbx")
+				// First byte of the SHA-256 hash following the header
+				data[headerLength(t, data)] ^= 0x01
+				return data
+			},
+			kind: ErrCorruptedDatabase,
+		},
+		{
+			title: "modified content block",
+			data: func(t *testing.T) []byte {
+				data := readFile(t, "tests/kdbx4/example.kdbx")
+				// First byte of the data of the first block, after the hashes, block HMAC and length
+				data[headerLength(t, data)+64+36] ^= 0x01
+				return data
+			},
+			kind:        ErrCorruptedDatabase,
+			expectedErr: &ErrBlockHMACMismatch{},
+		},
+		{
+			title: "modified content block without validating hashes",
+			data: func(t *testing.T) []byte {
+				data := readFile(t, "tests/kdbx4/example.kdbx")
+				data[headerLength(t, data)+64+36] ^= 0x01
+				return data
+			},
+			skipHashes:  true,
+			kind:        ErrCorruptedDatabase,
+			expectedErr: &ErrBlockHMACMismatch{},
+		},
+		{
+			title: "unsupported version",
+			data: func(t *testing.T) []byte {
+				data := readFile(t, "tests/kdbx4/example.kdbx")
+				data[10] = 0x05 // Major version
+				return data
+			},
+			kind:        ErrUnsupportedDatabase,
+			expectedErr: &ErrUnsupportedVersion{},
+		},
+		{
+			title: "unsupported cipher",
+			data: func(t *testing.T) []byte {
+				data := readFile(t, "tests/kdbx3/example.kdbx")
+				cipherID := bytes.Index(data, CipherAES)
+				data[cipherID] ^= 0x01
+				return data
+			},
+			kind: ErrUnsupportedDatabase,
+		},
+		{
+			title: "invalid xml",
+			data: func(t *testing.T) []byte {
+				db := NewDatabase(WithDatabaseKDBXVersion3())
+				db.Header.FileHeaders.TransformRounds = 1
+				db.Credentials = NewPasswordCredentials("abcdefg12345678")
+				return encodeRawContent(t, db, "<KeePassFile><Meta></Root></KeePassFile>")
+			},
+			kind: ErrInvalidXML,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			password := c.password
+			if password == "" {
+				password = "abcdefg12345678"
+			}
+
+			db := NewDatabase()
+			db.Credentials = NewPasswordCredentials(password)
+			db.Options.ValidateHashes = !c.skipHashes
+			err := NewDecoder(bytes.NewReader(c.data(t))).Decode(db)
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) || !errors.Is(err, c.kind) {
+				t.Fatalf("Expected a DecodeError of kind %v, received %v", c.kind, err)
+			}
+			if c.expectedErr != nil && !errors.As(err, c.expectedErr) {
+				t.Errorf("Expected error of type %T, received %v", c.expectedErr, err)
+			}
+
+			isInvalid := c.kind == ErrInvalidCredentials || c.kind == ErrCorruptedDatabase
+			if errors.Is(err, ErrInvalidDatabaseOrCredentials) != isInvalid {
+				t.Errorf("Expected %v to match ErrInvalidDatabaseOrCredentials: %t", err, isInvalid)
+			}
+		})
+	}
+}
+
+func TestNewDecodeError(t *testing.T) {
+	cases := []struct {
+		title string
+		err   error
+		kind  error
+	}{
+		{title: "nil", err: nil},
+		{title: "unknown error", err: ErrRequiredAttributeMissing("Credentials")},
+		{title: "limit", err: ErrLimitExceeded{Name: "MaxKdfRounds", Value: 2, Limit: 1}},
+		{title: "header field", err: ErrInvalidHeaderFieldLength{ID: headerIDTransformRounds, Length: 2}, kind: ErrCorruptedDatabase},
+		{title: "unsupported kdf", err: ErrUnsupportedKdf, kind: ErrUnsupportedDatabase},
+		{title: "block hash", err: ErrBlockHashMismatch{Index: 1}, kind: ErrCorruptedDatabase},
+		{title: "unexpected eof", err: io.ErrUnexpectedEOF, kind: ErrTruncatedDatabase},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			err := newDecodeError(c.err)
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				if c.kind != nil || err != c.err {
+					t.Fatalf("Expected kind %v, received %v", c.kind, err)
+				}
+				return
+			}
+			if decodeErr.Kind != c.kind || decodeErr.Err != c.err {
+				t.Errorf("Expected kind %v wrapping %v, received %v", c.kind, c.err, err)
+			}
+			if newDecodeError(err) != err {
+				t.Errorf("Expected a DecodeError not to be wrapped again")
+			}
+		})
+	}
+}
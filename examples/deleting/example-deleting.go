@@ -28,12 +28,19 @@ func main() {
 	// Unlock protected entries to handle stream cipher
 	db.UnlockProtectedEntries()
 
-	rootGroup := db.Content.Root.Groups[0]
-
-	// Remove `My GMail password` entry from example-writing example
-	rootGroup.Entries = rootGroup.Entries[:0]
-
-	db.Content.Root.Groups[0] = rootGroup
+	// Remove `My GMail password` entry from example-writing example.
+	// DeleteEntry records the deletion, so that synchronizing with other copies
+	// of the database does not restore the entry.
+	// The UUIDs are collected first, as deleting an entry modifies the entries of its group.
+	var uuids []gokeepasslib.UUID
+	for _, entry := range db.Content.Root.Groups[0].Entries {
+		uuids = append(uuids, entry.UUID)
+	}
+	for _, uuid := range uuids {
+		if err := db.DeleteEntry(uuid); err != nil {
+			panic(err)
+		}
+	}
 
 	// Lock entries using stream cipher
 	db.LockProtectedEntries()
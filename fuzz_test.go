@@ -0,0 +1,65 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// addFuzzSeeds adds the content of the files matching the patterns to the seed corpus
+func addFuzzSeeds(f *testing.F, patterns ...string) {
+	f.Helper()
+
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			f.Fatalf("Received unexpected error %v", err)
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				f.Fatalf("Failed to read seed %s: %s", path, err)
+			}
+			f.Add(data)
+		}
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	addFuzzSeeds(f, "tests/kdbx3/example*.kdbx", "tests/kdbx4/example*.kdbx")
+	credentials := NewPasswordCredentials("abcdefg12345678")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		db := NewDatabase()
+		db.Credentials = credentials
+		// Keep the key derivation of the seeds, but fail fast on expensive parameters
+		db.Options.MaxHeaderFieldSize = 64 * 1024
+		db.Options.MaxBinarySize = 1024 * 1024
+		db.Options.MaxKdfMemory = 1024 * 1024
+		db.Options.MaxKdfIterations = 2
+		db.Options.MaxKdfRounds = 60000
+
+		NewDecoder(bytes.NewReader(data)).Decode(db)
+	})
+}
+
+func FuzzReadHeader(f *testing.F) {
+	addFuzzSeeds(f, "tests/kdbx3/example*.kdbx", "tests/kdbx4/example*.kdbx")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		header, err := ReadHeader(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		header.Summary()
+	})
+}
+
+func FuzzParseKeyData(f *testing.F) {
+	addFuzzSeeds(f, "tests/keyfiles/*.key", "tests/kdbx*/*.key")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ParseKeyData(data)
+	})
+}
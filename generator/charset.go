@@ -0,0 +1,128 @@
+package generator
+
+import "strings"
+
+// Character sets as used by KeePass
+const (
+	UpperCase  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	LowerCase  = "abcdefghijklmnopqrstuvwxyz"
+	Digits     = "0123456789"
+	Specials   = "!\"#$%&'*+,./:;=?@\\^`|~"
+	Brackets   = "()[]{}<>"
+	Minus      = "-"
+	Underline  = "_"
+	Space      = " "
+	LookAlikes = "O0Il1|"
+)
+
+// CharSetProfile generates passwords of a fixed length out of a set of characters
+type CharSetProfile struct {
+	Length            int
+	UpperCase         bool
+	LowerCase         bool
+	Digits            bool
+	Specials          bool
+	Brackets          bool
+	Minus             bool
+	Underline         bool
+	Space             bool
+	Custom            string // Additional characters
+	Exclude           string // Characters which are never used
+	ExcludeLookAlikes bool   // Exclude characters which are easily confused, see LookAlikes
+}
+
+// DefaultCharSetProfile is the default profile of KeePass,
+// 20 upper case and lower case letters and digits
+var DefaultCharSetProfile = CharSetProfile{
+	Length:    20,
+	UpperCase: true,
+	LowerCase: true,
+	Digits:    true,
+}
+
+// CharSet returns the characters passwords are generated from
+func (p CharSetProfile) CharSet() string {
+	var set charSet
+	for _, class := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{p.UpperCase, UpperCase},
+		{p.LowerCase, LowerCase},
+		{p.Digits, Digits},
+		{p.Specials, Specials},
+		{p.Brackets, Brackets},
+		{p.Minus, Minus},
+		{p.Underline, Underline},
+		{p.Space, Space},
+		{true, p.Custom},
+	} {
+		if class.enabled {
+			set = set.add(class.chars)
+		}
+	}
+
+	set = set.remove(p.Exclude)
+	if p.ExcludeLookAlikes {
+		set = set.remove(LookAlikes)
+	}
+	return string(set)
+}
+
+// Entropy returns the entropy of the passwords generated with the profile in bits
+func (p CharSetProfile) Entropy() float64 {
+	if p.Length <= 0 {
+		return 0
+	}
+	return float64(p.Length) * bits(len([]rune(p.CharSet())))
+}
+
+func (p CharSetProfile) generate(g *Generator) (string, error) {
+	if p.Length <= 0 {
+		return "", ErrInvalidLength
+	}
+
+	set := charSet(p.CharSet())
+	password := make([]rune, p.Length)
+	for i := range password {
+		char, err := g.pick(set)
+		if err != nil {
+			return "", err
+		}
+		password[i] = char
+	}
+	return string(password), nil
+}
+
+// charSet is an ordered set of characters
+type charSet []rune
+
+// add returns the set with the characters of chars which are not contained yet
+func (s charSet) add(chars string) charSet {
+	for _, char := range chars {
+		if !s.contains(char) {
+			s = append(s, char)
+		}
+	}
+	return s
+}
+
+// remove returns the set without the characters of chars
+func (s charSet) remove(chars string) charSet {
+	var set charSet
+	for _, char := range s {
+		if !strings.ContainsRune(chars, char) {
+			set = append(set, char)
+		}
+	}
+	return set
+}
+
+func (s charSet) contains(char rune) bool {
+	for _, c := range s {
+		if c == char {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// zeroReader always returns zero bytes, so the first character of each set is chosen
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestCharSetProfile_CharSet(t *testing.T) {
+	cases := []struct {
+		title    string
+		profile  CharSetProfile
+		expected string
+	}{
+		{
+			title:    "digits and brackets",
+			profile:  CharSetProfile{Digits: true, Brackets: true},
+			expected: Digits + Brackets,
+		},
+		{
+			title:    "custom without duplicates",
+			profile:  CharSetProfile{Digits: true, Custom: "a1b"},
+			expected: Digits + "ab",
+		},
+		{
+			title:    "excluded characters",
+			profile:  CharSetProfile{Digits: true, Exclude: "2468"},
+			expected: "013579",
+		},
+		{
+			title:    "look-alikes",
+			profile:  CharSetProfile{UpperCase: true, Digits: true, ExcludeLookAlikes: true},
+			expected: strings.NewReplacer("O", "", "I", "", "0", "", "1", "").Replace(UpperCase + Digits),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			if set := c.profile.CharSet(); set != c.expected {
+				t.Errorf("Expected %q, received %q", c.expected, set)
+			}
+		})
+	}
+}
+
+func TestCharSetProfile_Generate(t *testing.T) {
+	profile := CharSetProfile{Length: 32, LowerCase: true, Digits: true}
+
+	password, err := Generate(profile)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(password) != 32 || strings.Trim(password, LowerCase+Digits) != "" {
+		t.Errorf("Expected 32 lower case letters and digits, received %s", password)
+	}
+
+	password, err = New(WithRandomSource(zeroReader{})).Generate(profile)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if password != strings.Repeat("a", 32) {
+		t.Errorf("Expected the random source to be used, received %s", password)
+	}
+
+	if _, err := Generate(CharSetProfile{Length: 8}); !errors.Is(err, ErrEmptyCharSet) {
+		t.Errorf("Expected ErrEmptyCharSet, received %v", err)
+	}
+	if _, err := Generate(CharSetProfile{Digits: true}); !errors.Is(err, ErrInvalidLength) {
+		t.Errorf("Expected ErrInvalidLength, received %v", err)
+	}
+	if _, err := New(WithRandomSource(bytes.NewReader(nil))).Generate(profile); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected the error of the random source, received %v", err)
+	}
+}
+
+func TestCharSetProfile_Entropy(t *testing.T) {
+	entropy := DefaultCharSetProfile.Entropy()
+	if expected := 20 * math.Log2(62); math.Abs(entropy-expected) > 1e-9 {
+		t.Errorf("Expected %f, received %f", expected, entropy)
+	}
+	if entropy := (CharSetProfile{Length: 10, Custom: "a"}).Entropy(); entropy != 0 {
+		t.Errorf("Expected no entropy for a single character, received %f", entropy)
+	}
+}
@@ -0,0 +1,102 @@
+// Package generator creates random passwords and passphrases like the KeePass password generator.
+package generator
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+)
+
+// Errors returned when generating passwords
+var (
+	ErrInvalidLength  = errors.New("generator: length must be positive")
+	ErrEmptyCharSet   = errors.New("generator: character set is empty")
+	ErrInvalidPattern = errors.New("generator: invalid pattern")
+	ErrEmptyWordList  = errors.New("generator: word list is empty")
+)
+
+// Profile describes how passwords are generated
+type Profile interface {
+	// Entropy returns the entropy of the passwords generated with the profile in bits
+	Entropy() float64
+
+	generate(g *Generator) (string, error)
+}
+
+// Generator generates passwords using a source of randomness
+type Generator struct {
+	random io.Reader
+}
+
+// Option is the option function type for use with New
+type Option func(*Generator)
+
+// WithRandomSource sets the source of randomness, crypto/rand.Reader by default.
+// This is mainly useful for deterministic tests.
+func WithRandomSource(random io.Reader) Option {
+	return func(g *Generator) {
+		g.random = random
+	}
+}
+
+// New creates a generator configured with the given options
+func New(options ...Option) *Generator {
+	g := &Generator{random: rand.Reader}
+	for _, option := range options {
+		option(g)
+	}
+	return g
+}
+
+// Generate returns a new password generated using the given profile
+func (g *Generator) Generate(p Profile) (string, error) {
+	return p.generate(g)
+}
+
+// Generate returns a new password generated using the given profile and crypto/rand
+func Generate(p Profile) (string, error) {
+	return New().Generate(p)
+}
+
+// intn returns a uniformly distributed random number in [0, n)
+func (g *Generator) intn(n int) (int, error) {
+	i, err := rand.Int(g.random, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// pick returns a random character of set
+func (g *Generator) pick(set charSet) (rune, error) {
+	if len(set) == 0 {
+		return 0, ErrEmptyCharSet
+	}
+	i, err := g.intn(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return set[i], nil
+}
+
+// shuffle permutes chars randomly using the Fisher-Yates shuffle
+func (g *Generator) shuffle(chars []rune) error {
+	for i := len(chars) - 1; i > 0; i-- {
+		j, err := g.intn(i + 1)
+		if err != nil {
+			return err
+		}
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return nil
+}
+
+// bits returns the entropy of a random choice out of n possibilities
+func bits(n int) float64 {
+	if n <= 1 {
+		return 0
+	}
+	return math.Log2(float64(n))
+}
@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PassphraseProfile generates diceware-style passphrases of random words
+type PassphraseProfile struct {
+	Words      int
+	Separator  string
+	WordList   []string // Words to choose from, the built-in WordList if empty
+	Capitalize bool     // Capitalize the first letter of each word
+}
+
+// WordList returns a copy of the built-in list of words used for passphrases
+func WordList() []string {
+	words := make([]string, len(wordList))
+	copy(words, wordList)
+	return words
+}
+
+// Entropy returns the entropy of the passphrases generated with the profile in bits
+func (p PassphraseProfile) Entropy() float64 {
+	if p.Words <= 0 {
+		return 0
+	}
+	return float64(p.Words) * bits(len(p.words()))
+}
+
+func (p PassphraseProfile) generate(g *Generator) (string, error) {
+	if p.Words <= 0 {
+		return "", ErrInvalidLength
+	}
+
+	words := p.words()
+	if len(words) == 0 {
+		return "", ErrEmptyWordList
+	}
+
+	passphrase := make([]string, p.Words)
+	for i := range passphrase {
+		index, err := g.intn(len(words))
+		if err != nil {
+			return "", err
+		}
+		passphrase[i] = words[index]
+		if p.Capitalize {
+			passphrase[i] = capitalize(passphrase[i])
+		}
+	}
+	return strings.Join(passphrase, p.Separator), nil
+}
+
+// words returns the distinct words of the word list of the profile
+func (p PassphraseProfile) words() []string {
+	if len(p.WordList) == 0 {
+		return wordList
+	}
+
+	seen := make(map[string]bool, len(p.WordList))
+	words := make([]string, 0, len(p.WordList))
+	for _, word := range p.WordList {
+		if word != "" && !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func capitalize(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(first)) + word[size:]
+}
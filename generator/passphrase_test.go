@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPassphraseProfile_Generate(t *testing.T) {
+	passphrase, err := Generate(PassphraseProfile{Words: 6, Separator: "-"})
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	words := strings.Split(passphrase, "-")
+	if len(words) != 6 {
+		t.Fatalf("Expected 6 words, received %s", passphrase)
+	}
+	builtin := WordList()
+	for _, word := range words {
+		if !containsWord(builtin, word) {
+			t.Errorf("Expected words of the built-in list, received %s", word)
+		}
+	}
+
+	profile := PassphraseProfile{
+		Words:      3,
+		Separator:  " ",
+		WordList:   []string{"correct", "horse", "battery", "staple"},
+		Capitalize: true,
+	}
+	passphrase, err = New(WithRandomSource(zeroReader{})).Generate(profile)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if passphrase != "Correct Correct Correct" {
+		t.Errorf("Expected words of the custom list, received %s", passphrase)
+	}
+
+	if _, err := Generate(PassphraseProfile{Words: 3, WordList: []string{""}}); !errors.Is(err, ErrEmptyWordList) {
+		t.Errorf("Expected ErrEmptyWordList, received %v", err)
+	}
+	if _, err := Generate(PassphraseProfile{}); !errors.Is(err, ErrInvalidLength) {
+		t.Errorf("Expected ErrInvalidLength, received %v", err)
+	}
+}
+
+func TestPassphraseProfile_Entropy(t *testing.T) {
+	profile := PassphraseProfile{Words: 2, WordList: []string{"a", "b", "c", "d", "d"}}
+	if entropy := profile.Entropy(); entropy != 4 {
+		t.Errorf("Expected 4 bits for distinct words, received %f", entropy)
+	}
+
+	entropy := PassphraseProfile{Words: 6}.Entropy()
+	if expected := 6 * math.Log2(float64(len(WordList()))); math.Abs(entropy-expected) > 1e-9 || entropy < 60 {
+		t.Errorf("Expected %f, received %f", expected, entropy)
+	}
+}
+
+func TestWordList(t *testing.T) {
+	seen := map[string]bool{}
+	for _, word := range WordList() {
+		if seen[word] {
+			t.Errorf("Expected distinct words, received %s twice", word)
+		}
+		seen[word] = true
+		if strings.Trim(word, LowerCase) != "" {
+			t.Errorf("Expected lower case words, received %s", word)
+		}
+	}
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
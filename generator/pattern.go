@@ -0,0 +1,185 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	lowerVowels     = "aeiou"
+	upperVowels     = "AEIOU"
+	lowerConsonants = "bcdfghjklmnpqrstvwxyz"
+	upperConsonants = "BCDFGHJKLMNPQRSTVWXYZ"
+	printableASCII  = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// patternCharSets are the character sets of the KeePass pattern placeholders
+var patternCharSets = map[rune]string{
+	'a': LowerCase + Digits,
+	'A': UpperCase + LowerCase + Digits,
+	'U': UpperCase + Digits,
+	'd': Digits,
+	'h': Digits + "abcdef",
+	'H': Digits + "ABCDEF",
+	'l': LowerCase,
+	'L': UpperCase + LowerCase,
+	'u': UpperCase,
+	'v': lowerVowels,
+	'V': upperVowels + lowerVowels,
+	'Z': upperVowels,
+	'c': lowerConsonants,
+	'C': upperConsonants + lowerConsonants,
+	'z': upperConsonants,
+	'p': ",.;:",
+	'b': Brackets,
+	's': printableASCII,
+	'S': UpperCase + LowerCase + Digits + printableASCII,
+	'x': latin1Supplement(),
+}
+
+// PatternProfile generates passwords using the pattern syntax of KeePass, like `u{8}d{4}[\!]{2}`:
+// Placeholders like `d` (digit) or `u` (upper case letter) are replaced by a random character of their set,
+// `\` escapes a character to be used literally, `{n}` repeats the preceding element n times
+// and `[...]` defines a custom set of characters and placeholders, excluding the ones after `^`.
+type PatternProfile struct {
+	Pattern string
+	Permute bool // Randomly permute the characters of the generated password
+}
+
+// Entropy returns the entropy of the passwords generated with the profile in bits.
+// The entropy of invalid patterns is 0.
+func (p PatternProfile) Entropy() float64 {
+	sets, err := parsePattern(p.Pattern)
+	if err != nil {
+		return 0
+	}
+
+	var entropy float64
+	for _, set := range sets {
+		entropy += bits(len(set))
+	}
+	return entropy
+}
+
+func (p PatternProfile) generate(g *Generator) (string, error) {
+	sets, err := parsePattern(p.Pattern)
+	if err != nil {
+		return "", err
+	}
+
+	password := make([]rune, len(sets))
+	for i, set := range sets {
+		if password[i], err = g.pick(set); err != nil {
+			return "", err
+		}
+	}
+
+	if p.Permute {
+		if err := g.shuffle(password); err != nil {
+			return "", err
+		}
+	}
+	return string(password), nil
+}
+
+// parsePattern returns the character set of each character of passwords generated from pattern
+func parsePattern(pattern string) ([]charSet, error) {
+	chars := []rune(pattern)
+	var sets []charSet
+
+	for i := 0; i < len(chars); i++ {
+		switch char := chars[i]; char {
+		case '\\':
+			i++
+			if i >= len(chars) {
+				return nil, ErrInvalidPattern
+			}
+			sets = append(sets, charSet{chars[i]})
+		case '[':
+			end, set, err := parseCustomSet(chars, i+1)
+			if err != nil {
+				return nil, err
+			}
+			sets = append(sets, set)
+			i = end
+		case '{':
+			end := i + 1
+			for end < len(chars) && chars[end] != '}' {
+				end++
+			}
+			if end >= len(chars) || len(sets) == 0 {
+				return nil, ErrInvalidPattern
+			}
+			count, err := strconv.Atoi(string(chars[i+1 : end]))
+			if err != nil || count < 0 {
+				return nil, ErrInvalidPattern
+			}
+
+			last := sets[len(sets)-1]
+			sets = sets[:len(sets)-1]
+			for j := 0; j < count; j++ {
+				sets = append(sets, last)
+			}
+			i = end
+		default:
+			if placeholder, ok := patternCharSets[char]; ok {
+				sets = append(sets, charSet(placeholder))
+			} else {
+				sets = append(sets, charSet{char})
+			}
+		}
+	}
+	return sets, nil
+}
+
+// parseCustomSet parses a custom character set starting after the opening bracket at start.
+// It returns the index of the closing bracket and the characters of the set.
+func parseCustomSet(chars []rune, start int) (int, charSet, error) {
+	var set charSet
+	var excluded strings.Builder
+	exclude := false
+
+	for i := start; i < len(chars); i++ {
+		var add string
+		switch char := chars[i]; char {
+		case ']':
+			set = set.remove(excluded.String())
+			if len(set) == 0 {
+				return 0, nil, ErrEmptyCharSet
+			}
+			return i, set, nil
+		case '^':
+			exclude = true
+			continue
+		case '\\':
+			i++
+			if i >= len(chars) {
+				return 0, nil, ErrInvalidPattern
+			}
+			add = string(chars[i])
+		default:
+			add = string(char)
+			if placeholder, ok := patternCharSets[char]; ok {
+				add = placeholder
+			}
+		}
+
+		if exclude {
+			excluded.WriteString(add)
+		} else {
+			set = set.add(add)
+		}
+	}
+	return 0, nil, ErrInvalidPattern
+}
+
+// latin1Supplement returns the printable characters of the Latin-1 supplement without the soft hyphen
+func latin1Supplement() string {
+	var chars strings.Builder
+	for char := rune(0xa1); char <= 0xff; char++ {
+		if char != 0xad {
+			chars.WriteRune(char)
+		}
+	}
+	return chars.String()
+}
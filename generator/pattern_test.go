@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPatternProfile_Generate(t *testing.T) {
+	cases := []struct {
+		title    string
+		profile  PatternProfile
+		expected string
+	}{
+		{
+			title:    "placeholders and repetitions",
+			profile:  PatternProfile{Pattern: `u{8}d{4}[\!]{2}`},
+			expected: "AAAAAAAA0000!!",
+		},
+		{
+			title:    "escaped and literal characters",
+			profile:  PatternProfile{Pattern: `\d-\\u`},
+			expected: `d-\A`,
+		},
+		{
+			title:    "custom set with exclusions",
+			profile:  PatternProfile{Pattern: `[u^\A\B\C]{3}`},
+			expected: "DDD",
+		},
+		{
+			title:    "custom set with placeholders",
+			profile:  PatternProfile{Pattern: `[dh]`},
+			expected: "0",
+		},
+		{
+			title:    "zero repetitions",
+			profile:  PatternProfile{Pattern: `ud{0}`},
+			expected: "A",
+		},
+		{
+			title:    "permutation",
+			profile:  PatternProfile{Pattern: `\1\2\3\4`, Permute: true},
+			expected: "2341",
+		},
+	}
+
+	g := New(WithRandomSource(zeroReader{}))
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			password, err := g.Generate(c.profile)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if password != c.expected {
+				t.Errorf("Expected %s, received %s", c.expected, password)
+			}
+		})
+	}
+}
+
+func TestPatternProfile_GenerateRandom(t *testing.T) {
+	password, err := Generate(PatternProfile{Pattern: "u{8}d{4}", Permute: true})
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(password) != 12 || strings.Trim(password, UpperCase+Digits) != "" {
+		t.Errorf("Expected 12 upper case letters and digits, received %s", password)
+	}
+}
+
+func TestPatternProfile_Errors(t *testing.T) {
+	cases := []struct {
+		title       string
+		pattern     string
+		expectedErr error
+	}{
+		{title: "trailing escape", pattern: `d\`, expectedErr: ErrInvalidPattern},
+		{title: "unterminated set", pattern: `[abc`, expectedErr: ErrInvalidPattern},
+		{title: "empty set", pattern: `[d^d]`, expectedErr: ErrEmptyCharSet},
+		{title: "repetition without element", pattern: `{3}`, expectedErr: ErrInvalidPattern},
+		{title: "invalid repetition", pattern: `d{x}`, expectedErr: ErrInvalidPattern},
+		{title: "unterminated repetition", pattern: `d{3`, expectedErr: ErrInvalidPattern},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			if _, err := Generate(PatternProfile{Pattern: c.pattern}); !errors.Is(err, c.expectedErr) {
+				t.Errorf("Expected %v, received %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestPatternProfile_Entropy(t *testing.T) {
+	entropy := PatternProfile{Pattern: `u{8}d{4}[\!]{2}`}.Entropy()
+	if expected := 8*math.Log2(26) + 4*math.Log2(10); math.Abs(entropy-expected) > 1e-9 {
+		t.Errorf("Expected %f, received %f", expected, entropy)
+	}
+	if entropy := (PatternProfile{Pattern: `[`}).Entropy(); entropy != 0 {
+		t.Errorf("Expected no entropy for an invalid pattern, received %f", entropy)
+	}
+}
@@ -0,0 +1,94 @@
+package generator
+
+import "strings"
+
+// wordList is the built-in list of short, common English words used for passphrases
+var wordList = strings.Fields(`
+able acid acorn acre actor adapt adobe afar agent agile aging agree ahead aide aim air aisle
+alarm album alert algae alibi alien align alike alive alley allot allow alloy aloft alone along
+aloof alpha altar amber amble amend amino ample amuse angel anger angle angry ankle annex anvil
+apple apron aqua arbor arch arena argue arise armor aroma array arrow ashen aside asset atlas
+atom attic audio audit aunt avert avid avoid awake award aware awning axis axle bacon badge
+bagel baker balmy bamboo banjo banner barge barn baron basil basin basket baton beach beacon
+beard beast begin belly bench berry bike bingo birch bison blade blank blast blaze blend bless
+blimp blink bliss block bloom blot blouse blue bluff blunt blur blush board boast bonus boost
+booth boots bored boss bottle bounce bowl boxer brace braid brain brake brand brass brave bread
+break breeze brick bride brief bring brisk broad broil brook broom brush bubble bucket buddy
+budget buffet bugle build bulb bunch bundle bunny burst bush butter buzz cabin cable cactus
+cadet cage cake calm camel cameo camp canal candy canoe canon canvas canyon cape cargo carol
+carpet carrot carry carve case cash castle catch cause cedar cello chair chalk champ chant chaos
+charm chart chase cheek cheer chef cherry chess chest chew chief child chill chimp chip chirp
+choir chop chord chorus chuck chunk cider cinema circle citrus civic civil claim clamp clap
+clash clasp class clay clean clerk click cliff climb cling clock close cloth cloud clover clown
+club clue coach coast cobra cocoa coconut code coffee coil coin comet comic comma condor coral
+cord core corn couch count cousin cover coyote crab craft crane crate crawl crayon cream creek
+crest crew crisp crop cross crowd crown crumb crust cube cupid curl curry curve cushion cycle
+daily dairy daisy dance dandy darts dash data dawn deal debut decal decay decoy deed deer delta
+demo denim dense depot depth derby desk detour dial diary dice diet digit dime diner dingo dish
+dive dizzy dock dodge dolphin donor donut door dose dove draft dragon drain drama drape draw
+dream dress drift drill drink drive drone drum duck duet dune dusk dust duty dwarf eager eagle
+early earth easel east easy ebony echo eclair edge edit eel effort eight elbow elder elect elf
+elite elk elm ember emblem emerald empty enamel endure energy engine enjoy entry envoy epic
+equal equip erase error essay ether even event exact exam exile exit expo extra fable fabric
+face fact fade fair fairy faith falcon fame fancy fang farm fast fauna feast feather fence fern
+ferry fetch fever fiber field fiesta fifth fig film final finch find fire firm fish five fizz
+flag flake flame flash flask fleet flint flip float flock flood floor flora flour flow fluid
+flute foam focus foggy folk font food forest forge fork form fort forum fossil fox frame fresh
+friend frog frost fruit fudge fuel funny fur fuzzy gadget galaxy gale gallon game garage garden
+garlic gate gauge gear gecko gem genie ghost giant gift ginger giraffe given glad glass glide
+globe glory glove glow glue goal goat gold golf good goose gopher gorge gospel grace grade grain
+grand grape graph grass gravy great green grid grill grin grip groove group grove growl guard
+guava guess guest guide guitar gulf gull gummy guru gust habit hail half hammer hamster hand
+handy harbor hardy harp harvest hatch haven hawk hazel head heap heart heat hedge helmet help
+herb herd hero heron hike hill hinge hippo hobby hockey hold holly home honey hood hook hope
+horizon horn horse hose hotel hour house hover human humble humor hunt hurry husky hut hymn icon
+idea idle igloo image impact inch index inlet input insect island issue item ivory ivy jacket
+jade jaguar jam jar jazz jeans jelly jewel jigsaw job jockey jog joke jolly journal joy judge
+juice jumbo jump jungle junior jury kale kayak keen kennel kettle key kick kid kidney kind king
+kiosk kite kitten kiwi knack knee knife knock koala label lace ladder lady lagoon lake lamb lamp
+lance land lane laptop large lark laser lasso latch later laugh lava lawn layer leaf learn least
+ledge lemon lens leopard lever liberty lilac lily limb lime limit linen lion liquid list little
+lizard llama load loaf lobby lobster local lock locust lodge logic lotus loud lounge loyal lucky
+lunar lunch lyric macaw machine magic magnet maid major mango manor maple marble march margin
+marina market marsh mask mason match meadow medal melon memo mentor menu merit mesa metal meteor
+method metro middle mild mile mill mimic mind mineral mint minute mirror mist mitten mixer moat
+model modem mole moment monkey month moose morning mosaic moss motel moth motor mound mount
+mouse mouth movie muffin mule mural museum music mustard myth nail name napkin narrow nation
+native nature navy near neck nectar needle neon nephew nerve nest net new nickel niece night
+ninja noble noodle north note novel nugget number nurse nutmeg nylon oak oasis oat object ocean
+octave odor office okay olive omega onion onyx opal open opera orange orbit orchid order organ
+origin otter ounce outfit oval oven owl owner oxygen oyster pace paddle page paint palace palm
+panda panel panic pantry paper parade parcel park parrot party pasta patch path patio pause
+peach peak peanut pear pearl pebble pecan pedal pelican pencil penny pepper perch piano picnic
+piece pier pigeon pillow pilot pine pinto pioneer pipe pirate pitch pixel pizza place plain plan
+planet plank plant plate plaza plenty plot plum plume plus pocket poem poet point polar polka
+pond pony pool poppy porch portal potato pouch powder praise prawn press pride prince print
+prism prize proof prose proud prune pulse puma pumpkin punch pupil puppy purple puzzle pyramid
+quail quake quarter queen quest quick quiet quill quilt quiz quota rabbit raccoon race radar
+radio raft rain raisin rally ranch range rapid raven razor reach ready recipe record reef relax
+relay remedy remote rent reply rescue rhino rhythm ribbon rice rider ridge rifle ring rinse
+ripple river road roast robin robot rocket rodeo roof rookie room root rope rose rotor round
+route rover royal ruby rudder rug ruler rumble rustic saddle safari saga sage sail salad salmon
+salon salt salute sample sand satin sauce sauna savory scale scarf scene scent scheme school
+scoop scooter scout scrap screen scroll sculpt seal season seat second secret seed segment
+select shadow shape shark shelf shell shelter shield shift shine ship shirt shore short shovel
+shrimp siesta signal silk silver simple siren sister sketch skill skunk slate sled sleeve slice
+slide slope smile smoke snack snail snake sneeze snow soap soccer sock sofa solar solid sonar
+song sonic soup south space spark sparrow speed spell sphere spice spider spike spiral splash
+sponge spoon sport spray spring sprout spruce square squid stable stack stage stairs stamp star
+start statue steam steel stem step stereo stew stick stone stool storm story stove straw stream
+street stripe studio sugar suit summit sun sunny super surf swamp swan sweater sweet swift swing
+symbol syrup table tablet taco tail talent tally tango tank tape target tavern taxi teacup
+teapot teddy temple tempo tennis tent term thank theme thorn thread thrive throne thumb thunder
+ticket tide tiger tile timber tiny toast today toffee token tomato tone tongue tool topaz torch
+tornado tortoise total toucan towel tower town toy track trade trail train tram travel tray
+treat tree trend tribe trick trio trophy trout truck trumpet trunk tulip tuna tundra tunnel
+turkey turtle tutor tuxedo twig twin type ultra umbrella uncle under unicorn union unit upper
+urban usher utmost vacuum valley valve vanilla vapor vase vault velvet vendor venue verb verse
+vessel vest veteran video view villa vine vinyl violet violin viper visit visor vista vital
+vivid vocal voice volcano volume vote voyage wafer wagon waist walnut walrus wand warm wash wasp
+watch water wave wax weasel weather web wedge week whale wheat wheel whistle wick widget width
+wigwam willow window wing winter wire wisdom wish wizard wolf wombat wonder wood wool word world
+worm wrap wreath wren wrist xenon yacht yard yarn year yeast yellow yeti yield yodel yogurt
+young yummy zebra zero zesty zigzag zinc zipper zodiac zone zoom
+`)
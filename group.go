@@ -47,9 +47,13 @@ type Group struct {
 	EnableAutoType          w.NullableBoolWrapper `xml:"EnableAutoType"`
 	EnableSearching         w.NullableBoolWrapper `xml:"EnableSearching"`
 	LastTopVisibleEntry     string                `xml:"LastTopVisibleEntry"`
+	PreviousParentGroup     *UUID                 `xml:"PreviousParentGroup,omitempty"` // KDBX 4.1
+	Tags                    string                `xml:"Tags,omitempty"`                // KDBX 4.1
 	Entries                 []Entry               `xml:"Entry,omitempty"`
 	Groups                  []Group               `xml:"Group,omitempty"`
 	groupChildOrder         int                   `xml:"-"`
+	kdbxFormatVersion       formatVersion         `xml:"-"`
+	unknownXML              unknownXMLData        `xml:"-"`
 }
 
 // Clone creates a copy of a Group struct including its child entities
@@ -64,11 +68,30 @@ func (g Group) Clone() Group {
 	for i, group := range g.Groups {
 		clone.Groups[i] = group.Clone()
 	}
+	if g.PreviousParentGroup != nil {
+		previousParentGroup := *g.PreviousParentGroup
+		clone.PreviousParentGroup = &previousParentGroup
+	}
 	return clone
 }
 
-// UnmarshalXML unmarshals the boolean from d
-func (g *Group) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+// MarshalXML marshals the group into e, leaving out KDBX 4.1 elements for older versions
+func (g *Group) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type group Group
+
+	out := group(*g)
+	if omitKdbx41Elements(g.kdbxFormatVersion) {
+		out.PreviousParentGroup = nil
+		out.Tags = ""
+	}
+
+	return marshalXMLWithUnknown(e, start, &out, g.unknownXML)
+}
+
+// UnmarshalXML unmarshals the group from d, keeping elements unknown to the library
+func (g *Group) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	tracker := newUnknownXMLTracker(start)
+
 	for {
 		token, err := d.Token()
 		if errors.Is(err, io.EOF) {
@@ -76,14 +99,25 @@ func (g *Group) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
 		}
 		switch element := token.(type) {
 		case xml.StartElement:
-			unmarshalGroupToken(g, d, element)
+			unmarshalGroupToken(g, d, element, tracker)
 		}
 	}
 
+	g.unknownXML = tracker.data
 	return nil
 }
 
-func unmarshalGroupToken(g *Group, d *xml.Decoder, element xml.StartElement) error {
+func unmarshalGroupToken(
+	g *Group,
+	d *xml.Decoder,
+	element xml.StartElement,
+	tracker *unknownXMLTracker,
+) error {
+	if !isKnownGroupElement(element.Name.Local) {
+		return tracker.unknown(d, element)
+	}
+	defer tracker.known(element.Name.Local)
+
 	switch element.Name.Local {
 	case "Entry":
 		if g.groupChildOrder == groupChildOrderDefault {
@@ -131,11 +165,26 @@ func unmarshalGroupToken(g *Group, d *xml.Decoder, element xml.StartElement) err
 		return d.DecodeElement(&g.EnableSearching, &element)
 	case "LastTopVisibleEntry":
 		return d.DecodeElement(&g.LastTopVisibleEntry, &element)
+	case "PreviousParentGroup":
+		g.PreviousParentGroup = new(UUID)
+		return d.DecodeElement(g.PreviousParentGroup, &element)
+	case "Tags":
+		return d.DecodeElement(&g.Tags, &element)
 	}
 
 	return nil
 }
 
+func isKnownGroupElement(name string) bool {
+	switch name {
+	case "Entry", "Group", "UUID", "Name", "Notes", "IconID", "CustomIconUUID", "Times",
+		"IsExpanded", "DefaultAutoTypeSequence", "EnableAutoType", "EnableSearching",
+		"LastTopVisibleEntry", "PreviousParentGroup", "Tags":
+		return true
+	}
+	return false
+}
+
 // NewGroup returns a new group with time data and uuid set
 func NewGroup(options ...GroupOption) Group {
 	group := Group{
@@ -153,6 +202,8 @@ func NewGroup(options ...GroupOption) Group {
 }
 
 func (g *Group) setKdbxFormatVersion(version formatVersion) {
+	g.kdbxFormatVersion = version
+
 	(&g.Times).setKdbxFormatVersion(version)
 
 	for i := range g.Groups {
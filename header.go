@@ -25,10 +25,19 @@ var (
 	// DefaultKDBX4Sig is the full valid default signature struct for new databases (Kdbx v4.0)
 	DefaultKDBX4Sig = Signature{BaseSignature, SecondarySignature, 0, 4}
 
+	// DefaultKDBX41Sig is the full valid default signature struct for new databases (Kdbx v4.1)
+	DefaultKDBX41Sig = Signature{BaseSignature, SecondarySignature, 1, 4}
+
 	// DefaultSig is the full valid default signature struct for new databases (Kdbx v3.1)
 	DefaultSig = DefaultKDBX3Sig
 
 	errHeaderSHA256MisMatching = errors.New("Sha256 of header mismatching")
+
+	// keePass1Signature is the version signature of KeePass 1.x (kdb) files
+	keePass1Signature = [...]byte{0x65, 0xfb, 0x4b, 0xb5}
+
+	// preReleaseSignature is the version signature of files written by pre-release versions of KeePass 2.x
+	preReleaseSignature = [...]byte{0x66, 0xfb, 0x4b, 0xb5}
 )
 
 // Compression flags
@@ -50,14 +59,24 @@ const (
 	headerIDKdfParameters      = 11
 	headerIDPublicCustomData   = 12
 
+	// memorySize is the lowest Argon2 memory in bytes which is used by the calibration
 	memorySize = 1024 * 1024
 
-	defaultTransformRounds = 6000
+	// The key derivation parameters of new databases, matching the defaults of KeePass
+	defaultTransformRounds = 60000
+	defaultMemory          = 64 * 1024 * 1024
 	defaultParallelism     = 2
 	defaultIterations      = 2
 	defaultVersion         = 19
 
 	kdbxV4Version = 4
+
+	// maxMajorVersion is the latest major file version which can be read
+	maxMajorVersion = 4
+
+	// formatVersionMinorShift is used to store the minor version next to the major version
+	// in a formatVersion value
+	formatVersionMinorShift = 16
 )
 
 // CipherAES is the AES cipher ID
@@ -108,6 +127,14 @@ var KdfArgon2 = []byte{
 	0x03, 0xE3, 0x0A, 0x0C,
 }
 
+// KdfArgon2id is the Argon2id key derivation function ID
+var KdfArgon2id = []byte{
+	0x9E, 0x29, 0x8B, 0x19,
+	0x56, 0xDB, 0x47, 0x73,
+	0xB2, 0x3D, 0xFC, 0x3E,
+	0xC6, 0xF0, 0xA1, 0xE6,
+}
+
 // DBHeader is the header of a database
 type DBHeader struct {
 	RawData     []byte
@@ -140,6 +167,8 @@ type FileHeaders struct {
 	InnerRandomStreamID uint32             // FieldID: 10 (KDBX 3.1)
 	KdfParameters       *KdfParameters     // FieldID: 11 (KDBX 4)
 	PublicCustomData    *VariantDictionary // FieldID: 12 (KDBX 4)
+
+	calibrationFailure *kdfCalibrationFailure // Failure of WithFileHeadersKdfCalibration, returned on encoding
 }
 
 // KdfParameters contains every field of the KdfParameters header field
@@ -171,6 +200,25 @@ type VariantDictionaryItem struct {
 	Value       []byte
 }
 
+// FileHeadersOption is the option function type for use with FileHeaders structs
+type FileHeadersOption func(*FileHeaders)
+
+// WithFileHeadersKdfArgon2id can be passed to NewKDBX4FileHeaders
+// to derive the key using Argon2id instead of Argon2d
+func WithFileHeadersKdfArgon2id() FileHeadersOption {
+	return func(fh *FileHeaders) {
+		fh.KdfParameters.UUID = KdfArgon2id
+	}
+}
+
+// WithFileHeadersPublicCustomData can be passed to NewKDBX4FileHeaders
+// to store unencrypted custom data in the header
+func WithFileHeadersPublicCustomData(data *VariantDictionary) FileHeadersOption {
+	return func(fh *FileHeaders) {
+		fh.PublicCustomData = data
+	}
+}
+
 // NewHeader creates a new Header with good defaults
 func NewHeader() *DBHeader {
 	return NewKDBX3Header()
@@ -185,10 +233,18 @@ func NewKDBX3Header() *DBHeader {
 }
 
 // NewKDBX4Header creates a new Header with good defaults for KDBX4
-func NewKDBX4Header() *DBHeader {
+func NewKDBX4Header(options ...FileHeadersOption) *DBHeader {
 	return &DBHeader{
 		Signature:   &DefaultKDBX4Sig,
-		FileHeaders: NewKDBX4FileHeaders(),
+		FileHeaders: NewKDBX4FileHeaders(options...),
+	}
+}
+
+// NewKDBX41Header creates a new Header with good defaults for KDBX4.1
+func NewKDBX41Header(options ...FileHeadersOption) *DBHeader {
+	return &DBHeader{
+		Signature:   &DefaultKDBX41Sig,
+		FileHeaders: NewKDBX4FileHeaders(options...),
 	}
 }
 
@@ -228,7 +284,7 @@ func NewKDBX3FileHeaders() *FileHeaders {
 }
 
 // NewKDBX4FileHeaders creates a new FileHeaders with good defaults for KDBX4
-func NewKDBX4FileHeaders() *FileHeaders {
+func NewKDBX4FileHeaders(options ...FileHeadersOption) *FileHeaders {
 	masterSeed := make([]byte, 32)
 	rand.Read(masterSeed)
 
@@ -238,7 +294,7 @@ func NewKDBX4FileHeaders() *FileHeaders {
 	var salt [32]byte
 	rand.Read(salt[:])
 
-	return &FileHeaders{
+	fh := &FileHeaders{
 		CipherID:         CipherChaCha20,
 		CompressionFlags: GzipCompressionFlag,
 		MasterSeed:       masterSeed,
@@ -248,15 +304,31 @@ func NewKDBX4FileHeaders() *FileHeaders {
 			Rounds:      0,
 			Salt:        salt,
 			Parallelism: defaultParallelism,
-			Memory:      memorySize,
+			Memory:      defaultMemory,
 			Iterations:  defaultIterations,
 			Version:     defaultVersion,
 		},
 	}
+
+	for _, option := range options {
+		option(fh)
+	}
+
+	return fh
 }
 
-// readFrom reads the header from an io.Reader
-func (h *DBHeader) readFrom(r io.Reader) error {
+// ReadHeader reads the unencrypted header of a database from r without requiring its credentials,
+// r is left positioned after the header
+func ReadHeader(r io.Reader) (*DBHeader, error) {
+	h := new(DBHeader)
+	if err := h.readFrom(r, NewOptions()); err != nil {
+		return nil, newDecodeError(err)
+	}
+	return h, nil
+}
+
+// readFrom reads the header from an io.Reader, the size of the fields is limited by opts
+func (h *DBHeader) readFrom(r io.Reader, opts *DBOptions) error {
 	// Save read data into a buffer that will be the RawData
 	buffer := bytes.NewBuffer([]byte{})
 	tR := io.TeeReader(r, buffer)
@@ -266,15 +338,18 @@ func (h *DBHeader) readFrom(r io.Reader) error {
 	if err := binary.Read(tR, binary.LittleEndian, h.Signature); err != nil {
 		return err
 	}
+	if err := h.Signature.validate(); err != nil {
+		return err
+	}
 
 	// Read file headers
 	h.FileHeaders = new(FileHeaders)
 	for {
 		var err error
 		if h.IsKdbx4() {
-			err = h.FileHeaders.readHeader4(tR)
+			err = h.FileHeaders.readHeader4(tR, opts)
 		} else {
-			err = h.FileHeaders.readHeader31(tR)
+			err = h.FileHeaders.readHeader31(tR, opts)
 		}
 
 		// Update RawData buffer
@@ -291,10 +366,9 @@ func (h *DBHeader) readFrom(r io.Reader) error {
 }
 
 // readHeader4 reads a header of a KDBX v4 database
-func (fh *FileHeaders) readHeader4(r io.Reader) error {
+func (fh *FileHeaders) readHeader4(r io.Reader, opts *DBOptions) error {
 	var id uint8
 	var length uint32
-	var data []byte
 
 	if err := binary.Read(r, binary.LittleEndian, &id); err != nil {
 		return err
@@ -302,8 +376,11 @@ func (fh *FileHeaders) readHeader4(r io.Reader) error {
 	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
 		return err
 	}
-	data = make([]byte, length)
-	if err := binary.Read(r, binary.LittleEndian, &data); err != nil {
+	if err := checkLimit("MaxHeaderFieldSize", uint64(length), opts.MaxHeaderFieldSize); err != nil {
+		return err
+	}
+	data, err := readData(r, uint64(length))
+	if err != nil {
 		return err
 	}
 
@@ -311,10 +388,9 @@ func (fh *FileHeaders) readHeader4(r io.Reader) error {
 }
 
 // readHeader4 reads a header of a KDBX v3.1 database
-func (fh *FileHeaders) readHeader31(r io.Reader) error {
+func (fh *FileHeaders) readHeader31(r io.Reader, opts *DBOptions) error {
 	var id uint8
 	var length uint16
-	var data []byte
 
 	if err := binary.Read(r, binary.LittleEndian, &id); err != nil {
 		return err
@@ -322,16 +398,30 @@ func (fh *FileHeaders) readHeader31(r io.Reader) error {
 	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
 		return err
 	}
-	data = make([]byte, length)
-	if err := binary.Read(r, binary.LittleEndian, &data); err != nil {
+	if err := checkLimit("MaxHeaderFieldSize", uint64(length), opts.MaxHeaderFieldSize); err != nil {
+		return err
+	}
+	data, err := readData(r, uint64(length))
+	if err != nil {
 		return err
 	}
 
 	return fh.readFileHeader(id, data)
 }
 
+// fileHeaderSizes are the sizes of the header fields with a fixed size
+var fileHeaderSizes = map[uint8]int{
+	headerIDCompressionsFlags: 4,
+	headerIDTransformRounds:   8,
+	headerIDInnerRandomStream: 4,
+}
+
 // readFileHeader reads a header value and puts it into the right variable
 func (fh *FileHeaders) readFileHeader(id uint8, data []byte) error {
+	if size, ok := fileHeaderSizes[id]; ok && len(data) != size {
+		return ErrInvalidHeaderFieldLength{ID: id, Length: len(data)}
+	}
+
 	switch id {
 	case headerIDHeaderEnd:
 		return ErrEndOfHeaders
@@ -367,6 +457,16 @@ func (fh *FileHeaders) readFileHeader(id uint8, data []byte) error {
 	return nil
 }
 
+// kdfParameterSizes are the sizes of the kdf parameters with a fixed size
+var kdfParameterSizes = map[string]int{
+	"R": 8,
+	"S": 32,
+	"P": 4,
+	"M": 8,
+	"I": 8,
+	"V": 4,
+}
+
 // readKdfParameters reads a variant dictionary and puts values into KdfParameters
 func (k *KdfParameters) readKdfParameters(data []byte) error {
 	dict := new(VariantDictionary)
@@ -376,13 +476,17 @@ func (k *KdfParameters) readKdfParameters(data []byte) error {
 
 	k.RawData = dict
 	for _, item := range dict.Items {
+		if size, ok := kdfParameterSizes[string(item.Name)]; ok && len(item.Value) != size {
+			return fmt.Errorf("%w: invalid length %d of %q", ErrInvalidKdfParameters, len(item.Value), item.Name)
+		}
+
 		switch string(item.Name) {
 		case "$UUID":
 			k.UUID = item.Value
 		case "R":
 			k.Rounds = binary.LittleEndian.Uint64(item.Value)
 		case "S":
-			copy(k.Salt[:], item.Value[:32])
+			copy(k.Salt[:], item.Value)
 		case "P":
 			k.Parallelism = binary.LittleEndian.Uint32(item.Value)
 		case "M":
@@ -402,6 +506,11 @@ func (k *KdfParameters) readKdfParameters(data []byte) error {
 	return nil
 }
 
+// IsArgon2 returns true if the key derivation function is either Argon2d or Argon2id
+func (k *KdfParameters) IsArgon2() bool {
+	return bytes.Equal(k.UUID, KdfArgon2) || bytes.Equal(k.UUID, KdfArgon2id)
+}
+
 const (
 	variantDictionaryTypeUInt32 = 0x4
 	variantDictionaryTypeUInt64 = 0x5
@@ -414,107 +523,32 @@ const (
 
 // updateRawData converts the kdf parameters into rawdata again
 func (k *KdfParameters) updateRawData() {
-	dict := new(VariantDictionary)
-	dict.Version = 256
-	dict.Items = make([]*VariantDictionaryItem, 0, 9)
+	dict := NewVariantDictionary()
 
 	if len(k.UUID) > 0 {
-		uuidItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeBinary,
-			Name:  []byte("$UUID"),
-			Value: k.UUID,
-		}
-		dict.Items = append(dict.Items, uuidItem)
+		dict.SetByteArray("$UUID", k.UUID)
 	}
-
 	if k.Rounds > 0 {
-		roundsItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeUInt64,
-			Name:  []byte("R"),
-			Value: make([]byte, 8),
-		}
-		binary.LittleEndian.PutUint64(roundsItem.Value, k.Rounds)
-		dict.Items = append(dict.Items, roundsItem)
+		dict.SetUInt64("R", k.Rounds)
 	}
-
 	if k.Version > 0 {
-		versionItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeUInt32,
-			Name:  []byte("V"),
-			Value: make([]byte, 4),
-		}
-		binary.LittleEndian.PutUint32(versionItem.Value, k.Version)
-		dict.Items = append(dict.Items, versionItem)
+		dict.SetUInt32("V", k.Version)
 	}
-
 	if k.Iterations > 0 {
-		iterationsItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeUInt64,
-			Name:  []byte("I"),
-			Value: make([]byte, 8),
-		}
-		binary.LittleEndian.PutUint64(iterationsItem.Value, k.Iterations)
-		dict.Items = append(dict.Items, iterationsItem)
+		dict.SetUInt64("I", k.Iterations)
 	}
-
 	if k.Memory > 0 {
-		memoryItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeUInt64,
-			Name:  []byte("M"),
-			Value: make([]byte, 8),
-		}
-		binary.LittleEndian.PutUint64(memoryItem.Value, k.Memory)
-		dict.Items = append(dict.Items, memoryItem)
+		dict.SetUInt64("M", k.Memory)
 	}
-
 	if k.Parallelism > 0 {
-		parallelismItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeUInt32,
-			Name:  []byte("P"),
-			Value: make([]byte, 4),
-		}
-		binary.LittleEndian.PutUint32(parallelismItem.Value, k.Parallelism)
-		dict.Items = append(dict.Items, parallelismItem)
+		dict.SetUInt32("P", k.Parallelism)
 	}
-
-	if len(k.Salt) > 0 {
-		saltItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeBinary,
-			Name:  []byte("S"),
-			Value: make([]byte, 32),
-		}
-		copy(saltItem.Value[:32], k.Salt[:])
-		dict.Items = append(dict.Items, saltItem)
-	}
-
+	dict.SetByteArray("S", k.Salt[:])
 	if len(k.SecretKey) > 0 {
-		secretKeyItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeBinary,
-			Name:  []byte("K"),
-			Value: k.SecretKey,
-		}
-		dict.Items = append(dict.Items, secretKeyItem)
+		dict.SetByteArray("K", k.SecretKey)
 	}
-
 	if len(k.AssocData) > 0 {
-		assocDataItem := &VariantDictionaryItem{
-			Type:  variantDictionaryTypeBinary,
-			Name:  []byte("K"),
-			Value: k.AssocData,
-		}
-		dict.Items = append(dict.Items, assocDataItem)
-	}
-
-	// Set NameLength, ValueLength and writes data to the result
-	i := 0
-	for _, item := range dict.Items {
-		item.NameLength = int32(len(item.Name))
-		item.ValueLength = int32(len(item.Value))
-
-		if item.ValueLength > 0 {
-			dict.Items[i] = item
-			i++
-		}
+		dict.SetByteArray("A", k.AssocData)
 	}
 
 	k.RawData = dict
@@ -538,6 +572,9 @@ func (vd *VariantDictionary) readVariantDictionary(data []byte) error {
 			if err := binary.Read(r, binary.LittleEndian, &vdi.NameLength); err != nil {
 				return err
 			}
+			if vdi.NameLength < 0 || int(vdi.NameLength) > r.Len() {
+				return ErrInvalidVariantDictionary
+			}
 			vdi.Name = make([]byte, vdi.NameLength)
 			if err := binary.Read(r, binary.LittleEndian, &vdi.Name); err != nil {
 				return err
@@ -546,6 +583,9 @@ func (vd *VariantDictionary) readVariantDictionary(data []byte) error {
 			if err := binary.Read(r, binary.LittleEndian, &vdi.ValueLength); err != nil {
 				return err
 			}
+			if vdi.ValueLength < 0 || int(vdi.ValueLength) > r.Len() {
+				return ErrInvalidVariantDictionary
+			}
 			vdi.Value = make([]byte, vdi.ValueLength)
 			if err := binary.Read(r, binary.LittleEndian, &vdi.Value); err != nil {
 				return err
@@ -635,13 +675,14 @@ func writeTo4Header(w io.Writer, id uint8, data []byte) error {
 
 // writeTo4VariantDictionary is an helper to write a variant dictionary to the given io.Writer
 func writeTo4VariantDictionary(w io.Writer, id uint8, data *VariantDictionary) error {
-	if data != nil {
+	if data != nil && len(data.Items) > 0 {
 		var buffer bytes.Buffer
 		if err := binary.Write(&buffer, binary.LittleEndian, data.Version); err != nil {
 			return err
 		}
 
 		for _, item := range data.Items {
+			item.updateLengths()
 			if err := binary.Write(&buffer, binary.LittleEndian, item.Type); err != nil {
 				return err
 			}
@@ -738,29 +779,139 @@ func writeTo31Header(w io.Writer, id uint8, data []byte) error {
 	return nil
 }
 
-// Get a VariantDictionaryItem via its key
-func (vd *VariantDictionary) Get(key string) *VariantDictionaryItem {
-	for _, item := range vd.Items {
-		if string(item.Name) == key {
-			return item
-		}
+// formatVersion holds the major version in the lower 16 bits
+// and the minor version in the upper bits
+type formatVersion int
+
+func newFormatVersion(major, minor uint16) formatVersion {
+	return formatVersion(int(major) | int(minor)<<formatVersionMinorShift)
+}
+
+func (v formatVersion) major() int {
+	return int(v) & (1<<formatVersionMinorShift - 1)
+}
+
+func (v formatVersion) minor() int {
+	return int(v) >> formatVersionMinorShift
+}
+
+func isKdbx4(v formatVersion) bool {
+	return v.major() == kdbxV4Version
+}
+
+func isKdbx41(v formatVersion) bool {
+	return isKdbx4(v) && v.minor() >= 1
+}
+
+// omitKdbx41Elements returns true if elements which were introduced with KDBX 4.1
+// have to be left out when marshalling for the given version.
+// An unset version keeps all elements.
+func omitKdbx41Elements(v formatVersion) bool {
+	return v != 0 && !isKdbx41(v)
+}
+
+// validate returns an error if the signature is not the one of a supported kdbx file
+func (s *Signature) validate() error {
+	switch {
+	case s.BaseSignature != BaseSignature:
+		return ErrInvalidSignature{"BaseSignature", s.BaseSignature, BaseSignature}
+	case s.SecondarySignature == keePass1Signature:
+		return ErrKeePass1Database
+	case s.SecondarySignature == preReleaseSignature:
+		return ErrPreReleaseDatabase
+	case s.SecondarySignature != SecondarySignature:
+		return ErrInvalidSignature{"SecondarySignature", s.SecondarySignature, SecondarySignature}
+	case s.MajorVersion > maxMajorVersion:
+		return ErrUnsupportedVersion{s.MajorVersion, s.MinorVersion}
 	}
 	return nil
 }
 
-type formatVersion int
+// HeaderSummary describes the settings of a database stored in its unencrypted header
+type HeaderSummary struct {
+	MajorVersion     uint16
+	MinorVersion     uint16
+	Cipher           string // AES-256, ChaCha20, Twofish or the hexadecimal ID of an unknown cipher
+	Compressed       bool
+	KDF              string // AES-KDF, Argon2d, Argon2id or the hexadecimal ID of an unknown KDF
+	Rounds           uint64 // Rounds of AES-KDF
+	Memory           uint64 // Memory of Argon2 in bytes
+	Iterations       uint64 // Iterations of Argon2
+	Parallelism      uint32 // Parallelism of Argon2
+	PublicCustomData *VariantDictionary
+}
 
-func isKdbx4(v formatVersion) bool {
-	return v == kdbxV4Version
+// Version returns the file version like "4.1"
+func (s HeaderSummary) Version() string {
+	return fmt.Sprintf("%d.%d", s.MajorVersion, s.MinorVersion)
+}
+
+// Summary returns the settings of the database described by the header
+func (h *DBHeader) Summary() HeaderSummary {
+	fh := h.FileHeaders
+	summary := HeaderSummary{
+		MajorVersion: h.Signature.MajorVersion,
+		MinorVersion: h.Signature.MinorVersion,
+		Cipher:       cipherName(fh.CipherID),
+		Compressed:   fh.CompressionFlags == GzipCompressionFlag,
+	}
+
+	if !h.IsKdbx4() {
+		summary.KDF = kdfName(KdfAES3)
+		summary.Rounds = fh.TransformRounds
+		return summary
+	}
+
+	summary.PublicCustomData = fh.PublicCustomData
+	if k := fh.KdfParameters; k != nil {
+		summary.KDF = kdfName(k.UUID)
+		if k.IsArgon2() {
+			summary.Memory = k.Memory
+			summary.Iterations = k.Iterations
+			summary.Parallelism = k.Parallelism
+		} else {
+			summary.Rounds = k.Rounds
+		}
+	}
+	return summary
+}
+
+func cipherName(id []byte) string {
+	switch {
+	case bytes.Equal(id, CipherAES):
+		return "AES-256"
+	case bytes.Equal(id, CipherChaCha20):
+		return "ChaCha20"
+	case bytes.Equal(id, CipherTwoFish):
+		return "Twofish"
+	}
+	return fmt.Sprintf("%x", id)
+}
+
+func kdfName(id []byte) string {
+	switch {
+	case bytes.Equal(id, KdfAES3), bytes.Equal(id, KdfAES4):
+		return "AES-KDF"
+	case bytes.Equal(id, KdfArgon2):
+		return "Argon2d"
+	case bytes.Equal(id, KdfArgon2id):
+		return "Argon2id"
+	}
+	return fmt.Sprintf("%x", id)
 }
 
 // IsKdbx4 returns true if the header version equals to 4
 func (h *DBHeader) IsKdbx4() bool {
-	return isKdbx4(formatVersion(h.Signature.MajorVersion))
+	return isKdbx4(h.formatVersion())
+}
+
+// IsKdbx41 returns true if the header version is 4.1 or any later 4.x version
+func (h *DBHeader) IsKdbx41() bool {
+	return isKdbx41(h.formatVersion())
 }
 
 func (h *DBHeader) formatVersion() formatVersion {
-	return formatVersion(h.Signature.MajorVersion)
+	return newFormatVersion(h.Signature.MajorVersion, h.Signature.MinorVersion)
 }
 
 // GetSha256 returns the Sha256 hash of the header
@@ -899,6 +1050,22 @@ func (e ErrInvalidSignature) Error() string {
 	)
 }
 
+// ErrKeePass1Database is the error returned if the file is a KeePass 1.x database
+var ErrKeePass1Database = errors.New("gokeepasslib: KeePass 1.x databases (kdb) are not supported")
+
+// ErrPreReleaseDatabase is the error returned if the file has been written by a pre-release version of KeePass 2.x
+var ErrPreReleaseDatabase = errors.New("gokeepasslib: databases of pre-release versions of KeePass 2.x are not supported")
+
+// ErrUnsupportedVersion is the error returned if the file version is newer than the supported ones
+type ErrUnsupportedVersion struct {
+	MajorVersion uint16
+	MinorVersion uint16
+}
+
+func (e ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("gokeepasslib: unsupported file version %d.%d", e.MajorVersion, e.MinorVersion)
+}
+
 // ErrEndOfHeaders is the error returned when end of headers is read
 var ErrEndOfHeaders = errors.New("gokeepasslib: header id was 0, end of headers")
 
@@ -0,0 +1,140 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestReadHeader(t *testing.T) {
+	cases := []struct {
+		title      string
+		dbFilePath string
+		expected   HeaderSummary
+	}{
+		{
+			title:      "kdbx3",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			expected: HeaderSummary{
+				MajorVersion: 3,
+				MinorVersion: 1,
+				Cipher:       "AES-256",
+				Compressed:   true,
+				KDF:          "AES-KDF",
+				Rounds:       6000,
+			},
+		},
+		{
+			title:      "kdbx4 with chacha and argon2",
+			dbFilePath: "tests/kdbx4/example-chacha-argon2.kdbx",
+			expected: HeaderSummary{
+				MajorVersion: 4,
+				Cipher:       "ChaCha20",
+				Compressed:   true,
+				KDF:          "Argon2d",
+				Memory:       1048576,
+				Iterations:   2,
+				Parallelism:  2,
+			},
+		},
+		{
+			title:      "kdbx4 without compression",
+			dbFilePath: "tests/kdbx4/example-nocompression.kdbx",
+			expected: HeaderSummary{
+				MajorVersion: 4,
+				Cipher:       "AES-256",
+				KDF:          "Argon2d",
+				Memory:       1048576,
+				Iterations:   2,
+				Parallelism:  2,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			file, err := os.Open(c.dbFilePath)
+			if err != nil {
+				t.Fatalf("Failed to open keepass file: %s", err)
+			}
+			defer file.Close()
+
+			header, err := ReadHeader(file)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if summary := header.Summary(); !reflect.DeepEqual(summary, c.expected) {
+				t.Errorf("Expected %+v, received %+v", c.expected, summary)
+			}
+		})
+	}
+}
+
+func TestReadHeaderPublicCustomData(t *testing.T) {
+	publicData := NewVariantDictionary()
+	publicData.SetString("owner", "platform")
+
+	db := NewDatabase(WithDatabaseKDBXVersion41(WithFileHeadersPublicCustomData(publicData)))
+	db.Credentials = NewPasswordCredentials(password)
+
+	var buffer bytes.Buffer
+	if err := NewEncoder(&buffer).Encode(db); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	header, err := ReadHeader(&buffer)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	summary := header.Summary()
+	if summary.Version() != "4.1" {
+		t.Errorf("Expected version 4.1, received %s", summary.Version())
+	}
+	if owner, _ := summary.PublicCustomData.GetString("owner"); owner != "platform" {
+		t.Errorf("Expected the public custom data, received %q", owner)
+	}
+}
+
+func TestReadHeaderSignature(t *testing.T) {
+	cases := []struct {
+		title     string
+		signature []byte
+		expected  error
+	}{
+		{
+			title:     "keepass 1.x",
+			signature: []byte{0x03, 0xd9, 0xa2, 0x9a, 0x65, 0xfb, 0x4b, 0xb5, 0x03, 0x00, 0x03, 0x00},
+			expected:  ErrKeePass1Database,
+		},
+		{
+			title:     "pre-release",
+			signature: []byte{0x03, 0xd9, 0xa2, 0x9a, 0x66, 0xfb, 0x4b, 0xb5, 0x01, 0x00, 0x02, 0x00},
+			expected:  ErrPreReleaseDatabase,
+		},
+		{
+			title:     "invalid base signature",
+			signature: []byte{0x50, 0x4b, 0x03, 0x04, 0x67, 0xfb, 0x4b, 0xb5, 0x01, 0x00, 0x03, 0x00},
+			expected: ErrInvalidSignature{
+				"BaseSignature",
+				[4]byte{0x50, 0x4b, 0x03, 0x04},
+				BaseSignature,
+			},
+		},
+		{
+			title:     "unsupported version",
+			signature: []byte{0x03, 0xd9, 0xa2, 0x9a, 0x67, 0xfb, 0x4b, 0xb5, 0x00, 0x00, 0x05, 0x00},
+			expected:  ErrUnsupportedVersion{MajorVersion: 5},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			_, err := ReadHeader(bytes.NewReader(c.signature))
+			if !errors.Is(err, c.expected) {
+				t.Errorf("Expected error %v, received %v", c.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package gokeepasslib
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tobischo/gokeepasslib/v3/quality"
+)
+
+// DefaultHealthMinScore is the minimum quality score of passwords which are not reported as weak
+const DefaultHealthMinScore = 3
+
+// HealthIssue is a problem of an entry found by Database.HealthReport
+type HealthIssue int
+
+const (
+	HealthEmptyPassword    HealthIssue = iota // Entry has no password
+	HealthWeakPassword                        // Password is easy to guess
+	HealthBreachedPassword                    // Password is contained in the list of breached passwords
+	HealthReusedPassword                      // Password is used by other entries or their history
+	HealthExpired                             // Entry has expired
+	HealthOldPassword                         // Password has not been changed for longer than the maximum age
+)
+
+func (i HealthIssue) String() string {
+	switch i {
+	case HealthEmptyPassword:
+		return "empty password"
+	case HealthWeakPassword:
+		return "weak password"
+	case HealthBreachedPassword:
+		return "breached password"
+	case HealthReusedPassword:
+		return "reused password"
+	case HealthExpired:
+		return "expired"
+	case HealthOldPassword:
+		return "old password"
+	}
+	return "unknown"
+}
+
+// HealthOptions stores options for checking the health of a database
+type HealthOptions struct {
+	MinScore          int                 // Minimum quality.Estimate score of passwords, DefaultHealthMinScore if 0
+	MaxPasswordAge    time.Duration       // Maximum time since the last password change, not checked if 0
+	Now               time.Time           // Time to check expiry and password age against, the current time if zero
+	Breaches          *quality.BreachList // Breached passwords, not checked if nil
+	IncludeRecycleBin bool                // True to check the entries of the recycle bin
+}
+
+// HealthFinding is an issue of an entry
+type HealthFinding struct {
+	UUID        UUID
+	Title       string
+	Issue       HealthIssue
+	Score       int       // Quality score of a weak password
+	BreachCount int64     // Occurrences of a breached password in the list of breached passwords
+	Related     []UUID    // Other entries using a reused password currently or in their history
+	Time        time.Time // Expiry time of an expired entry, last change of an old password
+}
+
+func (f HealthFinding) String() string {
+	uuid, _ := f.UUID.MarshalText()
+	return fmt.Sprintf("%s: %q (%s)", f.Issue, f.Title, uuid)
+}
+
+// HealthReport lists the issues found in the entries of a database
+type HealthReport struct {
+	Findings []HealthFinding
+}
+
+// ByEntry returns the findings grouped by the UUID of their entries
+func (r HealthReport) ByEntry() map[UUID][]HealthFinding {
+	entries := map[UUID][]HealthFinding{}
+	for _, finding := range r.Findings {
+		entries[finding.UUID] = append(entries[finding.UUID], finding)
+	}
+	return entries
+}
+
+// HealthReport checks the entries of the database for empty, weak, breached, reused and old passwords
+// and for expired entries.
+// Passwords of entries with the KDBX 4.1 QualityCheck disabled are not checked for their quality,
+// that is whether they are empty, weak or breached.
+// Field references in passwords are resolved, passwords referencing other entries are not reported as reused.
+// The protected values of the database have to be unlocked.
+func (db *Database) HealthReport(opts HealthOptions) (HealthReport, error) {
+	if db.Content == nil || db.Content.Root == nil {
+		return HealthReport{}, ErrRequiredAttributeMissing("Content")
+	}
+	if opts.MinScore == 0 {
+		opts.MinScore = DefaultHealthMinScore
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	h := healthChecker{
+		db:        db,
+		opts:      opts,
+		strengths: map[string]quality.Strength{},
+		current:   map[string][]UUID{},
+		history:   map[string][]UUID{},
+	}
+	if !opts.IncludeRecycleBin && db.Content.Meta != nil {
+		h.recycleBin = db.Content.Meta.RecycleBinUUID
+	}
+	for i := range db.Content.Root.Groups {
+		h.collect(&db.Content.Root.Groups[i])
+	}
+
+	var report HealthReport
+	for _, e := range h.entries {
+		findings, err := h.check(e)
+		if err != nil {
+			return HealthReport{}, err
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	return report, nil
+}
+
+type healthChecker struct {
+	db         *Database
+	opts       HealthOptions
+	recycleBin UUID
+	entries    []*Entry
+	strengths  map[string]quality.Strength
+	current    map[string][]UUID // Entries by their current password
+	history    map[string][]UUID // Entries by the passwords of their history
+}
+
+// collect adds the entries of g and its sub groups and indexes their passwords
+func (h *healthChecker) collect(g *Group) {
+	if g.UUID != (UUID{}) && g.UUID.Compare(h.recycleBin) {
+		return
+	}
+
+	for i := range g.Entries {
+		e := &g.Entries[i]
+		h.entries = append(h.entries, e)
+		if password, ok := h.password(e); ok && password != "" {
+			h.current[password] = append(h.current[password], e.UUID)
+		}
+		for _, previous := range e.GetHistoryEntries() {
+			if password := previous.GetPassword(); password != "" && indexReference(password) < 0 {
+				h.history[password] = append(h.history[password], e.UUID)
+			}
+		}
+	}
+	for i := range g.Groups {
+		h.collect(&g.Groups[i])
+	}
+}
+
+// password returns the resolved password of e and whether it can be reused,
+// which it can not if it references the password of another entry
+func (h *healthChecker) password(e *Entry) (string, bool) {
+	password := e.GetPassword()
+	if indexReference(password) < 0 {
+		return password, true
+	}
+	if resolved, err := h.db.GetResolvedPassword(e); err == nil {
+		return resolved, false
+	}
+	return password, false
+}
+
+func (h *healthChecker) check(e *Entry) ([]HealthFinding, error) {
+	var findings []HealthFinding
+	add := func(finding HealthFinding) {
+		finding.UUID = e.UUID
+		finding.Title = e.GetTitle()
+		findings = append(findings, finding)
+	}
+
+	password, reusable := h.password(e)
+	if e.IsQualityCheckEnabled() {
+		if password == "" {
+			add(HealthFinding{Issue: HealthEmptyPassword})
+		} else {
+			if strength := h.strength(e, password); strength.Score < h.opts.MinScore {
+				add(HealthFinding{Issue: HealthWeakPassword, Score: strength.Score})
+			}
+			if h.opts.Breaches != nil {
+				count, breached, err := h.opts.Breaches.Lookup(password)
+				if err != nil {
+					return nil, err
+				}
+				if breached {
+					add(HealthFinding{Issue: HealthBreachedPassword, BreachCount: count})
+				}
+			}
+		}
+	}
+
+	if reusable && password != "" {
+		if related := h.related(e.UUID, password); len(related) > 0 {
+			add(HealthFinding{Issue: HealthReusedPassword, Related: related})
+		}
+	}
+
+	if e.Times.Expires.Bool && e.Times.ExpiryTime != nil && !e.Times.ExpiryTime.Time.After(h.opts.Now) {
+		add(HealthFinding{Issue: HealthExpired, Time: e.Times.ExpiryTime.Time})
+	}
+
+	if h.opts.MaxPasswordAge > 0 {
+		if changed := passwordChanged(e); h.opts.Now.Sub(changed) > h.opts.MaxPasswordAge {
+			add(HealthFinding{Issue: HealthOldPassword, Time: changed})
+		}
+	}
+	return findings, nil
+}
+
+// strength estimates the strength of password, considering the title and user name of e as known words
+func (h *healthChecker) strength(e *Entry, password string) quality.Strength {
+	key := password + "\x00" + e.GetTitle() + "\x00" + e.GetContent("UserName")
+	if strength, ok := h.strengths[key]; ok {
+		return strength
+	}
+	strength := quality.Estimate(password, e.GetTitle(), e.GetContent("UserName"))
+	h.strengths[key] = strength
+	return strength
+}
+
+// related returns the other entries using password currently or in their history
+func (h *healthChecker) related(uuid UUID, password string) []UUID {
+	seen := map[UUID]bool{uuid: true}
+	var related []UUID
+	for _, entries := range [][]UUID{h.current[password], h.history[password]} {
+		for _, other := range entries {
+			if !seen[other] {
+				seen[other] = true
+				related = append(related, other)
+			}
+		}
+	}
+	sort.Slice(related, func(i, j int) bool {
+		return string(related[i][:]) < string(related[j][:])
+	})
+	return related
+}
+
+// passwordChanged returns when the current password of e has been set,
+// based on the modification times of the history entries with the same password
+func passwordChanged(e *Entry) time.Time {
+	changed := mergeTime(e.Times.LastModificationTime)
+	history := e.GetHistoryEntries()
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].GetPassword() != e.GetPassword() {
+			break
+		}
+		changed = mergeTime(history[i].Times.LastModificationTime)
+	}
+	return changed
+}
@@ -0,0 +1,117 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tobischo/gokeepasslib/v3/quality"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+func addHealthEntry(db *Database, uuid UUID, password string, offset time.Duration) *Entry {
+	entry := newMergeEntry(uuid, "entry", offset)
+	entry.Values = append(entry.Values, ValueData{Key: "Password", Value: V{Content: password}})
+	group := &db.Content.Root.Groups[0].Groups[1]
+	group.Entries = append(group.Entries, entry)
+	return &group.Entries[len(group.Entries)-1]
+}
+
+func TestDatabase_HealthReport(t *testing.T) {
+	var (
+		strongUUID    = UUID{0x10}
+		weakUUID      = UUID{0x11}
+		uncheckedUUID = UUID{0x12}
+		emptyUUID     = UUID{0x13}
+		expiredUUID   = UUID{0x14}
+		oldUUID       = UUID{0x15}
+		referenceUUID = UUID{0x16}
+		historyUUID   = UUID{0x17}
+		recycledUUID  = UUID{0x18}
+	)
+
+	db := newMergeDatabase()
+	db.Content.Root.Groups[0].Groups[0].Entries = nil
+	addHealthEntry(db, strongUUID, "kX9#vL2$mQ8!wZ", 0)
+	addHealthEntry(db, weakUUID, "password", 0)
+	unchecked := w.NewBoolWrapper(false)
+	addHealthEntry(db, uncheckedUUID, "password", 0).QualityCheck = &unchecked
+	addHealthEntry(db, emptyUUID, "", 0)
+	expired := addHealthEntry(db, expiredUUID, "Rz7&pT4^nB1*cY", 0)
+	expired.Times.Expires = w.NewBoolWrapper(true)
+	expired.Times.ExpiryTime = &w.TimeWrapper{Time: mergeBaseTime}
+	addHealthEntry(db, oldUUID, "uH3@fK8%dW5!sJ", -72*time.Hour)
+	addHealthEntry(db, referenceUUID, NewFieldReference(ReferencePassword, strongUUID), 0)
+	previous := addHealthEntry(db, historyUUID, "kX9#vL2$mQ8!wZ", -time.Hour)
+	history := addHealthEntry(db, historyUUID, "Qm4!gV9&hN2#xE", 0)
+	history.Histories = []History{{Entries: []Entry{*previous}}}
+	group := &db.Content.Root.Groups[0].Groups[1]
+	group.Entries = append(group.Entries[:len(group.Entries)-2], group.Entries[len(group.Entries)-1])
+
+	db.Content.Meta.RecycleBinEnabled = w.NewBoolWrapper(true)
+	if err := db.RecycleEntry(addHealthEntry(db, recycledUUID, "", 0).UUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	sum := sha1.Sum([]byte("password"))
+	breaches := []byte(strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\r\n")
+
+	report, err := db.HealthReport(HealthOptions{
+		MaxPasswordAge: 24 * time.Hour,
+		Now:            mergeBaseTime.Add(time.Hour),
+		Breaches:       quality.NewBreachList(bytes.NewReader(breaches), int64(len(breaches))),
+	})
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	issues := map[UUID][]HealthIssue{}
+	for uuid, findings := range report.ByEntry() {
+		for _, finding := range findings {
+			issues[uuid] = append(issues[uuid], finding.Issue)
+		}
+	}
+	expected := map[UUID][]HealthIssue{
+		strongUUID:    {HealthReusedPassword},
+		weakUUID:      {HealthWeakPassword, HealthBreachedPassword, HealthReusedPassword},
+		uncheckedUUID: {HealthReusedPassword},
+		emptyUUID:     {HealthEmptyPassword},
+		expiredUUID:   {HealthExpired},
+		oldUUID:       {HealthOldPassword},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("Expected %v, received %v", expected, issues)
+	}
+
+	for _, finding := range report.Findings {
+		switch {
+		case finding.UUID == strongUUID && !reflect.DeepEqual(finding.Related, []UUID{historyUUID}):
+			t.Errorf("Expected the reuse in the history to be reported, received %v", finding.Related)
+		case finding.Issue == HealthBreachedPassword && finding.BreachCount != 42:
+			t.Errorf("Expected the breach count, received %d", finding.BreachCount)
+		case finding.Issue == HealthOldPassword && !finding.Time.Equal(mergeBaseTime.Add(-72*time.Hour)):
+			t.Errorf("Expected the time of the last password change, received %v", finding.Time)
+		}
+	}
+}
+
+func TestPasswordChanged(t *testing.T) {
+	entry := newMergeEntry(mergeEntryUUID, "entry", 0)
+	entry.Values = append(entry.Values, ValueData{Key: "Password", Value: V{Content: "current"}})
+
+	var history []Entry
+	for i, password := range []string{"old", "current", "current"} {
+		version := newMergeEntry(mergeEntryUUID, "entry", time.Duration(i-3)*time.Hour)
+		version.Values = append(version.Values, ValueData{Key: "Password", Value: V{Content: password}})
+		history = append(history, version)
+	}
+	entry.Histories = []History{{Entries: history}}
+
+	if changed := passwordChanged(&entry); !changed.Equal(mergeBaseTime.Add(-2 * time.Hour)) {
+		t.Errorf("Expected the first version with the current password, received %v", changed)
+	}
+}
@@ -0,0 +1,185 @@
+package gokeepasslib
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrHistoryIndexOutOfRange is returned when restoring a history entry which does not exist
+var ErrHistoryIndexOutOfRange = errors.New("gokeepasslib: history index out of range")
+
+// EditEntry changes the entry with the given uuid using edit, like editing an entry in KeePass:
+// The current state is stored in the history of the entry before the change,
+// the modification time is updated and the history is pruned to the limits of the meta data.
+// The entry is not changed if edit returns an error.
+func (db *Database) EditEntry(uuid UUID, edit func(*Entry) error) (*Entry, error) {
+	entry := db.FindEntryByUUID(uuid)
+	if entry == nil {
+		return nil, ErrEntryNotFound
+	}
+
+	edited := copyEntry(*entry)
+	if err := edit(&edited); err != nil {
+		return nil, err
+	}
+	edited.UUID = entry.UUID
+
+	addHistorySnapshot(&edited, *entry)
+	edited.Times.LastModificationTime = db.now()
+	edited.Times.LastAccessTime = db.now()
+	*entry = edited
+
+	db.MaintainHistory(entry)
+	return entry, nil
+}
+
+// GetHistoryEntries returns the previous versions of the entry, oldest first
+func (e *Entry) GetHistoryEntries() []Entry {
+	return historyEntries(e.Histories)
+}
+
+// RestoreEntryFromHistory replaces the entry with the given uuid by the version at index
+// of its history (see Entry.GetHistoryEntries).
+// The current state is stored in the history before, so the restore can be undone.
+func (db *Database) RestoreEntryFromHistory(uuid UUID, index int) (*Entry, error) {
+	entry := db.FindEntryByUUID(uuid)
+	if entry == nil {
+		return nil, ErrEntryNotFound
+	}
+
+	history := entry.GetHistoryEntries()
+	if index < 0 || index >= len(history) {
+		return nil, ErrHistoryIndexOutOfRange
+	}
+
+	restored := copyEntry(history[index])
+	restored.UUID = entry.UUID
+	restored.Histories = entry.Histories
+	restored.Times.LocationChanged = entry.Times.LocationChanged
+	restored.PreviousParentGroup = entry.PreviousParentGroup
+	restored.kdbxFormatVersion = entry.kdbxFormatVersion
+
+	addHistorySnapshot(&restored, *entry)
+	restored.Times.LastModificationTime = db.now()
+	restored.Times.LastAccessTime = db.now()
+	*entry = restored
+
+	db.MaintainHistory(entry)
+	return entry, nil
+}
+
+// MaintainHistory removes the oldest history entries of e until the history
+// does not exceed MetaData.HistoryMaxItems and MetaData.HistoryMaxSize anymore.
+// Negative limits are unlimited.
+// Binaries which are not referenced anymore are removed from the database.
+func (db *Database) MaintainHistory(e *Entry) {
+	if db.Content == nil || db.Content.Meta == nil {
+		return
+	}
+
+	history := e.GetHistoryEntries()
+	pruned := len(history)
+
+	if maxItems := db.Content.Meta.HistoryMaxItems; maxItems >= 0 {
+		for int64(len(history)) > maxItems {
+			history = history[1:]
+		}
+	}
+
+	if maxSize := db.Content.Meta.HistoryMaxSize; maxSize >= 0 {
+		var size int64
+		for _, entry := range history {
+			size += db.entrySize(entry)
+		}
+		for len(history) > 0 && size > maxSize {
+			size -= db.entrySize(history[0])
+			history = history[1:]
+		}
+	}
+
+	if len(history) == pruned {
+		return
+	}
+
+	e.Histories = nil
+	if len(history) > 0 {
+		e.Histories = []History{{Entries: history}}
+	}
+	db.cleanupUnusedBinaries()
+}
+
+// addHistorySnapshot adds the state of snapshot without its own history to the history of e
+func addHistorySnapshot(e *Entry, snapshot Entry) {
+	snapshot = copyEntry(withoutHistory(snapshot))
+	history := append(e.GetHistoryEntries(), snapshot)
+	e.Histories = []History{{Entries: history}}
+}
+
+// entrySize estimates the size of e in bytes by the size of its strings and binaries
+func (db *Database) entrySize(e Entry) int64 {
+	size := int64(len(e.Tags) + len(e.OverrideURL) + len(e.ForegroundColor) + len(e.BackgroundColor))
+	for _, value := range e.Values {
+		size += int64(len(value.Key) + len(value.Value.Content))
+	}
+	for _, data := range e.CustomData {
+		size += int64(len(data.Key) + len(data.Value))
+	}
+	size += int64(len(e.AutoType.DefaultSequence))
+	for _, association := range e.AutoType.Associations {
+		size += int64(len(association.Window) + len(association.KeystrokeSequence))
+	}
+	for _, ref := range e.Binaries {
+		size += int64(len(ref.Name))
+		if db.Header == nil {
+			continue
+		}
+		if binary := db.FindBinary(ref.Value.ID); binary != nil {
+			size += int64(len(binary.Content))
+		}
+	}
+	return size
+}
+
+// cleanupUnusedBinaries removes the binaries which are not referenced anymore,
+// if the binaries of the database are accessible
+func (db *Database) cleanupUnusedBinaries() {
+	if db.Header == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return
+	}
+	if db.Header.IsKdbx4() && db.Content.InnerHeader == nil {
+		return
+	}
+	db.cleanupBinaries()
+}
+
+func withoutHistory(e Entry) Entry {
+	e.Histories = nil
+	return e
+}
+
+func historyEntries(histories []History) []Entry {
+	var entries []Entry
+	for _, history := range histories {
+		entries = append(entries, history.Entries...)
+	}
+	return entries
+}
+
+// addHistoryEntries adds the entries to history, unless a version with the same
+// modification time exists already, and sorts it by modification time
+func addHistoryEntries(history []Entry, entries ...Entry) []Entry {
+	for _, entry := range entries {
+		modified := mergeTime(entry.Times.LastModificationTime)
+		if slices.ContainsFunc(history, func(e Entry) bool {
+			return mergeTime(e.Times.LastModificationTime).Equal(modified)
+		}) {
+			continue
+		}
+		history = append(history, entry)
+	}
+
+	slices.SortStableFunc(history, func(a, b Entry) int {
+		return mergeTime(a.Times.LastModificationTime).Compare(mergeTime(b.Times.LastModificationTime))
+	})
+	return history
+}
@@ -0,0 +1,181 @@
+package gokeepasslib
+
+import (
+	"errors"
+	"testing"
+)
+
+func setPassword(password string) func(*Entry) error {
+	return func(e *Entry) error {
+		if i := e.GetPasswordIndex(); i >= 0 {
+			e.Values[i].Value.Content = password
+			return nil
+		}
+		e.Values = append(e.Values, ValueData{Key: "Password", Value: V{Content: password}})
+		return nil
+	}
+}
+
+func TestDatabase_EditEntry(t *testing.T) {
+	db := newMergeDatabase()
+	binary := db.AddBinary([]byte("attached file"))
+	db.Content.Root.Groups[0].Groups[0].Entries[0].Binaries = []BinaryReference{binary.CreateReference("file.txt")}
+
+	entry, err := db.EditEntry(mergeEntryUUID, setPassword("first"))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	entry, err = db.EditEntry(mergeEntryUUID, setPassword("second"))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	if entry.GetPassword() != "second" {
+		t.Errorf("Expected the edited password, received %s", entry.GetPassword())
+	}
+	if !entry.Times.LastModificationTime.Time.After(mergeBaseTime) {
+		t.Errorf("Expected the modification time to be updated")
+	}
+
+	history := entry.GetHistoryEntries()
+	if len(history) != 2 || len(entry.Histories) != 1 {
+		t.Fatalf("Expected 2 history entries in a single history, received %+v", entry.Histories)
+	}
+	if history[0].GetPassword() != "" || history[1].GetPassword() != "first" {
+		t.Errorf("Expected the previous passwords in the history, received %+v", history)
+	}
+	for _, h := range history {
+		if len(h.Histories) != 0 {
+			t.Errorf("Expected the snapshot to not contain a history")
+		}
+		if h.UUID != mergeEntryUUID {
+			t.Errorf("Expected the snapshot to keep the UUID")
+		}
+		if len(h.Binaries) != 1 || h.Binaries[0].Value.ID != binary.ID {
+			t.Errorf("Expected the snapshot to keep the binary reference, received %+v", h.Binaries)
+		}
+	}
+
+	expectedErr := errors.New("edit failed")
+	_, err = db.EditEntry(mergeEntryUUID, func(e *Entry) error {
+		e.Tags = "changed"
+		return expectedErr
+	})
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("Expected the edit error, received %v", err)
+	}
+	if entry := db.FindEntryByUUID(mergeEntryUUID); entry.Tags != "" || len(entry.GetHistoryEntries()) != 2 {
+		t.Errorf("Expected the entry to be unchanged on error")
+	}
+
+	if _, err := db.EditEntry(NewUUID(), setPassword("x")); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("Expected ErrEntryNotFound, received %v", err)
+	}
+}
+
+func TestDatabase_MaintainHistory(t *testing.T) {
+	cases := []struct {
+		title            string
+		maxItems         int64
+		maxSize          int64
+		expectedHistory  []string
+		expectedBinaries int
+	}{
+		{
+			title:            "unlimited",
+			maxItems:         -1,
+			maxSize:          -1,
+			expectedHistory:  []string{"", "1", "2", "3"},
+			expectedBinaries: 1,
+		},
+		{
+			title:            "by item count",
+			maxItems:         2,
+			maxSize:          -1,
+			expectedHistory:  []string{"2", "3"},
+			expectedBinaries: 0,
+		},
+		{
+			title:            "by size",
+			maxItems:         -1,
+			maxSize:          45,
+			expectedHistory:  []string{"2", "3"},
+			expectedBinaries: 0,
+		},
+		{
+			title:            "without history",
+			maxItems:         0,
+			maxSize:          -1,
+			expectedBinaries: 0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := newMergeDatabase()
+			db.Content.Meta.HistoryMaxItems = -1
+			db.Content.Meta.HistoryMaxSize = -1
+
+			// Only the oldest version references a binary
+			binary := db.AddBinary([]byte("old attachment"))
+			entry := &db.Content.Root.Groups[0].Groups[0].Entries[0]
+			entry.Binaries = []BinaryReference{binary.CreateReference("a")}
+			if _, err := db.EditEntry(mergeEntryUUID, func(e *Entry) error {
+				e.Binaries = nil
+				return setPassword("1")(e)
+			}); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			for _, password := range []string{"2", "3", "4"} {
+				if _, err := db.EditEntry(mergeEntryUUID, setPassword(password)); err != nil {
+					t.Fatalf("Received unexpected error %v", err)
+				}
+			}
+
+			db.Content.Meta.HistoryMaxItems = c.maxItems
+			db.Content.Meta.HistoryMaxSize = c.maxSize
+			entry = db.FindEntryByUUID(mergeEntryUUID)
+			db.MaintainHistory(entry)
+
+			history := entry.GetHistoryEntries()
+			if len(history) != len(c.expectedHistory) {
+				t.Fatalf("Expected %d history entries, received %d", len(c.expectedHistory), len(history))
+			}
+			for i, h := range history {
+				if h.GetPassword() != c.expectedHistory[i] {
+					t.Errorf("Expected password %s, received %s", c.expectedHistory[i], h.GetPassword())
+				}
+			}
+			if len(*db.getBinaries()) != c.expectedBinaries {
+				t.Errorf("Expected %d binaries, received %d", c.expectedBinaries, len(*db.getBinaries()))
+			}
+		})
+	}
+}
+
+func TestDatabase_RestoreEntryFromHistory(t *testing.T) {
+	db := newMergeDatabase()
+
+	for _, password := range []string{"1", "2"} {
+		if _, err := db.EditEntry(mergeEntryUUID, setPassword(password)); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+	}
+
+	entry, err := db.RestoreEntryFromHistory(mergeEntryUUID, 1)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if entry.GetPassword() != "1" {
+		t.Errorf("Expected the restored password, received %s", entry.GetPassword())
+	}
+
+	history := entry.GetHistoryEntries()
+	if len(history) != 3 || history[2].GetPassword() != "2" {
+		t.Errorf("Expected the replaced version in the history, received %+v", history)
+	}
+
+	if _, err := db.RestoreEntryFromHistory(mergeEntryUUID, 3); !errors.Is(err, ErrHistoryIndexOutOfRange) {
+		t.Errorf("Expected ErrHistoryIndexOutOfRange, received %v", err)
+	}
+}
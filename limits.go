@@ -0,0 +1,123 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Default limits of the options created by NewOptions.
+// The key derivation limits allow many times the parameters calibrated to take one second.
+const (
+	DefaultMaxHeaderFieldSize = 1024 * 1024        // 1 MiB
+	DefaultMaxBinarySize      = 256 * 1024 * 1024  // 256 MiB
+	DefaultMaxKdfMemory       = 1024 * 1024 * 1024 // 1 GiB
+	DefaultMaxKdfIterations   = 10000
+	DefaultMaxKdfRounds       = 1000 * 1000 * 1000
+)
+
+// readDataChunkSize is the size up to which data is allocated at once,
+// larger data is read in chunks so that truncated input does not allocate the full length
+const readDataChunkSize = 1024 * 1024
+
+// ErrLimitExceeded is the error returned if a value read from a database exceeds a limit of its DBOptions
+type ErrLimitExceeded struct {
+	Name  string // Name of the DBOptions limit
+	Value uint64
+	Limit uint64
+}
+
+func (e ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("gokeepasslib: %s of %d exceeds the limit of %d", e.Name, e.Value, e.Limit)
+}
+
+// ErrInvalidHeaderFieldLength is the error returned if a header field of a fixed size has another length
+type ErrInvalidHeaderFieldLength struct {
+	ID     uint8
+	Length int
+}
+
+func (e ErrInvalidHeaderFieldLength) Error() string {
+	return fmt.Sprintf("gokeepasslib: invalid length %d of header field %d", e.Length, e.ID)
+}
+
+// ErrInvalidVariantDictionary is the error returned if a variant dictionary is malformed
+var ErrInvalidVariantDictionary = errors.New("gokeepasslib: invalid variant dictionary")
+
+// ErrInvalidKdfParameters is the error returned if the key derivation parameters can not be used
+var ErrInvalidKdfParameters = errors.New("gokeepasslib: invalid kdf parameters")
+
+// checkLimit returns an ErrLimitExceeded if value exceeds limit, a limit of 0 means no limit
+func checkLimit(name string, value, limit uint64) error {
+	if limit > 0 && value > limit {
+		return ErrLimitExceeded{Name: name, Value: value, Limit: limit}
+	}
+	return nil
+}
+
+// readData reads length bytes from r.
+// Large data is read in chunks, so that a length exceeding the remaining input does not allocate it.
+func readData(r io.Reader, length uint64) ([]byte, error) {
+	if length <= readDataChunkSize {
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	if length > math.MaxInt64 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var buffer bytes.Buffer
+	n, err := io.CopyN(&buffer, r, int64(length))
+	if uint64(n) < length {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// validate checks that the key derivation parameters can be used and do not exceed the limits of opts
+func (k *KdfParameters) validate(opts *DBOptions) error {
+	if opts == nil {
+		opts = &DBOptions{}
+	}
+
+	switch {
+	case k.IsArgon2():
+	case bytes.Equal(k.UUID, KdfAES3), bytes.Equal(k.UUID, KdfAES4):
+		return checkLimit("MaxKdfRounds", k.Rounds, opts.MaxKdfRounds)
+	default:
+		return fmt.Errorf("%w: %x", ErrUnsupportedKdf, k.UUID)
+	}
+
+	if k.Iterations == 0 || k.Iterations > math.MaxUint32 {
+		return fmt.Errorf("%w: %d argon2 iterations", ErrInvalidKdfParameters, k.Iterations)
+	}
+	if k.Parallelism == 0 || k.Parallelism > math.MaxUint8 {
+		return fmt.Errorf("%w: argon2 parallelism of %d", ErrInvalidKdfParameters, k.Parallelism)
+	}
+	if k.Memory/1024 > math.MaxUint32 {
+		return fmt.Errorf("%w: %d bytes of argon2 memory", ErrInvalidKdfParameters, k.Memory)
+	}
+	if err := checkLimit("MaxKdfIterations", k.Iterations, opts.MaxKdfIterations); err != nil {
+		return err
+	}
+	return checkLimit("MaxKdfMemory", k.Memory, opts.MaxKdfMemory)
+}
+
+// checkKdfLimits checks the key derivation parameters used for the version of the header against the limits of opts
+func (h *DBHeader) checkKdfLimits(opts *DBOptions) error {
+	if !h.IsKdbx4() {
+		return checkLimit("MaxKdfRounds", h.FileHeaders.TransformRounds, opts.MaxKdfRounds)
+	}
+	if h.FileHeaders.KdfParameters == nil {
+		return ErrRequiredAttributeMissing("KdfParameters")
+	}
+	return h.FileHeaders.KdfParameters.validate(opts)
+}
@@ -0,0 +1,351 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"testing"
+)
+
+// malformedHeader returns a signature followed by the header fields written by write
+func malformedHeader(t *testing.T, signature Signature, write func(w io.Writer) error) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if err := binary.Write(&buffer, binary.LittleEndian, signature); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if err := write(&buffer); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestReadHeaderMalformed(t *testing.T) {
+	cases := []struct {
+		title     string
+		signature Signature
+		write     func(w io.Writer) error
+		expectErr func(err error) bool
+	}{
+		{
+			title:     "truncated field",
+			signature: DefaultKDBX4Sig,
+			write: func(w io.Writer) error {
+				_, err := w.Write(append([]byte{headerIDComment, 100, 0x00, 0x00, 0x00}, make([]byte, 10)...))
+				return err
+			},
+			expectErr: func(err error) bool {
+				return errors.Is(err, io.ErrUnexpectedEOF)
+			},
+		},
+		{
+			title:     "oversized field",
+			signature: DefaultKDBX4Sig,
+			write: func(w io.Writer) error {
+				_, err := w.Write([]byte{headerIDComment, 0xFF, 0xFF, 0xFF, 0xFF})
+				return err
+			},
+			expectErr: func(err error) bool {
+				var limitErr ErrLimitExceeded
+				return errors.As(err, &limitErr) && limitErr.Name == "MaxHeaderFieldSize"
+			},
+		},
+		{
+			title:     "invalid length of the compression flags",
+			signature: DefaultKDBX3Sig,
+			write: func(w io.Writer) error {
+				return writeTo31Header(w, headerIDCompressionsFlags, []byte{0x01, 0x00})
+			},
+			expectErr: func(err error) bool {
+				var lengthErr ErrInvalidHeaderFieldLength
+				return errors.As(err, &lengthErr) && lengthErr.ID == headerIDCompressionsFlags
+			},
+		},
+		{
+			title:     "negative length in variant dictionary",
+			signature: DefaultKDBX4Sig,
+			write: func(w io.Writer) error {
+				// Version, UInt64 type and a name length of -1
+				dict := []byte{0x00, 0x01, variantDictionaryTypeUInt64, 0xFF, 0xFF, 0xFF, 0xFF}
+				return writeTo4Header(w, headerIDKdfParameters, dict)
+			},
+			expectErr: func(err error) bool {
+				return errors.Is(err, ErrInvalidVariantDictionary)
+			},
+		},
+		{
+			title:     "short kdf salt",
+			signature: DefaultKDBX4Sig,
+			write: func(w io.Writer) error {
+				dict := NewVariantDictionary()
+				dict.SetByteArray("S", []byte{0x01, 0x02, 0x03, 0x04})
+				return writeTo4VariantDictionary(w, headerIDKdfParameters, dict)
+			},
+			expectErr: func(err error) bool {
+				return errors.Is(err, ErrInvalidKdfParameters)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			data := malformedHeader(t, c.signature, c.write)
+			if _, err := ReadHeader(bytes.NewReader(data)); !c.expectErr(err) {
+				t.Errorf("Received unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeLimits(t *testing.T) {
+	cases := []struct {
+		title      string
+		dbFilePath string
+		options    DBOptions
+		limit      string
+	}{
+		{
+			title:      "aes-kdf rounds",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			options:    DBOptions{MaxKdfRounds: 1000},
+			limit:      "MaxKdfRounds",
+		},
+		{
+			title:      "argon2 memory",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			options:    DBOptions{MaxKdfMemory: 1024},
+			limit:      "MaxKdfMemory",
+		},
+		{
+			title:      "argon2 iterations",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			options:    DBOptions{MaxKdfIterations: 1},
+			limit:      "MaxKdfIterations",
+		},
+		{
+			title:      "header field size",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			options:    DBOptions{MaxHeaderFieldSize: 16},
+			limit:      "MaxHeaderFieldSize",
+		},
+		{
+			title:      "binary size",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			options:    DBOptions{MaxBinarySize: 1},
+			limit:      "MaxBinarySize",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			file, err := os.Open(c.dbFilePath)
+			if err != nil {
+				t.Fatalf("Failed to open keepass file: %s", err)
+			}
+			defer file.Close()
+
+			db := NewDatabase()
+			db.Credentials = NewPasswordCredentials("abcdefg12345678")
+			db.Options = &c.options
+
+			var limitErr ErrLimitExceeded
+			err = NewDecoder(file).Decode(db)
+			if !errors.As(err, &limitErr) || limitErr.Name != c.limit {
+				t.Errorf("Expected the %s limit to be exceeded, received %v", c.limit, err)
+			}
+		})
+	}
+}
+
+func TestDecodeKdbx3RoundsLimitWithKdfParameters(t *testing.T) {
+	// The kdf parameters of a Kdbx v3.1 database are not used, the transform rounds are
+	data := malformedHeader(t, DefaultKDBX3Sig, func(w io.Writer) error {
+		rounds := make([]byte, 8)
+		binary.LittleEndian.PutUint64(rounds, math.MaxUint64)
+		if err := writeTo31Header(w, headerIDTransformRounds, rounds); err != nil {
+			return err
+		}
+
+		k := &KdfParameters{UUID: KdfAES4, Rounds: 1}
+		k.updateRawData()
+		var buffer bytes.Buffer
+		if err := writeTo4VariantDictionary(&buffer, headerIDKdfParameters, k.RawData); err != nil {
+			return err
+		}
+		// Rewrite the field with the Kdbx v3.1 structure
+		if err := writeTo31Header(w, headerIDKdfParameters, buffer.Bytes()[5:]); err != nil {
+			return err
+		}
+		return writeTo31Header(w, headerIDHeaderEnd, []byte{0x0D, 0x0A, 0x0D, 0x0A})
+	})
+
+	db := NewDatabase()
+	db.Credentials = NewPasswordCredentials("abcdefg12345678")
+	db.Options.MaxKdfRounds = 60000
+
+	var limitErr ErrLimitExceeded
+	err := NewDecoder(bytes.NewReader(data)).Decode(db)
+	if !errors.As(err, &limitErr) || limitErr.Name != "MaxKdfRounds" {
+		t.Errorf("Expected the MaxKdfRounds limit to be exceeded, received %v", err)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	cases := []struct {
+		title      string
+		dbFilePath string
+	}{
+		{title: "Database Format v3.1", dbFilePath: "tests/kdbx3/example.kdbx"},
+		{title: "Database Format v4", dbFilePath: "tests/kdbx4/example.kdbx"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			data, err := os.ReadFile(c.dbFilePath)
+			if err != nil {
+				t.Fatalf("Failed to read keepass file: %s", err)
+			}
+
+			// The end of the content is verified, even though it is not required to decode the XML
+			var lengths []int
+			for length := 0; length < len(data)-64; length += 61 {
+				lengths = append(lengths, length)
+			}
+			for length := len(data) - 64; length < len(data); length++ {
+				lengths = append(lengths, length)
+			}
+
+			for _, length := range lengths {
+				db := NewDatabase()
+				db.Credentials = NewPasswordCredentials("abcdefg12345678")
+				if err := NewDecoder(bytes.NewReader(data[:length])).Decode(db); err == nil {
+					t.Errorf("Expected an error for %d of %d bytes", length, len(data))
+				}
+			}
+		})
+	}
+}
+
+func TestReadData(t *testing.T) {
+	cases := []struct {
+		title     string
+		data      []byte
+		length    uint64
+		expectErr error
+	}{
+		{title: "small", data: []byte{0x01, 0x02, 0x03}, length: 2},
+		{title: "large", data: make([]byte, readDataChunkSize+1), length: readDataChunkSize + 1},
+		{title: "truncated small", data: []byte{0x01}, length: 2, expectErr: io.ErrUnexpectedEOF},
+		{title: "truncated large", data: []byte{0x01}, length: math.MaxUint32, expectErr: io.ErrUnexpectedEOF},
+		{title: "exceeding int64", data: []byte{0x01}, length: math.MaxUint64, expectErr: io.ErrUnexpectedEOF},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			data, err := readData(bytes.NewReader(c.data), c.length)
+			if !errors.Is(err, c.expectErr) {
+				t.Fatalf("Expected error %v, received %v", c.expectErr, err)
+			}
+			if err == nil && !bytes.Equal(data, c.data[:c.length]) {
+				t.Errorf("Expected %d bytes of data, received %d", c.length, len(data))
+			}
+		})
+	}
+}
+
+func TestKdfParametersValidate(t *testing.T) {
+	cases := []struct {
+		title     string
+		params    KdfParameters
+		expectErr error
+	}{
+		{
+			title:  "valid argon2",
+			params: KdfParameters{UUID: KdfArgon2id, Memory: memorySize, Iterations: 2, Parallelism: 2},
+		},
+		{
+			title:     "no iterations",
+			params:    KdfParameters{UUID: KdfArgon2, Memory: memorySize, Parallelism: 2},
+			expectErr: ErrInvalidKdfParameters,
+		},
+		{
+			title:     "no parallelism",
+			params:    KdfParameters{UUID: KdfArgon2, Memory: memorySize, Iterations: 2},
+			expectErr: ErrInvalidKdfParameters,
+		},
+		{
+			title:     "memory exceeding argon2",
+			params:    KdfParameters{UUID: KdfArgon2, Memory: math.MaxUint64, Iterations: 2, Parallelism: 2},
+			expectErr: ErrInvalidKdfParameters,
+		},
+		{
+			title:  "aes",
+			params: KdfParameters{UUID: KdfAES4, Rounds: math.MaxUint64},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			if err := c.params.validate(nil); !errors.Is(err, c.expectErr) {
+				t.Errorf("Expected error %v, received %v", c.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestKdfParametersValidateDefaultLimits(t *testing.T) {
+	cases := []struct {
+		title  string
+		params KdfParameters
+		limit  string
+	}{
+		{
+			title:  "new database defaults",
+			params: *NewKDBX4FileHeaders().KdfParameters,
+		},
+		{
+			title:  "aes-kdf rounds",
+			params: KdfParameters{UUID: KdfAES4, Rounds: DefaultMaxKdfRounds + 1},
+			limit:  "MaxKdfRounds",
+		},
+		{
+			title:  "argon2 iterations",
+			params: KdfParameters{UUID: KdfArgon2, Memory: memorySize, Iterations: DefaultMaxKdfIterations + 1, Parallelism: 2},
+			limit:  "MaxKdfIterations",
+		},
+		{
+			title:  "argon2 memory",
+			params: KdfParameters{UUID: KdfArgon2, Memory: DefaultMaxKdfMemory + 1024, Iterations: 2, Parallelism: 2},
+			limit:  "MaxKdfMemory",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			err := c.params.validate(NewOptions())
+			if c.limit == "" {
+				if err != nil {
+					t.Errorf("Received unexpected error %v", err)
+				}
+				return
+			}
+
+			var limitErr ErrLimitExceeded
+			if !errors.As(err, &limitErr) || limitErr.Name != c.limit {
+				t.Errorf("Expected the %s limit to be exceeded, received %v", c.limit, err)
+			}
+		})
+	}
+}
+
+func TestNewOptionsLimits(t *testing.T) {
+	opts := NewOptions()
+	if opts.MaxHeaderFieldSize == 0 || opts.MaxBinarySize == 0 || opts.MaxKdfMemory == 0 ||
+		opts.MaxKdfIterations == 0 || opts.MaxKdfRounds == 0 {
+		t.Errorf("Expected every limit to be set, received %+v", opts)
+	}
+}
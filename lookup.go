@@ -0,0 +1,313 @@
+package gokeepasslib
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GroupPathSeparator separates the group names and the entry title of a path.
+// Names containing the separator or a backslash can be escaped with a backslash (`a\/b`, `a\\b`).
+const GroupPathSeparator = "/"
+
+// SearchField is a bit mask of the entry fields which are searched
+type SearchField int
+
+const (
+	SearchTitle        SearchField = 1 << iota // Title string field
+	SearchUserName                             // UserName string field
+	SearchURL                                  // URL string field
+	SearchNotes                                // Notes string field
+	SearchTags                                 // Tags of the entry
+	SearchCustomFields                         // Custom string fields which are not protected
+	SearchPassword                             // Password string field, not part of SearchAllFields
+
+	SearchAllFields = SearchTitle | SearchUserName | SearchURL | SearchNotes | SearchTags | SearchCustomFields
+)
+
+// standardFields are the string fields which are not considered custom fields
+var standardFields = map[string]SearchField{
+	"Title":    SearchTitle,
+	"UserName": SearchUserName,
+	"URL":      SearchURL,
+	"Notes":    SearchNotes,
+	"Password": SearchPassword,
+}
+
+// SearchOptions stores options for searching entries
+type SearchOptions struct {
+	Fields            SearchField // Fields to search, SearchAllFields if 0
+	Regexp            bool        // True to interpret the query as regular expression
+	CaseSensitive     bool        // True to match the case of the query
+	IncludeRecycleBin bool        // True to include the entries of the recycle bin
+	IgnoreSearchFlags bool        // True to include groups with searching disabled
+}
+
+// FindGroupByUUID returns the group with the given uuid, or nil if none is found
+func (db *Database) FindGroupByUUID(uuid UUID) *Group {
+	if db.Content == nil || db.Content.Root == nil {
+		return nil
+	}
+	group, _, _ := findGroup(db.Content.Root.Groups, uuid)
+	return group
+}
+
+// FindEntryByUUID returns the entry with the given uuid, or nil if none is found
+func (db *Database) FindEntryByUUID(uuid UUID) *Entry {
+	if db.Content == nil || db.Content.Root == nil {
+		return nil
+	}
+	entry, _, _ := findEntry(db.Content.Root.Groups, uuid)
+	return entry
+}
+
+// FindParentGroup returns the group containing the group or entry with the given uuid,
+// or nil if none is found or it is a root group
+func (db *Database) FindParentGroup(uuid UUID) *Group {
+	if db.Content == nil || db.Content.Root == nil {
+		return nil
+	}
+	if _, parent, _ := findEntry(db.Content.Root.Groups, uuid); parent != nil {
+		return parent
+	}
+	_, parent, _ := findGroup(db.Content.Root.Groups, uuid)
+	return parent
+}
+
+// FindGroupByPath returns the group at the given path like "Root/Servers",
+// starting with the name of the root group, or nil if none is found.
+// If several groups have the same name, the first one is used.
+func (db *Database) FindGroupByPath(path string) *Group {
+	if db.Content == nil || db.Content.Root == nil {
+		return nil
+	}
+
+	names := splitGroupPath(path)
+	if len(names) == 0 {
+		return nil
+	}
+	return findGroupByNames(db.Content.Root.Groups, names)
+}
+
+// FindEntryByPath returns the entry at the given path like "Root/Servers/db01",
+// consisting of the group path and the title of the entry, or nil if none is found.
+// If several groups or entries have the same name, the first one is used.
+func (db *Database) FindEntryByPath(path string) *Entry {
+	if db.Content == nil || db.Content.Root == nil {
+		return nil
+	}
+
+	names := splitGroupPath(path)
+	if len(names) < 2 {
+		return nil
+	}
+	return findEntryByNames(db.Content.Root.Groups, names)
+}
+
+// GetGroupPath returns the path of the group or entry with the given uuid,
+// or an empty string if none is found
+func (db *Database) GetGroupPath(uuid UUID) string {
+	if db.Content == nil || db.Content.Root == nil {
+		return ""
+	}
+
+	names, ok := groupPathNames(db.Content.Root.Groups, uuid)
+	if !ok {
+		return ""
+	}
+	for i := range names {
+		names[i] = strings.ReplaceAll(names[i], `\`, `\\`)
+		names[i] = strings.ReplaceAll(names[i], GroupPathSeparator, `\`+GroupPathSeparator)
+	}
+	return strings.Join(names, GroupPathSeparator)
+}
+
+// Search returns the entries matching query in any of the fields of opts.
+// Groups with EnableSearching disabled (directly or inherited from a parent group)
+// and the recycle bin are skipped unless requested otherwise.
+// The returned entries point into the database, so changes to them are kept.
+func (db *Database) Search(query string, opts SearchOptions) ([]*Entry, error) {
+	if db.Content == nil || db.Content.Root == nil {
+		return nil, ErrRequiredAttributeMissing("Content")
+	}
+
+	match, err := newSearchMatcher(query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s := searcher{
+		opts:  opts,
+		match: match,
+	}
+	if db.Content.Meta != nil {
+		s.recycleBin = db.Content.Meta.RecycleBinUUID
+	}
+	if s.opts.Fields == 0 {
+		s.opts.Fields = SearchAllFields
+	}
+
+	for i := range db.Content.Root.Groups {
+		s.searchGroup(&db.Content.Root.Groups[i], true)
+	}
+	return s.results, nil
+}
+
+func newSearchMatcher(query string, opts SearchOptions) (func(string) bool, error) {
+	if opts.Regexp {
+		if !opts.CaseSensitive {
+			query = "(?i)" + query
+		}
+		expression, err := regexp.Compile(query)
+		if err != nil {
+			return nil, err
+		}
+		return expression.MatchString, nil
+	}
+
+	if opts.CaseSensitive {
+		return func(value string) bool {
+			return strings.Contains(value, query)
+		}, nil
+	}
+
+	query = strings.ToLower(query)
+	return func(value string) bool {
+		return strings.Contains(strings.ToLower(value), query)
+	}, nil
+}
+
+type searcher struct {
+	opts       SearchOptions
+	match      func(string) bool
+	recycleBin UUID
+	results    []*Entry
+}
+
+func (s *searcher) searchGroup(g *Group, parentSearchable bool) {
+	if !s.opts.IncludeRecycleBin && g.UUID != (UUID{}) && g.UUID.Compare(s.recycleBin) {
+		return
+	}
+
+	searchable := parentSearchable
+	if g.EnableSearching.Valid {
+		searchable = g.EnableSearching.Bool
+	}
+
+	if searchable || s.opts.IgnoreSearchFlags {
+		for i := range g.Entries {
+			if s.matchEntry(&g.Entries[i]) {
+				s.results = append(s.results, &g.Entries[i])
+			}
+		}
+	}
+
+	for i := range g.Groups {
+		s.searchGroup(&g.Groups[i], searchable)
+	}
+}
+
+func (s *searcher) matchEntry(e *Entry) bool {
+	if s.opts.Fields&SearchTags != 0 && s.match(e.Tags) {
+		return true
+	}
+
+	for _, value := range e.Values {
+		field, ok := standardFields[value.Key]
+		if !ok {
+			if value.Value.Protected.Bool {
+				continue
+			}
+			field = SearchCustomFields
+		}
+
+		if s.opts.Fields&field != 0 && s.match(value.Value.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitGroupPath splits path at unescaped separators
+func splitGroupPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	var names []string
+	var name strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && strings.HasPrefix(path[i+1:], `\`) {
+			name.WriteByte('\\')
+			i++
+			continue
+		}
+		if path[i] == '\\' && strings.HasPrefix(path[i+1:], GroupPathSeparator) {
+			name.WriteString(GroupPathSeparator)
+			i += len(GroupPathSeparator)
+			continue
+		}
+		if strings.HasPrefix(path[i:], GroupPathSeparator) {
+			names = append(names, name.String())
+			name.Reset()
+			i += len(GroupPathSeparator) - 1
+			continue
+		}
+		name.WriteByte(path[i])
+	}
+	return append(names, name.String())
+}
+
+func findGroupByNames(groups []Group, names []string) *Group {
+	for i := range groups {
+		if groups[i].Name != names[0] {
+			continue
+		}
+		if len(names) == 1 {
+			return &groups[i]
+		}
+		if group := findGroupByNames(groups[i].Groups, names[1:]); group != nil {
+			return group
+		}
+	}
+	return nil
+}
+
+func findEntryByNames(groups []Group, names []string) *Entry {
+	for i := range groups {
+		if groups[i].Name != names[0] {
+			continue
+		}
+		if len(names) == 2 {
+			for j := range groups[i].Entries {
+				if groups[i].Entries[j].GetTitle() == names[1] {
+					return &groups[i].Entries[j]
+				}
+			}
+		}
+		if len(names) > 2 {
+			if entry := findEntryByNames(groups[i].Groups, names[1:]); entry != nil {
+				return entry
+			}
+		}
+	}
+	return nil
+}
+
+// groupPathNames returns the names of the groups leading to the group or entry with the given uuid
+func groupPathNames(groups []Group, uuid UUID) ([]string, bool) {
+	for i := range groups {
+		group := &groups[i]
+		if group.UUID.Compare(uuid) {
+			return []string{group.Name}, true
+		}
+		for j := range group.Entries {
+			if group.Entries[j].UUID.Compare(uuid) {
+				return []string{group.Name, group.Entries[j].GetTitle()}, true
+			}
+		}
+		if names, ok := groupPathNames(group.Groups, uuid); ok {
+			return append([]string{group.Name}, names...), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,313 @@
+package gokeepasslib
+
+import (
+	"testing"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// newLookupDatabase creates a database with the structure
+// Root/{Servers/{db01, web/{proxy}}, a\/b/{escaped}, Hidden/{Nested/{hidden}}, Recycle Bin/{deleted}}
+func newLookupDatabase() *Database {
+	db := NewDatabase()
+
+	newEntry := func(title string, values ...ValueData) Entry {
+		entry := NewEntry()
+		entry.Values = append([]ValueData{{Key: "Title", Value: V{Content: title}}}, values...)
+		return entry
+	}
+
+	web := NewGroup()
+	web.Name = "web"
+	web.Entries = []Entry{newEntry("proxy", ValueData{Key: "URL", Value: V{Content: "https://proxy.example.com"}})}
+
+	servers := NewGroup()
+	servers.Name = "Servers"
+	servers.Entries = []Entry{newEntry(
+		"db01",
+		ValueData{Key: "UserName", Value: V{Content: "Admin"}},
+		ValueData{Key: "Password", Value: V{Content: "secret", Protected: w.NewBoolWrapper(true)}},
+		ValueData{Key: "Location", Value: V{Content: "rack 4"}},
+	)}
+	servers.Entries[0].Tags = "database;production"
+	servers.Groups = []Group{web}
+
+	escaped := NewGroup()
+	escaped.Name = "a/b"
+	escaped.Entries = []Entry{newEntry("escaped")}
+
+	nested := NewGroup()
+	nested.Name = "Nested"
+	nested.EnableSearching = w.NullableBoolWrapper{}
+	nested.Entries = []Entry{newEntry("hidden")}
+
+	hidden := NewGroup()
+	hidden.Name = "Hidden"
+	hidden.EnableSearching = w.NewNullableBoolWrapper(false)
+	hidden.Groups = []Group{nested}
+
+	recycleBin := NewGroup()
+	recycleBin.Name = "Recycle Bin"
+	recycleBin.Entries = []Entry{newEntry("deleted database")}
+	db.Content.Meta.RecycleBinEnabled = w.NewBoolWrapper(true)
+	db.Content.Meta.RecycleBinUUID = recycleBin.UUID
+
+	root := NewGroup()
+	root.Name = "Root"
+	root.Groups = []Group{servers, escaped, hidden, recycleBin}
+	db.Content.Root.Groups = []Group{root}
+
+	return db
+}
+
+func TestDatabase_FindByPath(t *testing.T) {
+	db := newLookupDatabase()
+
+	groupCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "Root", expected: "Root"},
+		{path: "Root/Servers/web", expected: "web"},
+		{path: `Root/a\/b`, expected: "a/b"},
+		{path: "Root/Missing"},
+		{path: "Servers"},
+		{path: ""},
+	}
+
+	for _, c := range groupCases {
+		t.Run("group "+c.path, func(t *testing.T) {
+			group := db.FindGroupByPath(c.path)
+			if c.expected == "" {
+				if group != nil {
+					t.Errorf("Expected no group, received %s", group.Name)
+				}
+				return
+			}
+			if group == nil || group.Name != c.expected {
+				t.Errorf("Expected group %s, received %+v", c.expected, group)
+			}
+		})
+	}
+
+	entryCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "Root/Servers/db01", expected: "db01"},
+		{path: "Root/Servers/web/proxy", expected: "proxy"},
+		{path: `Root/a\/b/escaped`, expected: "escaped"},
+		{path: "Root/Servers/proxy"},
+		{path: "Root"},
+	}
+
+	for _, c := range entryCases {
+		t.Run("entry "+c.path, func(t *testing.T) {
+			entry := db.FindEntryByPath(c.path)
+			if c.expected == "" {
+				if entry != nil {
+					t.Errorf("Expected no entry, received %s", entry.GetTitle())
+				}
+				return
+			}
+			if entry == nil || entry.GetTitle() != c.expected {
+				t.Errorf("Expected entry %s, received %+v", c.expected, entry)
+			}
+		})
+	}
+}
+
+func TestDatabase_FindByUUID(t *testing.T) {
+	db := newLookupDatabase()
+	servers := db.FindGroupByPath("Root/Servers")
+	proxy := db.FindEntryByPath("Root/Servers/web/proxy")
+
+	if group := db.FindGroupByUUID(servers.UUID); group != servers {
+		t.Errorf("Expected to find the servers group, received %+v", group)
+	}
+	if entry := db.FindEntryByUUID(proxy.UUID); entry != proxy {
+		t.Errorf("Expected to find the proxy entry, received %+v", entry)
+	}
+	if entry := db.FindEntryByUUID(NewUUID()); entry != nil {
+		t.Errorf("Expected no entry, received %+v", entry)
+	}
+
+	if parent := db.FindParentGroup(proxy.UUID); parent == nil || parent.Name != "web" {
+		t.Errorf("Expected the web group as parent, received %+v", parent)
+	}
+	if parent := db.FindParentGroup(servers.UUID); parent == nil || parent.Name != "Root" {
+		t.Errorf("Expected the root group as parent, received %+v", parent)
+	}
+	if parent := db.FindParentGroup(db.Content.Root.Groups[0].UUID); parent != nil {
+		t.Errorf("Expected no parent for the root group, received %+v", parent)
+	}
+
+	if path := db.GetGroupPath(proxy.UUID); path != "Root/Servers/web/proxy" {
+		t.Errorf("Expected the path of the proxy entry, received %s", path)
+	}
+	escaped := db.FindGroupByPath(`Root/a\/b`)
+	if path := db.GetGroupPath(escaped.UUID); path != `Root/a\/b` {
+		t.Errorf("Expected the escaped path, received %s", path)
+	}
+}
+
+func TestDatabase_GetGroupPathEscaping(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "a/b", expected: `Root/a\/b`},
+		{name: `a\`, expected: `Root/a\\`},
+		{name: `a\/b`, expected: `Root/a\\\/b`},
+		{name: `\\`, expected: `Root/\\\\`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := NewDatabase()
+			db.Content.Root.Groups[0].Name = "Root"
+			group := NewGroup()
+			group.Name = c.name
+			if _, err := db.AddGroup(db.Content.Root.Groups[0].UUID, group); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			sub := NewGroup()
+			sub.Name = "sub"
+			if _, err := db.AddGroup(group.UUID, sub); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			path := db.GetGroupPath(group.UUID)
+			if path != c.expected {
+				t.Errorf("Expected the path %s, received %s", c.expected, path)
+			}
+			if found := db.FindGroupByPath(path); found == nil || found.UUID != group.UUID {
+				t.Errorf("Expected to find the group by its path %s, received %+v", path, found)
+			}
+			subPath := db.GetGroupPath(sub.UUID)
+			if found := db.FindGroupByPath(subPath); found == nil || found.UUID != sub.UUID {
+				t.Errorf("Expected to find the sub group by its path %s, received %+v", subPath, found)
+			}
+		})
+	}
+}
+
+func TestDatabase_Search(t *testing.T) {
+	cases := []struct {
+		title       string
+		query       string
+		opts        SearchOptions
+		expected    []string
+		expectedErr bool
+	}{
+		{
+			title:    "substring in title ignoring case",
+			query:    "DB0",
+			expected: []string{"db01"},
+		},
+		{
+			title: "case sensitive",
+			query: "admin",
+			opts:  SearchOptions{CaseSensitive: true},
+		},
+		{
+			title:    "user name",
+			query:    "admin",
+			expected: []string{"db01"},
+		},
+		{
+			title:    "url",
+			query:    "example.com",
+			expected: []string{"proxy"},
+		},
+		{
+			title:    "tags",
+			query:    "production",
+			expected: []string{"db01"},
+		},
+		{
+			title:    "custom fields",
+			query:    "rack",
+			expected: []string{"db01"},
+		},
+		{
+			title: "restricted fields",
+			query: "rack",
+			opts:  SearchOptions{Fields: SearchTitle | SearchURL},
+		},
+		{
+			title: "password is not searched by default",
+			query: "secret",
+		},
+		{
+			title:    "password",
+			query:    "secret",
+			opts:     SearchOptions{Fields: SearchPassword},
+			expected: []string{"db01"},
+		},
+		{
+			title:    "regular expression",
+			query:    "^(db|pro)[0-9x]+",
+			opts:     SearchOptions{Regexp: true},
+			expected: []string{"db01", "proxy"},
+		},
+		{
+			title:       "invalid regular expression",
+			query:       "(",
+			opts:        SearchOptions{Regexp: true},
+			expectedErr: true,
+		},
+		{
+			title:    "skips recycle bin and disabled groups",
+			query:    "d",
+			expected: []string{"db01", "escaped"},
+		},
+		{
+			title:    "includes recycle bin",
+			query:    "deleted",
+			opts:     SearchOptions{IncludeRecycleBin: true},
+			expected: []string{"deleted database"},
+		},
+		{
+			title:    "ignores search flags",
+			query:    "hidden",
+			opts:     SearchOptions{IgnoreSearchFlags: true},
+			expected: []string{"hidden"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := newLookupDatabase()
+
+			results, err := db.Search(c.query, c.opts)
+			if (err != nil) != c.expectedErr {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			if len(results) != len(c.expected) {
+				t.Fatalf("Expected %d results, received %d", len(c.expected), len(results))
+			}
+			for i, entry := range results {
+				if entry.GetTitle() != c.expected[i] {
+					t.Errorf("Expected %s, received %s", c.expected[i], entry.GetTitle())
+				}
+			}
+		})
+	}
+}
+
+func TestDatabase_SearchResultsPointIntoDatabase(t *testing.T) {
+	db := newLookupDatabase()
+
+	results, err := db.Search("proxy", SearchOptions{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected a single result, received %v, %v", results, err)
+	}
+
+	results[0].Tags = "changed"
+	if entry := db.FindEntryByPath("Root/Servers/web/proxy"); entry.Tags != "changed" {
+		t.Errorf("Expected the change to be kept in the database")
+	}
+}
@@ -0,0 +1,217 @@
+package gokeepasslib
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// ErrUnsupportedMasterKeyOption is returned by Database.ChangeMasterKey
+// for an option which does not apply to the format of the database
+var ErrUnsupportedMasterKeyOption = errors.New("gokeepasslib: master key option not supported by the database format")
+
+// MasterKeyOption is the option function type for use with Database.ChangeMasterKey
+type MasterKeyOption func(*masterKeyChange)
+
+type masterKeyChange struct {
+	kdfParameters   *KdfParameters
+	transformRounds uint64
+	now             time.Time
+}
+
+// WithMasterKeyKdfParameters replaces the key derivation parameters of a KDBX 4 database,
+// the salt is generated
+func WithMasterKeyKdfParameters(parameters KdfParameters) MasterKeyOption {
+	return func(c *masterKeyChange) {
+		c.kdfParameters = &parameters
+	}
+}
+
+// WithMasterKeyTransformRounds replaces the AES-KDF rounds of a KDBX 3.1 database
+func WithMasterKeyTransformRounds(rounds uint64) MasterKeyOption {
+	return func(c *masterKeyChange) {
+		c.transformRounds = rounds
+	}
+}
+
+// WithMasterKeyTime sets the time the master key is changed at, the current time by default
+func WithMasterKeyTime(now time.Time) MasterKeyOption {
+	return func(c *masterKeyChange) {
+		c.now = now
+	}
+}
+
+// ChangeMasterKey sets new credentials, generates new seeds, IVs, key derivation salts and inner stream keys,
+// and re-protects locked protected values with the new inner stream key.
+// Protected values unlocked by UnlockProtectedEntries stay unlocked and are locked with the new key later.
+// The time of the change is stored in MetaData.MasterKeyChanged.
+// ErrUnsupportedMasterKeyOption is returned for options of another format,
+// and ErrInvalidKdfParameters for key derivation parameters which can not be used.
+func (db *Database) ChangeMasterKey(credentials *DBCredentials, options ...MasterKeyOption) error {
+	if credentials == nil {
+		return ErrRequiredAttributeMissing("Credentials")
+	}
+	if db.Header == nil || db.Header.FileHeaders == nil {
+		return ErrRequiredAttributeMissing("Header")
+	}
+	if db.Content == nil || db.Content.Meta == nil {
+		return ErrRequiredAttributeMissing("Content")
+	}
+
+	c := masterKeyChange{now: time.Now()}
+	for _, option := range options {
+		option(&c)
+	}
+
+	if db.Header.FileHeaders.KdfParameters == nil {
+		if c.kdfParameters != nil {
+			return fmt.Errorf("%w: KDF parameters of a KDBX 3.1 database", ErrUnsupportedMasterKeyOption)
+		}
+	} else {
+		if c.transformRounds > 0 {
+			return fmt.Errorf("%w: transform rounds of a KDBX 4 database", ErrUnsupportedMasterKeyOption)
+		}
+		if c.kdfParameters != nil {
+			if err := c.kdfParameters.validate(nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	err := db.renewSeeds(func(fh *FileHeaders) {
+		if fh.KdfParameters == nil {
+			fh.TransformSeed = randomBytes(len(fh.TransformSeed))
+			if c.transformRounds > 0 {
+				fh.TransformRounds = c.transformRounds
+			}
+			return
+		}
+
+		kdfParameters := *fh.KdfParameters
+		if c.kdfParameters != nil {
+			kdfParameters = *c.kdfParameters
+		}
+		rand.Read(kdfParameters.Salt[:])
+		fh.KdfParameters = &kdfParameters
+	})
+	if err != nil {
+		return err
+	}
+
+	db.Credentials = credentials
+	db.Wipe()
+	db.Content.Meta.MasterKeyChanged = &w.TimeWrapper{Formatted: true, Time: c.now.In(time.UTC)}
+	return nil
+}
+
+// renewSeeds replaces the master seed, encryption IV, inner stream key and stream start bytes
+// of the database with new random values, calls update with the new file headers,
+// and re-protects locked protected values with the new inner stream key,
+// unlocked ones stay unlocked. The database is left unchanged if an error is returned.
+func (db *Database) renewSeeds(update func(fh *FileHeaders)) error {
+	if db.Header == nil || db.Header.FileHeaders == nil {
+		return ErrRequiredAttributeMissing("Header")
+	}
+	if db.Content == nil || db.Content.Root == nil {
+		return ErrRequiredAttributeMissing("Content")
+	}
+
+	oldManager, err := db.GetStreamManager()
+	if err != nil {
+		return err
+	}
+	if oldManager == nil {
+		return ErrUnsupportedStreamType
+	}
+
+	fh := *db.Header.FileHeaders
+	fh.MasterSeed = randomBytes(len(fh.MasterSeed))
+	fh.EncryptionIV = randomBytes(len(fh.EncryptionIV))
+
+	var innerHeader *InnerHeader
+	var newManager *StreamManager
+	if db.Header.IsKdbx4() {
+		if db.Content.InnerHeader == nil {
+			return ErrRequiredAttributeMissing("InnerHeader")
+		}
+		ih := *db.Content.InnerHeader
+		ih.InnerRandomStreamKey = randomBytes(len(ih.InnerRandomStreamKey))
+		innerHeader = &ih
+		newManager, err = NewStreamManager(ih.InnerRandomStreamID, ih.InnerRandomStreamKey)
+	} else {
+		fh.ProtectedStreamKey = randomBytes(len(fh.ProtectedStreamKey))
+		fh.StreamStartBytes = randomBytes(len(fh.StreamStartBytes))
+		newManager, err = NewStreamManager(fh.InnerRandomStreamID, fh.ProtectedStreamKey)
+	}
+	if err != nil {
+		return err
+	}
+	if newManager == nil {
+		return ErrUnsupportedStreamType
+	}
+
+	if update != nil {
+		update(&fh)
+	}
+
+	if !db.protectedUnlocked {
+		oldManager.UnlockProtectedGroups(db.Content.Root.Groups)
+	}
+	db.Header.FileHeaders = &fh
+	if innerHeader != nil {
+		db.Content.InnerHeader = innerHeader
+	}
+	if !db.protectedUnlocked {
+		newManager.LockProtectedGroups(db.Content.Root.Groups)
+	}
+	return nil
+}
+
+func randomBytes(length int) []byte {
+	data := make([]byte, length)
+	rand.Read(data)
+	return data
+}
+
+// MasterKeyChangeStatus is the result of checking the master key change policy of a database
+type MasterKeyChangeStatus int
+
+const (
+	MasterKeyChangeNotRequired MasterKeyChangeStatus = iota // The master key does not need to be changed
+	MasterKeyChangeRecommended                              // MasterKeyChangeRec days have passed since the last change
+	MasterKeyChangeForced                                   // MasterKeyChangeForce days have passed since the last change
+)
+
+func (s MasterKeyChangeStatus) String() string {
+	switch s {
+	case MasterKeyChangeRecommended:
+		return "recommended"
+	case MasterKeyChangeForced:
+		return "forced"
+	}
+	return "not required"
+}
+
+// CheckMasterKeyChange checks whether the master key is overdue for a change at the given time
+// according to MetaData.MasterKeyChangeRec and MetaData.MasterKeyChangeForce, which are disabled if negative
+func (db *Database) CheckMasterKeyChange(now time.Time) MasterKeyChangeStatus {
+	if db.Content == nil || db.Content.Meta == nil {
+		return MasterKeyChangeNotRequired
+	}
+	meta := db.Content.Meta
+
+	age := now.Sub(mergeTime(meta.MasterKeyChanged))
+	overdue := func(days int64) bool {
+		return days >= 0 && age >= time.Duration(days)*24*time.Hour
+	}
+	switch {
+	case overdue(meta.MasterKeyChangeForce):
+		return MasterKeyChangeForced
+	case overdue(meta.MasterKeyChangeRec):
+		return MasterKeyChangeRecommended
+	}
+	return MasterKeyChangeNotRequired
+}
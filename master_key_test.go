@@ -0,0 +1,259 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func decodeTestFile(t *testing.T, dbFilePath string, credentials *DBCredentials) *Database {
+	t.Helper()
+
+	file, err := os.Open(dbFilePath)
+	if err != nil {
+		t.Fatalf("Failed to open keepass file: %s", err)
+	}
+	defer file.Close()
+
+	db := NewDatabase()
+	db.Credentials = credentials
+	if err := NewDecoder(file).Decode(db); err != nil {
+		t.Fatalf("Failed to decode file: %s", err)
+	}
+	return db
+}
+
+func TestDatabase_ChangeMasterKey(t *testing.T) {
+	cases := []struct {
+		title      string
+		dbFilePath string
+		options    []MasterKeyOption
+		check      func(t *testing.T, db *Database)
+	}{
+		{
+			title:      "Database Format v3.1",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			options:    []MasterKeyOption{WithMasterKeyTransformRounds(1000)},
+			check: func(t *testing.T, db *Database) {
+				if db.Header.FileHeaders.TransformRounds != 1000 {
+					t.Errorf("Expected 1000 rounds, received %d", db.Header.FileHeaders.TransformRounds)
+				}
+			},
+		},
+		{
+			title:      "Database Format v4",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			options: []MasterKeyOption{WithMasterKeyKdfParameters(KdfParameters{
+				UUID:        KdfArgon2id,
+				Memory:      memorySize,
+				Iterations:  3,
+				Parallelism: 1,
+				Version:     defaultVersion,
+			})},
+			check: func(t *testing.T, db *Database) {
+				kdf := db.Header.FileHeaders.KdfParameters
+				if db.Header.Summary().KDF != "Argon2id" || kdf.Iterations != 3 {
+					t.Errorf("Expected the new KDF parameters, received %v", kdf)
+				}
+				if kdf.Salt == [32]byte{} {
+					t.Errorf("Expected a salt to be generated")
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			oldCredentials := NewPasswordCredentials("abcdefg12345678")
+			db := decodeTestFile(t, c.dbFilePath, oldCredentials)
+			oldHeaders := *db.Header.FileHeaders
+
+			changed := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+			newCredentials := NewPasswordCredentials("new password")
+			options := append([]MasterKeyOption{WithMasterKeyTime(changed)}, c.options...)
+			if err := db.ChangeMasterKey(newCredentials, options...); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			fh := db.Header.FileHeaders
+			if bytes.Equal(fh.MasterSeed, oldHeaders.MasterSeed) || bytes.Equal(fh.EncryptionIV, oldHeaders.EncryptionIV) {
+				t.Errorf("Expected a new master seed and encryption IV")
+			}
+			if !db.Content.Meta.MasterKeyChanged.Time.Equal(changed) {
+				t.Errorf("Expected the master key change time %v, received %v", changed, db.Content.Meta.MasterKeyChanged.Time)
+			}
+			c.check(t, db)
+
+			var buffer bytes.Buffer
+			if err := NewEncoder(&buffer).Encode(db); err != nil {
+				t.Fatalf("Failed to encode file: %s", err)
+			}
+			encoded := buffer.Bytes()
+
+			rejected := NewDatabase()
+			rejected.Credentials = oldCredentials
+			if err := NewDecoder(bytes.NewReader(encoded)).Decode(rejected); err == nil {
+				t.Errorf("Expected the old credentials to be rejected")
+			}
+
+			decoded := NewDatabase()
+			decoded.Credentials = newCredentials
+			if err := NewDecoder(bytes.NewReader(encoded)).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode file: %s", err)
+			}
+			if err := decoded.UnlockProtectedEntries(); err != nil {
+				t.Fatalf("Problem unlocking entries. %s", err)
+			}
+
+			entry := decoded.Content.Root.Groups[0].Groups[0].Entries[0]
+			if pw := entry.GetPassword(); pw != password {
+				t.Errorf("Expected the protected password to be re-protected, received %q", pw)
+			}
+		})
+	}
+}
+
+func TestDatabase_ChangeMasterKeyInvalidOptions(t *testing.T) {
+	cases := []struct {
+		title      string
+		dbFilePath string
+		option     MasterKeyOption
+		expected   error
+	}{
+		{
+			title:      "kdf parameters of Database Format v3.1",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			option:     WithMasterKeyKdfParameters(KdfParameters{UUID: KdfAES3, Rounds: 1000}),
+			expected:   ErrUnsupportedMasterKeyOption,
+		},
+		{
+			title:      "transform rounds of Database Format v4",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			option:     WithMasterKeyTransformRounds(1000),
+			expected:   ErrUnsupportedMasterKeyOption,
+		},
+		{
+			title:      "invalid kdf parameters",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			option: WithMasterKeyKdfParameters(KdfParameters{
+				UUID:       KdfArgon2,
+				Memory:     memorySize,
+				Iterations: 2,
+				Version:    defaultVersion,
+			}),
+			expected: ErrInvalidKdfParameters,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			credentials := NewPasswordCredentials("abcdefg12345678")
+			db := decodeTestFile(t, c.dbFilePath, credentials)
+			fh := db.Header.FileHeaders
+
+			err := db.ChangeMasterKey(NewPasswordCredentials("new password"), c.option)
+			if !errors.Is(err, c.expected) {
+				t.Errorf("Expected error %v, received %v", c.expected, err)
+			}
+			if db.Credentials != credentials || db.Header.FileHeaders != fh {
+				t.Errorf("Expected the database to be unchanged")
+			}
+		})
+	}
+}
+
+func TestDatabase_ChangeMasterKeyUnlocked(t *testing.T) {
+	for _, dbFilePath := range []string{"tests/kdbx3/example.kdbx", "tests/kdbx4/example.kdbx"} {
+		t.Run(dbFilePath, func(t *testing.T) {
+			db := decodeTestFile(t, dbFilePath, NewPasswordCredentials("abcdefg12345678"))
+			if err := db.UnlockProtectedEntries(); err != nil {
+				t.Fatalf("Problem unlocking entries. %s", err)
+			}
+
+			newCredentials := NewPasswordCredentials("new password")
+			if err := db.ChangeMasterKey(newCredentials); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			entry := &db.Content.Root.Groups[0].Groups[0].Entries[0]
+			if pw := entry.GetPassword(); pw != password {
+				t.Errorf("Expected the password to stay unlocked, received %q", pw)
+			}
+
+			var buffer bytes.Buffer
+			if err := NewEncoder(&buffer).Encode(db); err != nil {
+				t.Fatalf("Failed to encode file: %s", err)
+			}
+			if pw := entry.GetPassword(); pw == password {
+				t.Errorf("Expected encoding to lock the password")
+			}
+
+			decoded := NewDatabase()
+			decoded.Credentials = newCredentials
+			if err := NewDecoder(bytes.NewReader(buffer.Bytes())).Decode(decoded); err != nil {
+				t.Fatalf("Failed to decode file: %s", err)
+			}
+			if err := decoded.UnlockProtectedEntries(); err != nil {
+				t.Fatalf("Problem unlocking entries. %s", err)
+			}
+			if pw := decoded.Content.Root.Groups[0].Groups[0].Entries[0].GetPassword(); pw != password {
+				t.Errorf("Expected the protected password %q, received %q", password, pw)
+			}
+		})
+	}
+}
+
+func TestDatabase_CheckMasterKeyChange(t *testing.T) {
+	changed := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		title    string
+		rec      int64
+		force    int64
+		now      time.Time
+		expected MasterKeyChangeStatus
+	}{
+		{
+			title:    "disabled",
+			rec:      -1,
+			force:    -1,
+			now:      changed.AddDate(10, 0, 0),
+			expected: MasterKeyChangeNotRequired,
+		},
+		{
+			title:    "not yet recommended",
+			rec:      30,
+			force:    90,
+			now:      changed.AddDate(0, 0, 29),
+			expected: MasterKeyChangeNotRequired,
+		},
+		{
+			title:    "recommended",
+			rec:      30,
+			force:    90,
+			now:      changed.AddDate(0, 0, 30),
+			expected: MasterKeyChangeRecommended,
+		},
+		{
+			title:    "forced",
+			rec:      30,
+			force:    90,
+			now:      changed.AddDate(0, 0, 100),
+			expected: MasterKeyChangeForced,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := NewDatabase()
+			db.Content.Meta.MasterKeyChanged.Time = changed
+			db.Content.Meta.MasterKeyChangeRec = c.rec
+			db.Content.Meta.MasterKeyChangeForce = c.force
+
+			if status := db.CheckMasterKeyChange(c.now); status != c.expected {
+				t.Errorf("Expected %s, received %s", c.expected, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,605 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"time"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// MergeMode defines how objects which exist in both databases are merged
+type MergeMode int
+
+const (
+	// MergeModeSynchronize keeps the most recently modified version of an object
+	MergeModeSynchronize MergeMode = iota
+	// MergeModeKeepExisting keeps the existing objects including their history and only adds new ones
+	MergeModeKeepExisting
+	// MergeModeOverwriteExisting replaces existing objects with the version of the other database
+	MergeModeOverwriteExisting
+)
+
+// MergeOptions stores options for merging databases
+type MergeOptions struct {
+	Mode           MergeMode
+	DisableHistory bool // True to replace entries without keeping the replaced version in their history
+}
+
+// MergeChangeType describes what happened to an object during a merge
+type MergeChangeType int
+
+const (
+	MergeChangeAdded          MergeChangeType = iota // Object has been added
+	MergeChangeUpdated                               // Object has been replaced by the version of the other database
+	MergeChangeHistoryUpdated                        // Entry history has been extended
+	MergeChangeMoved                                 // Object has been moved to another group
+	MergeChangeDeleted                               // Object has been removed due to a deleted object record
+)
+
+func (t MergeChangeType) String() string {
+	switch t {
+	case MergeChangeAdded:
+		return "added"
+	case MergeChangeUpdated:
+		return "updated"
+	case MergeChangeHistoryUpdated:
+		return "history updated"
+	case MergeChangeMoved:
+		return "moved"
+	case MergeChangeDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// MergeObjectType describes the kind of object a MergeChange refers to
+type MergeObjectType int
+
+const (
+	MergeObjectEntry MergeObjectType = iota
+	MergeObjectGroup
+	MergeObjectCustomIcon
+)
+
+func (t MergeObjectType) String() string {
+	switch t {
+	case MergeObjectEntry:
+		return "entry"
+	case MergeObjectGroup:
+		return "group"
+	case MergeObjectCustomIcon:
+		return "custom icon"
+	}
+	return "unknown"
+}
+
+// MergeChange describes a single change made to the database during a merge
+type MergeChange struct {
+	Type   MergeChangeType
+	Object MergeObjectType
+	UUID   UUID
+	Name   string // Title of an entry, name of a group or custom icon
+}
+
+func (c MergeChange) String() string {
+	uuid, _ := c.UUID.MarshalText()
+	return fmt.Sprintf("%s %s %q (%s)", c.Object, c.Type, c.Name, uuid)
+}
+
+// MergeReport lists the changes made to the database during a merge
+type MergeReport struct {
+	Changes []MergeChange
+}
+
+// HasChanges returns whether the merge changed the database
+func (r MergeReport) HasChanges() bool {
+	return len(r.Changes) > 0
+}
+
+func (r *MergeReport) add(changeType MergeChangeType, object MergeObjectType, uuid UUID, name string) {
+	r.Changes = append(r.Changes, MergeChange{
+		Type:   changeType,
+		Object: object,
+		UUID:   uuid,
+		Name:   name,
+	})
+}
+
+// Merge merges the groups, entries, custom icons and binaries of other into db,
+// similar to the synchronization of KeePass.
+// Groups and entries are matched by their UUID, moves are applied based on LocationChanged
+// and the deleted objects of both databases are applied.
+// The protected values of both databases have to be unlocked before merging.
+func (db *Database) Merge(other *Database, opts MergeOptions) (MergeReport, error) {
+	if err := checkMergeDatabase(db); err != nil {
+		return MergeReport{}, err
+	}
+	if err := checkMergeDatabase(other); err != nil {
+		return MergeReport{}, err
+	}
+
+	m := &merger{
+		db:       db,
+		other:    other,
+		opts:     opts,
+		binaries: binaryIDs{},
+		root:     db.Content.Root.Groups[0].UUID,
+	}
+
+	m.mergeDeletedObjects()
+	m.mergeCustomIcons()
+
+	otherRoot := &other.Content.Root.Groups[0]
+	if otherRoot.UUID == m.root && m.replaces(
+		otherRoot.Times.LastModificationTime,
+		db.Content.Root.Groups[0].Times.LastModificationTime,
+	) {
+		m.updateGroup(&db.Content.Root.Groups[0], otherRoot)
+	}
+
+	if err := m.mergeChildren(m.root, otherRoot); err != nil {
+		return MergeReport{}, err
+	}
+	m.relocateChildren(m.root, otherRoot)
+	m.applyDeletions(&db.Content.Root.Groups[0])
+
+	if db.Header != nil {
+		db.ensureKdbxFormatVersion()
+	}
+
+	return m.report, nil
+}
+
+func checkMergeDatabase(db *Database) error {
+	if db == nil || db.Header == nil {
+		return ErrRequiredAttributeMissing("Header")
+	}
+	if db.Content == nil || db.Content.Meta == nil || db.Content.Root == nil ||
+		len(db.Content.Root.Groups) == 0 {
+		return ErrRequiredAttributeMissing("Content")
+	}
+	if db.Header.IsKdbx4() && db.Content.InnerHeader == nil {
+		return ErrRequiredAttributeMissing("InnerHeader")
+	}
+	return nil
+}
+
+// binaryIDs maps the binary IDs of the other database to the ones of the merged database
+type binaryIDs map[int]int
+
+type merger struct {
+	db       *Database
+	other    *Database
+	opts     MergeOptions
+	report   MergeReport
+	binaries binaryIDs
+	contents map[int][]byte // Decoded binary contents of db by ID, loaded on first use
+	root     UUID
+}
+
+func mergeTime(t *w.TimeWrapper) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time
+}
+
+// replaces returns whether the version of the other database modified at otherTime
+// replaces the existing version modified at existingTime
+func (m *merger) replaces(otherTime, existingTime *w.TimeWrapper) bool {
+	switch m.opts.Mode {
+	case MergeModeKeepExisting:
+		return false
+	case MergeModeOverwriteExisting:
+		return !mergeTime(otherTime).Equal(mergeTime(existingTime))
+	}
+	return mergeTime(otherTime).After(mergeTime(existingTime))
+}
+
+// mergeDeletedObjects adds the deleted objects of other to db,
+// keeping the latest deletion time for each UUID
+func (m *merger) mergeDeletedObjects() {
+	if m.opts.Mode == MergeModeKeepExisting {
+		return
+	}
+
+	root := m.db.Content.Root
+	for _, deleted := range m.other.Content.Root.DeletedObjects {
+		i := slices.IndexFunc(root.DeletedObjects, func(d DeletedObjectData) bool {
+			return d.UUID == deleted.UUID
+		})
+		if i < 0 {
+			deleted.DeletionTime = cloneTimeWrapper(deleted.DeletionTime)
+			root.DeletedObjects = append(root.DeletedObjects, deleted)
+			continue
+		}
+		if mergeTime(deleted.DeletionTime).After(mergeTime(root.DeletedObjects[i].DeletionTime)) {
+			root.DeletedObjects[i].DeletionTime = cloneTimeWrapper(deleted.DeletionTime)
+		}
+	}
+}
+
+// isDeleted returns whether the object with the given uuid and modification time
+// has been deleted afterwards
+func (m *merger) isDeleted(uuid UUID, modified *w.TimeWrapper) bool {
+	for _, deleted := range m.db.Content.Root.DeletedObjects {
+		if deleted.UUID == uuid {
+			return !mergeTime(modified).After(mergeTime(deleted.DeletionTime))
+		}
+	}
+	return false
+}
+
+func (m *merger) mergeCustomIcons() {
+	meta := m.db.Content.Meta
+	for _, icon := range m.other.Content.Meta.CustomIcons {
+		icon.LastModificationTime = cloneTimeWrapper(icon.LastModificationTime)
+
+		i := slices.IndexFunc(meta.CustomIcons, func(ci CustomIcon) bool {
+			return ci.UUID == icon.UUID
+		})
+		if i < 0 {
+			meta.CustomIcons = append(meta.CustomIcons, icon)
+			m.report.add(MergeChangeAdded, MergeObjectCustomIcon, icon.UUID, icon.Name)
+			continue
+		}
+
+		existing := meta.CustomIcons[i]
+		if existing.Data == icon.Data && existing.Name == icon.Name {
+			continue
+		}
+		if m.replaces(icon.LastModificationTime, existing.LastModificationTime) {
+			meta.CustomIcons[i] = icon
+			m.report.add(MergeChangeUpdated, MergeObjectCustomIcon, icon.UUID, icon.Name)
+		}
+	}
+}
+
+// mergeChildren merges the entries and groups of the other group into
+// the group with the given parent uuid
+func (m *merger) mergeChildren(parent UUID, otherGroup *Group) error {
+	for i := range otherGroup.Entries {
+		if err := m.mergeEntry(parent, &otherGroup.Entries[i]); err != nil {
+			return err
+		}
+	}
+
+	for i := range otherGroup.Groups {
+		group := &otherGroup.Groups[i]
+		m.mergeGroup(parent, group)
+		if err := m.mergeChildren(group.UUID, group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *merger) mergeGroup(parent UUID, otherGroup *Group) {
+	if existing, _, _ := findGroup(m.db.Content.Root.Groups, otherGroup.UUID); existing != nil {
+		if m.replaces(otherGroup.Times.LastModificationTime, existing.Times.LastModificationTime) {
+			m.updateGroup(existing, otherGroup)
+			m.report.add(MergeChangeUpdated, MergeObjectGroup, existing.UUID, existing.Name)
+		}
+		return
+	}
+
+	if m.isDeleted(otherGroup.UUID, otherGroup.Times.LastModificationTime) {
+		return
+	}
+
+	group := *otherGroup
+	group.Times = otherGroup.Times.clone()
+	group.PreviousParentGroup = cloneUUID(otherGroup.PreviousParentGroup)
+	group.Entries = nil
+	group.Groups = nil
+
+	target := m.findTarget(parent)
+	target.Groups = append(target.Groups, group)
+	m.report.add(MergeChangeAdded, MergeObjectGroup, group.UUID, group.Name)
+}
+
+// updateGroup replaces the data of the existing group with the data of the other group,
+// keeping its location and children
+func (m *merger) updateGroup(existing *Group, otherGroup *Group) {
+	group := *otherGroup
+	group.Times = otherGroup.Times.clone()
+	group.Times.LocationChanged = existing.Times.LocationChanged
+	group.PreviousParentGroup = existing.PreviousParentGroup
+	group.Entries = existing.Entries
+	group.Groups = existing.Groups
+	group.kdbxFormatVersion = existing.kdbxFormatVersion
+	*existing = group
+}
+
+func (m *merger) mergeEntry(parent UUID, otherEntry *Entry) error {
+	existing, _, _ := findEntry(m.db.Content.Root.Groups, otherEntry.UUID)
+	if existing != nil && m.opts.Mode == MergeModeKeepExisting {
+		// Neither the entry nor its history are changed
+		return nil
+	}
+
+	entry, err := m.importEntry(*otherEntry)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		if m.isDeleted(entry.UUID, entry.Times.LastModificationTime) {
+			return nil
+		}
+
+		target := m.findTarget(parent)
+		target.Entries = append(target.Entries, entry)
+		m.report.add(MergeChangeAdded, MergeObjectEntry, entry.UUID, entry.GetTitle())
+		return nil
+	}
+
+	history := historyEntries(existing.Histories)
+	historyLength := len(history)
+	if !m.opts.DisableHistory {
+		history = addHistoryEntries(history, historyEntries(entry.Histories)...)
+	}
+
+	replaced := m.replaces(entry.Times.LastModificationTime, existing.Times.LastModificationTime)
+	sameVersion := mergeTime(entry.Times.LastModificationTime).Equal(
+		mergeTime(existing.Times.LastModificationTime),
+	)
+
+	if replaced {
+		if !m.opts.DisableHistory {
+			history = addHistoryEntries(history, withoutHistory(*existing))
+		}
+
+		entry.Times.LocationChanged = existing.Times.LocationChanged
+		entry.PreviousParentGroup = existing.PreviousParentGroup
+		entry.kdbxFormatVersion = existing.kdbxFormatVersion
+		*existing = entry
+		m.report.add(MergeChangeUpdated, MergeObjectEntry, existing.UUID, existing.GetTitle())
+	} else if !sameVersion && !m.opts.DisableHistory {
+		history = addHistoryEntries(history, withoutHistory(entry))
+	}
+
+	if replaced || len(history) != historyLength {
+		existing.Histories = nil
+		if len(history) > 0 {
+			existing.Histories = []History{{Entries: history}}
+		}
+	}
+	if !replaced && len(history) != historyLength {
+		m.report.add(MergeChangeHistoryUpdated, MergeObjectEntry, existing.UUID, existing.GetTitle())
+	}
+
+	return nil
+}
+
+// importEntry returns a copy of the entry of the other database
+// with its binary references pointing to binaries of db
+func (m *merger) importEntry(otherEntry Entry) (Entry, error) {
+	entry := copyEntry(otherEntry)
+
+	binaries, err := m.importBinaries(entry.Binaries)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Binaries = binaries
+
+	for i := range entry.Histories {
+		for j := range entry.Histories[i].Entries {
+			history := &entry.Histories[i].Entries[j]
+			binaries, err := m.importBinaries(history.Binaries)
+			if err != nil {
+				return Entry{}, err
+			}
+			history.Binaries = binaries
+		}
+	}
+
+	return entry, nil
+}
+
+// importBinaries adds the binaries referenced by refs to db
+// and returns the references to them.
+// References to binaries which do not exist in the other database are dropped.
+func (m *merger) importBinaries(refs []BinaryReference) ([]BinaryReference, error) {
+	if len(refs) == 0 {
+		return refs, nil
+	}
+
+	if m.contents == nil {
+		m.contents = map[int][]byte{}
+		for _, binary := range *m.db.getBinaries() {
+			content, err := binary.GetContentBytes()
+			if err != nil {
+				return nil, err
+			}
+			m.contents[binary.ID] = content
+		}
+	}
+
+	imported := make([]BinaryReference, 0, len(refs))
+	for _, ref := range refs {
+		id, ok := m.binaries[ref.Value.ID]
+		if !ok {
+			binary := m.other.FindBinary(ref.Value.ID)
+			if binary == nil {
+				continue
+			}
+
+			content, err := binary.GetContentBytes()
+			if err != nil {
+				return nil, err
+			}
+
+			id = m.addBinary(content, binary.MemoryProtection)
+			m.binaries[ref.Value.ID] = id
+		}
+
+		imported = append(imported, NewBinaryReference(ref.Name, id))
+	}
+	return imported, nil
+}
+
+// addBinary returns the ID of the binary of db with the given content, adding it if needed
+func (m *merger) addBinary(content []byte, memoryProtection byte) int {
+	for id, existing := range m.contents {
+		if bytes.Equal(existing, content) {
+			return id
+		}
+	}
+
+	binary := m.db.AddBinary(content)
+	binary.MemoryProtection = memoryProtection
+	m.contents[binary.ID] = content
+	return binary.ID
+}
+
+// findTarget returns the group with the given uuid, or the root group if it does not exist
+func (m *merger) findTarget(uuid UUID) *Group {
+	if group, _, _ := findGroup(m.db.Content.Root.Groups, uuid); group != nil {
+		return group
+	}
+	return &m.db.Content.Root.Groups[0]
+}
+
+// relocateChildren moves the entries and groups of db to the parent they have
+// in the other database, if they have been moved there more recently
+func (m *merger) relocateChildren(parent UUID, otherGroup *Group) {
+	for _, otherEntry := range otherGroup.Entries {
+		entry, current, i := findEntry(m.db.Content.Root.Groups, otherEntry.UUID)
+		if entry == nil || current.UUID == parent ||
+			!m.replaces(otherEntry.Times.LocationChanged, entry.Times.LocationChanged) {
+			continue
+		}
+		if target, _, _ := findGroup(m.db.Content.Root.Groups, parent); target == nil {
+			continue
+		}
+
+		moved := *entry
+		moved.Times.LocationChanged = cloneTimeWrapper(otherEntry.Times.LocationChanged)
+		moved.PreviousParentGroup = cloneUUID(otherEntry.PreviousParentGroup)
+		current.Entries = slices.Delete(current.Entries, i, i+1)
+
+		target, _, _ := findGroup(m.db.Content.Root.Groups, parent)
+		target.Entries = append(target.Entries, moved)
+		m.report.add(MergeChangeMoved, MergeObjectEntry, moved.UUID, moved.GetTitle())
+	}
+
+	for i := range otherGroup.Groups {
+		otherChild := &otherGroup.Groups[i]
+		m.relocateGroup(parent, otherChild)
+		m.relocateChildren(otherChild.UUID, otherChild)
+	}
+}
+
+func (m *merger) relocateGroup(parent UUID, otherGroup *Group) {
+	group, current, i := findGroup(m.db.Content.Root.Groups, otherGroup.UUID)
+	if group == nil || current == nil || current.UUID == parent ||
+		!m.replaces(otherGroup.Times.LocationChanged, group.Times.LocationChanged) {
+		return
+	}
+
+	// Moving a group into itself or one of its sub groups is not possible
+	if group.UUID == parent {
+		return
+	}
+	if target, _, _ := findGroup(group.Groups, parent); target != nil {
+		return
+	}
+	if target, _, _ := findGroup(m.db.Content.Root.Groups, parent); target == nil {
+		return
+	}
+
+	moved := *group
+	moved.Times.LocationChanged = cloneTimeWrapper(otherGroup.Times.LocationChanged)
+	moved.PreviousParentGroup = cloneUUID(otherGroup.PreviousParentGroup)
+	current.Groups = slices.Delete(current.Groups, i, i+1)
+
+	target, _, _ := findGroup(m.db.Content.Root.Groups, parent)
+	target.Groups = append(target.Groups, moved)
+	m.report.add(MergeChangeMoved, MergeObjectGroup, moved.UUID, moved.Name)
+}
+
+// applyDeletions removes the entries and groups below g which have been deleted
+// after their last modification.
+// Groups are only removed if they do not contain any entries or groups anymore.
+func (m *merger) applyDeletions(g *Group) {
+	for i := 0; i < len(g.Groups); {
+		group := &g.Groups[i]
+		m.applyDeletions(group)
+
+		if len(group.Entries) == 0 && len(group.Groups) == 0 &&
+			m.isDeleted(group.UUID, group.Times.LastModificationTime) {
+			m.report.add(MergeChangeDeleted, MergeObjectGroup, group.UUID, group.Name)
+			g.Groups = slices.Delete(g.Groups, i, i+1)
+			continue
+		}
+		i++
+	}
+
+	for i := 0; i < len(g.Entries); {
+		entry := &g.Entries[i]
+		if m.isDeleted(entry.UUID, entry.Times.LastModificationTime) {
+			m.report.add(MergeChangeDeleted, MergeObjectEntry, entry.UUID, entry.GetTitle())
+			g.Entries = slices.Delete(g.Entries, i, i+1)
+			continue
+		}
+		i++
+	}
+}
+
+// findGroup returns the group with the given uuid within groups,
+// its parent group (nil for top level groups) and its index within the parent
+func findGroup(groups []Group, uuid UUID) (*Group, *Group, int) {
+	for i := range groups {
+		if groups[i].UUID.Compare(uuid) {
+			return &groups[i], nil, i
+		}
+		if group, parent, index := findGroup(groups[i].Groups, uuid); group != nil {
+			if parent == nil {
+				parent = &groups[i]
+			}
+			return group, parent, index
+		}
+	}
+	return nil, nil, -1
+}
+
+// findEntry returns the entry with the given uuid within groups,
+// its parent group and its index within the parent
+func findEntry(groups []Group, uuid UUID) (*Entry, *Group, int) {
+	for i := range groups {
+		for j := range groups[i].Entries {
+			if groups[i].Entries[j].UUID.Compare(uuid) {
+				return &groups[i].Entries[j], &groups[i], j
+			}
+		}
+		if entry, parent, index := findEntry(groups[i].Groups, uuid); entry != nil {
+			return entry, parent, index
+		}
+	}
+	return nil, nil, -1
+}
+
+// copyEntry returns a deep copy of e, keeping the UUIDs
+func copyEntry(e Entry) Entry {
+	entry := e.Clone()
+	entry.UUID = e.UUID
+	entry.Times = e.Times.clone()
+	entry.AutoType.Associations = slices.Clone(e.AutoType.Associations)
+	for i, history := range e.Histories {
+		for j, historyEntry := range history.Entries {
+			entry.Histories[i].Entries[j] = copyEntry(historyEntry)
+		}
+	}
+	return entry
+}
+
+func cloneUUID(uuid *UUID) *UUID {
+	if uuid == nil {
+		return nil
+	}
+	clone := *uuid
+	return &clone
+}
@@ -0,0 +1,340 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+var (
+	mergeRootUUID  = UUID{0x01}
+	mergeGroupUUID = UUID{0x02}
+	mergeOtherUUID = UUID{0x03}
+	mergeEntryUUID = UUID{0x04}
+	mergeNewUUID   = UUID{0x05}
+	mergeBaseTime  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func mergeTimeData(offset time.Duration) TimeData {
+	return NewTimeData(func(td *TimeData) {
+		t := w.TimeWrapper{Time: mergeBaseTime.Add(offset)}
+		location := t
+		td.LastModificationTime = &t
+		td.LocationChanged = &location
+	})
+}
+
+func newMergeEntry(uuid UUID, title string, offset time.Duration) Entry {
+	entry := NewEntry()
+	entry.UUID = uuid
+	entry.Times = mergeTimeData(offset)
+	entry.Values = []ValueData{{Key: "Title", Value: V{Content: title}}}
+	return entry
+}
+
+func newMergeGroup(uuid UUID, name string) Group {
+	group := NewGroup()
+	group.UUID = uuid
+	group.Name = name
+	group.Times = mergeTimeData(0)
+	return group
+}
+
+// newMergeDatabase creates a database with the same structure on every call
+func newMergeDatabase() *Database {
+	db := NewDatabase(WithDatabaseKDBXVersion4())
+
+	root := newMergeGroup(mergeRootUUID, "root")
+	group := newMergeGroup(mergeGroupUUID, "group")
+	group.Entries = []Entry{newMergeEntry(mergeEntryUUID, "entry", 0)}
+	root.Groups = []Group{group, newMergeGroup(mergeOtherUUID, "other")}
+	db.Content.Root.Groups = []Group{root}
+
+	return db
+}
+
+func TestDatabase_Merge(t *testing.T) {
+	cases := []struct {
+		title           string
+		opts            MergeOptions
+		prepare         func(db, other *Database)
+		expectedChanges []MergeChange
+		check           func(t *testing.T, db *Database)
+	}{
+		{
+			title:   "without changes",
+			prepare: func(db, other *Database) {},
+		},
+		{
+			title: "with a new entry",
+			prepare: func(db, other *Database) {
+				group := &other.Content.Root.Groups[0].Groups[1]
+				group.Entries = append(group.Entries, newMergeEntry(mergeNewUUID, "new", 0))
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeAdded, Object: MergeObjectEntry, UUID: mergeNewUUID, Name: "new"},
+			},
+			check: func(t *testing.T, db *Database) {
+				entries := db.Content.Root.Groups[0].Groups[1].Entries
+				if len(entries) != 1 || entries[0].UUID != mergeNewUUID {
+					t.Errorf("Expected new entry in group, received %+v", entries)
+				}
+			},
+		},
+		{
+			title: "with a new group",
+			prepare: func(db, other *Database) {
+				group := newMergeGroup(mergeNewUUID, "new")
+				group.Entries = []Entry{newMergeEntry(UUID{0x06}, "nested", 0)}
+				other.Content.Root.Groups[0].Groups = append(other.Content.Root.Groups[0].Groups, group)
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeAdded, Object: MergeObjectGroup, UUID: mergeNewUUID, Name: "new"},
+				{Type: MergeChangeAdded, Object: MergeObjectEntry, UUID: UUID{0x06}, Name: "nested"},
+			},
+			check: func(t *testing.T, db *Database) {
+				groups := db.Content.Root.Groups[0].Groups
+				if len(groups) != 3 || len(groups[2].Entries) != 1 {
+					t.Errorf("Expected new group with entry, received %+v", groups)
+				}
+			},
+		},
+		{
+			title: "with a newer entry in other",
+			prepare: func(db, other *Database) {
+				other.Content.Root.Groups[0].Groups[0].Entries[0] = newMergeEntry(mergeEntryUUID, "changed", time.Hour)
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeUpdated, Object: MergeObjectEntry, UUID: mergeEntryUUID, Name: "changed"},
+			},
+			check: func(t *testing.T, db *Database) {
+				entry := db.Content.Root.Groups[0].Groups[0].Entries[0]
+				if entry.GetTitle() != "changed" {
+					t.Errorf("Expected the newer entry, received %s", entry.GetTitle())
+				}
+				if len(entry.Histories) != 1 || len(entry.Histories[0].Entries) != 1 ||
+					entry.Histories[0].Entries[0].GetTitle() != "entry" {
+					t.Errorf("Expected the replaced entry in the history, received %+v", entry.Histories)
+				}
+			},
+		},
+		{
+			title: "with a newer entry in db",
+			prepare: func(db, other *Database) {
+				db.Content.Root.Groups[0].Groups[0].Entries[0] = newMergeEntry(mergeEntryUUID, "changed", time.Hour)
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeHistoryUpdated, Object: MergeObjectEntry, UUID: mergeEntryUUID, Name: "changed"},
+			},
+			check: func(t *testing.T, db *Database) {
+				entry := db.Content.Root.Groups[0].Groups[0].Entries[0]
+				if entry.GetTitle() != "changed" {
+					t.Errorf("Expected the newer entry, received %s", entry.GetTitle())
+				}
+				if len(entry.Histories) != 1 || entry.Histories[0].Entries[0].GetTitle() != "entry" {
+					t.Errorf("Expected the older entry in the history, received %+v", entry.Histories)
+				}
+			},
+		},
+		{
+			title: "with a newer entry in other and keep existing mode",
+			opts:  MergeOptions{Mode: MergeModeKeepExisting, DisableHistory: true},
+			prepare: func(db, other *Database) {
+				other.Content.Root.Groups[0].Groups[0].Entries[0] = newMergeEntry(mergeEntryUUID, "changed", time.Hour)
+			},
+			check: func(t *testing.T, db *Database) {
+				entry := db.Content.Root.Groups[0].Groups[0].Entries[0]
+				if entry.GetTitle() != "entry" || len(entry.Histories) != 0 {
+					t.Errorf("Expected the existing entry, received %+v", entry)
+				}
+			},
+		},
+		{
+			title: "with a newer entry in other and keep existing mode with history",
+			opts:  MergeOptions{Mode: MergeModeKeepExisting},
+			prepare: func(db, other *Database) {
+				other.Content.Root.Groups[0].Groups[0].Entries[0] = newMergeEntry(mergeEntryUUID, "changed", time.Hour)
+				other.Content.Root.Groups[0].Groups[0].Entries[0].Histories = []History{
+					{Entries: []Entry{newMergeEntry(mergeEntryUUID, "other history", -time.Hour)}},
+				}
+			},
+			check: func(t *testing.T, db *Database) {
+				entry := db.Content.Root.Groups[0].Groups[0].Entries[0]
+				if entry.GetTitle() != "entry" || len(entry.Histories) != 0 {
+					t.Errorf("Expected the existing entry without history, received %+v", entry)
+				}
+			},
+		},
+		{
+			title: "with an older entry in other and overwrite existing mode",
+			opts:  MergeOptions{Mode: MergeModeOverwriteExisting},
+			prepare: func(db, other *Database) {
+				other.Content.Root.Groups[0].Groups[0].Entries[0] = newMergeEntry(mergeEntryUUID, "older", -time.Hour)
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeUpdated, Object: MergeObjectEntry, UUID: mergeEntryUUID, Name: "older"},
+			},
+		},
+		{
+			title: "with an entry deleted in other",
+			prepare: func(db, other *Database) {
+				other.Content.Root.Groups[0].Groups[0].Entries = nil
+				deletionTime := w.TimeWrapper{Time: mergeBaseTime.Add(time.Hour)}
+				other.Content.Root.DeletedObjects = []DeletedObjectData{
+					{UUID: mergeEntryUUID, DeletionTime: &deletionTime},
+				}
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeDeleted, Object: MergeObjectEntry, UUID: mergeEntryUUID, Name: "entry"},
+			},
+			check: func(t *testing.T, db *Database) {
+				if len(db.Content.Root.Groups[0].Groups[0].Entries) != 0 {
+					t.Errorf("Expected the entry to be deleted")
+				}
+				if len(db.Content.Root.DeletedObjects) != 1 {
+					t.Errorf("Expected the deleted object to be merged")
+				}
+			},
+		},
+		{
+			title: "with an entry modified after deletion",
+			prepare: func(db, other *Database) {
+				db.Content.Root.Groups[0].Groups[0].Entries[0] = newMergeEntry(mergeEntryUUID, "changed", 2*time.Hour)
+				other.Content.Root.Groups[0].Groups[0].Entries = nil
+				deletionTime := w.TimeWrapper{Time: mergeBaseTime.Add(time.Hour)}
+				other.Content.Root.DeletedObjects = []DeletedObjectData{
+					{UUID: mergeEntryUUID, DeletionTime: &deletionTime},
+				}
+			},
+			check: func(t *testing.T, db *Database) {
+				if len(db.Content.Root.Groups[0].Groups[0].Entries) != 1 {
+					t.Errorf("Expected the entry to be kept")
+				}
+			},
+		},
+		{
+			title: "with an entry moved in other",
+			prepare: func(db, other *Database) {
+				root := &other.Content.Root.Groups[0]
+				entry := root.Groups[0].Entries[0]
+				entry.Times.LocationChanged = &w.TimeWrapper{Time: mergeBaseTime.Add(time.Hour)}
+				root.Groups[0].Entries = nil
+				root.Groups[1].Entries = []Entry{entry}
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeMoved, Object: MergeObjectEntry, UUID: mergeEntryUUID, Name: "entry"},
+			},
+			check: func(t *testing.T, db *Database) {
+				root := db.Content.Root.Groups[0]
+				if len(root.Groups[0].Entries) != 0 || len(root.Groups[1].Entries) != 1 {
+					t.Errorf("Expected the entry to be moved, received %+v", root.Groups)
+				}
+			},
+		},
+		{
+			title: "with a group moved in other",
+			prepare: func(db, other *Database) {
+				root := &other.Content.Root.Groups[0]
+				group := root.Groups[1]
+				group.Times.LocationChanged = &w.TimeWrapper{Time: mergeBaseTime.Add(time.Hour)}
+				root.Groups = root.Groups[:1]
+				root.Groups[0].Groups = []Group{group}
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeMoved, Object: MergeObjectGroup, UUID: mergeOtherUUID, Name: "other"},
+			},
+			check: func(t *testing.T, db *Database) {
+				root := db.Content.Root.Groups[0]
+				if len(root.Groups) != 1 || len(root.Groups[0].Groups) != 1 {
+					t.Errorf("Expected the group to be moved, received %+v", root.Groups)
+				}
+			},
+		},
+		{
+			title: "with binaries and custom icons",
+			prepare: func(db, other *Database) {
+				db.AddBinary([]byte("existing content"))
+				other.AddBinary([]byte("new content"))
+				existing := other.AddBinary([]byte("existing content"))
+
+				entry := newMergeEntry(mergeNewUUID, "new", 0)
+				entry.CustomIconUUID = UUID{0x07}
+				entry.Binaries = []BinaryReference{
+					NewBinaryReference("new.txt", 0),
+					NewBinaryReference("existing.txt", existing.ID),
+				}
+				other.Content.Root.Groups[0].Entries = []Entry{entry}
+				other.Content.Meta.CustomIcons = []CustomIcon{{UUID: UUID{0x07}, Data: encodedIcon, Name: "icon"}}
+			},
+			expectedChanges: []MergeChange{
+				{Type: MergeChangeAdded, Object: MergeObjectCustomIcon, UUID: UUID{0x07}, Name: "icon"},
+				{Type: MergeChangeAdded, Object: MergeObjectEntry, UUID: mergeNewUUID, Name: "new"},
+			},
+			check: func(t *testing.T, db *Database) {
+				if len(db.Content.Meta.CustomIcons) != 1 {
+					t.Errorf("Expected the custom icon to be added")
+				}
+
+				entry := db.Content.Root.Groups[0].Entries[0]
+				expected := map[string]string{"new.txt": "new content", "existing.txt": "existing content"}
+				for _, ref := range entry.Binaries {
+					content, err := db.FindBinary(ref.Value.ID).GetContentString()
+					if err != nil {
+						t.Fatalf("Received unexpected error %v", err)
+					}
+					if content != expected[ref.Name] {
+						t.Errorf("Expected %s for %s, received %s", expected[ref.Name], ref.Name, content)
+					}
+				}
+				if len(*db.getBinaries()) != 2 {
+					t.Errorf("Expected 2 binaries, received %d", len(*db.getBinaries()))
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := newMergeDatabase()
+			other := newMergeDatabase()
+			c.prepare(db, other)
+
+			report, err := db.Merge(other, c.opts)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			if len(report.Changes) != len(c.expectedChanges) {
+				t.Fatalf("Expected changes %v, received %v", c.expectedChanges, report.Changes)
+			}
+			for i, change := range report.Changes {
+				if change != c.expectedChanges[i] {
+					t.Errorf("Expected change %v, received %v", c.expectedChanges[i], change)
+				}
+			}
+
+			if c.check != nil {
+				c.check(t, db)
+			}
+
+			// The merged database must still be encodable
+			db.Credentials = NewPasswordCredentials(password)
+			if err := NewEncoder(new(bytes.Buffer)).Encode(db); err != nil {
+				t.Errorf("Received unexpected error encoding %v", err)
+			}
+		})
+	}
+}
+
+func TestDatabase_MergeMissingContent(t *testing.T) {
+	db := newMergeDatabase()
+	other := newMergeDatabase()
+	other.Content = nil
+
+	_, err := db.Merge(other, MergeOptions{})
+	if err != ErrRequiredAttributeMissing("Content") {
+		t.Errorf("Expected missing content error, received %v", err)
+	}
+}
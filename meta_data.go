@@ -1,6 +1,8 @@
 package gokeepasslib
 
 import (
+	"encoding/xml"
+
 	w "github.com/tobischo/gokeepasslib/v3/wrappers"
 )
 
@@ -18,8 +20,32 @@ type MetaDataOption func(*MetaData)
 // CustomIcon is the structure needed to store custom icons.
 // Unsure of what version/format requires this
 type CustomIcon struct {
-	UUID UUID   `xml:"UUID"` // Entry's CustomIcon UUID should match this
-	Data string `xml:"Data"` // base64 encoded PNG icon.  Unknown size constraints
+	UUID                 UUID           `xml:"UUID"`                           // Entry's CustomIcon UUID should match this
+	Data                 string         `xml:"Data"`                           // base64 encoded PNG icon.  Unknown size constraints
+	Name                 string         `xml:"Name,omitempty"`                 // KDBX 4.1
+	LastModificationTime *w.TimeWrapper `xml:"LastModificationTime,omitempty"` // KDBX 4.1
+	kdbxFormatVersion    formatVersion  `xml:"-"`
+}
+
+func (ci *CustomIcon) setKdbxFormatVersion(version formatVersion) {
+	ci.kdbxFormatVersion = version
+
+	if ci.LastModificationTime != nil {
+		ci.LastModificationTime.Formatted = !isKdbx4(version)
+	}
+}
+
+// MarshalXML marshals the custom icon into e, leaving out KDBX 4.1 elements for older versions
+func (ci *CustomIcon) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type customIcon CustomIcon
+
+	out := customIcon(*ci)
+	if omitKdbx41Elements(ci.kdbxFormatVersion) {
+		out.Name = ""
+		out.LastModificationTime = nil
+	}
+
+	return e.EncodeElement(&out, start)
 }
 
 func WithMetaDataFormattedTime(formatted bool) MetaDataOption {
@@ -79,6 +105,27 @@ type MetaData struct {
 	LastTopVisibleGroup        string         `xml:"LastTopVisibleGroup"`
 	Binaries                   Binaries       `xml:"Binaries>Binary,omitempty"`
 	CustomData                 []CustomData   `xml:"CustomData>Item"`
+	unknownXML                 unknownXMLData `xml:"-"`
+}
+
+// MarshalXML marshals the meta data into e, including elements unknown to the library
+func (md *MetaData) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type metaData MetaData
+
+	return marshalXMLWithUnknown(e, start, (*metaData)(md), md.unknownXML)
+}
+
+// UnmarshalXML unmarshals the meta data from d, keeping elements unknown to the library
+func (md *MetaData) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type metaData MetaData
+
+	unknownXML, err := unmarshalXMLWithUnknown(d, start, (*metaData)(md))
+	if err != nil {
+		return err
+	}
+
+	md.unknownXML = unknownXML
+	return nil
 }
 
 func (md *MetaData) setKdbxFormatVersion(version formatVersion) {
@@ -103,4 +150,12 @@ func (md *MetaData) setKdbxFormatVersion(version formatVersion) {
 	if md.EntryTemplatesGroupChanged != nil {
 		md.EntryTemplatesGroupChanged.Formatted = !isKdbx4(version)
 	}
+
+	for i := range md.CustomIcons {
+		(&md.CustomIcons[i]).setKdbxFormatVersion(version)
+	}
+
+	for i := range md.CustomData {
+		(&md.CustomData[i]).setKdbxFormatVersion(version)
+	}
 }
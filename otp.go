@@ -0,0 +1,456 @@
+package gokeepasslib
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// OTPType is the type of one-time password of an entry
+type OTPType int
+
+const (
+	OTPTypeTOTP OTPType = iota // Time based one-time password (RFC 6238)
+	OTPTypeHOTP                // HMAC based one-time password (RFC 4226)
+)
+
+// OTPAlgorithm is the hash algorithm used for generating one-time passwords
+type OTPAlgorithm string
+
+// OTP hash algorithms as named in otpauth URIs
+const (
+	OTPAlgorithmSHA1   OTPAlgorithm = "SHA1"
+	OTPAlgorithmSHA256 OTPAlgorithm = "SHA256"
+	OTPAlgorithmSHA512 OTPAlgorithm = "SHA512"
+)
+
+// OTPFormat is the format the OTP settings are stored in the strings of an entry
+type OTPFormat int
+
+const (
+	// OTPFormatKeePass stores the settings in the `TimeOtp-*` and `HmacOtp-*` strings of KeePass 2.47+
+	OTPFormatKeePass OTPFormat = iota
+	// OTPFormatKeePassXC stores the settings as `otpauth://` URI in the `otp` string of KeePassXC
+	OTPFormatKeePassXC
+)
+
+const (
+	// DefaultOTPDigits is the number of digits of a code if not configured otherwise
+	DefaultOTPDigits = 6
+	// DefaultOTPPeriod is the validity of a TOTP code if not configured otherwise
+	DefaultOTPPeriod = 30 * time.Second
+)
+
+// Errors returned for the OTP settings of entries
+var (
+	ErrNoOTPSettings      = errors.New("gokeepasslib: entry has no OTP settings")
+	ErrInvalidOTPSettings = errors.New("gokeepasslib: invalid OTP settings")
+)
+
+const (
+	keePassTOTPPrefix = "TimeOtp-"
+	keePassHOTPPrefix = "HmacOtp-"
+	keePassXCOTPKey   = "otp"
+)
+
+var keePassOTPAlgorithms = map[string]OTPAlgorithm{
+	"HMAC-SHA-1":   OTPAlgorithmSHA1,
+	"HMAC-SHA-256": OTPAlgorithmSHA256,
+	"HMAC-SHA-512": OTPAlgorithmSHA512,
+}
+
+var otpBase32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// OTPSettings stores the settings for generating one-time passwords
+type OTPSettings struct {
+	Type      OTPType
+	Secret    []byte
+	Algorithm OTPAlgorithm  // OTPAlgorithmSHA1 if empty
+	Digits    int           // DefaultOTPDigits if 0
+	Period    time.Duration // DefaultOTPPeriod if 0, TOTP only
+	Counter   uint64        // Counter of the next code, HOTP only
+	Issuer    string        // Issuer of the otpauth URI
+	Account   string        // Account name of the otpauth URI
+}
+
+// GetOTPSettings parses the OTP settings of the entry from the KeePass `TimeOtp-*`
+// or `HmacOtp-*` strings or the KeePassXC `otp` string, in this order.
+// ErrNoOTPSettings is returned if the entry does not have any.
+func (e *Entry) GetOTPSettings() (*OTPSettings, error) {
+	if e.hasKeePassOTPSecret(keePassTOTPPrefix) {
+		return e.parseKeePassOTP(OTPTypeTOTP, keePassTOTPPrefix)
+	}
+	if e.hasKeePassOTPSecret(keePassHOTPPrefix) {
+		return e.parseKeePassOTP(OTPTypeHOTP, keePassHOTPPrefix)
+	}
+	if uri := e.GetContent(keePassXCOTPKey); uri != "" {
+		return ParseOTPURI(uri)
+	}
+	return nil, ErrNoOTPSettings
+}
+
+// SetOTPSettings stores the OTP settings in the strings of the entry in the given format,
+// replacing the settings stored in this format before.
+// The settings stored in other formats are kept, so the settings can be stored for several formats.
+func (e *Entry) SetOTPSettings(settings OTPSettings, format OTPFormat) error {
+	settings = settings.withDefaults()
+	if err := settings.validate(); err != nil {
+		return err
+	}
+
+	switch format {
+	case OTPFormatKeePass:
+		e.removeValues(func(key string) bool {
+			return strings.HasPrefix(key, keePassTOTPPrefix) || strings.HasPrefix(key, keePassHOTPPrefix)
+		})
+		e.setKeePassOTP(settings)
+	case OTPFormatKeePassXC:
+		e.setValue(keePassXCOTPKey, settings.URI(), true)
+	default:
+		return fmt.Errorf("%w: unknown format %d", ErrInvalidOTPSettings, format)
+	}
+	return nil
+}
+
+// GenerateTOTP returns the TOTP code of the entry valid at the given time
+func (e *Entry) GenerateTOTP(at time.Time) (string, error) {
+	settings, err := e.GetOTPSettings()
+	if err != nil {
+		return "", err
+	}
+	if settings.Type != OTPTypeTOTP {
+		return "", fmt.Errorf("%w: entry has HOTP settings", ErrInvalidOTPSettings)
+	}
+	return settings.TOTP(at)
+}
+
+// GenerateHOTP returns the next HOTP code of the entry and advances the counter
+// stored in the strings of the entry
+func (e *Entry) GenerateHOTP() (string, error) {
+	settings, err := e.GetOTPSettings()
+	if err != nil {
+		return "", err
+	}
+	if settings.Type != OTPTypeHOTP {
+		return "", fmt.Errorf("%w: entry has TOTP settings", ErrInvalidOTPSettings)
+	}
+
+	code, err := settings.HOTP(settings.Counter)
+	if err != nil {
+		return "", err
+	}
+
+	settings.Counter++
+	if e.hasKeePassOTPSecret(keePassHOTPPrefix) {
+		e.setValue(keePassHOTPPrefix+"Counter", strconv.FormatUint(settings.Counter, 10), false)
+	}
+	if uri := e.GetContent(keePassXCOTPKey); uri != "" {
+		if xcSettings, err := ParseOTPURI(uri); err == nil && xcSettings.Type == OTPTypeHOTP {
+			xcSettings.Counter = settings.Counter
+			e.setValue(keePassXCOTPKey, xcSettings.URI(), true)
+		}
+	}
+	return code, nil
+}
+
+// TOTP returns the TOTP code valid at the given time
+func (s OTPSettings) TOTP(at time.Time) (string, error) {
+	s = s.withDefaults()
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	return s.HOTP(uint64(at.Unix()) / uint64(s.Period/time.Second))
+}
+
+// HOTP returns the code for the given counter value
+func (s OTPSettings) HOTP(counter uint64) (string, error) {
+	s = s.withDefaults()
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(s.Algorithm.hash(), s.Secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := uint64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+
+	modulo := uint64(1)
+	for i := 0; i < s.Digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", s.Digits, value%modulo), nil
+}
+
+// URI returns the settings as `otpauth://` URI as used by KeePassXC and authenticator apps
+func (s OTPSettings) URI() string {
+	s = s.withDefaults()
+
+	query := url.Values{}
+	query.Set("secret", otpBase32Encoding.EncodeToString(s.Secret))
+	query.Set("algorithm", string(s.Algorithm))
+	query.Set("digits", strconv.Itoa(s.Digits))
+	if s.Issuer != "" {
+		query.Set("issuer", s.Issuer)
+	}
+
+	u := url.URL{Scheme: "otpauth", Host: "totp"}
+	if s.Type == OTPTypeHOTP {
+		u.Host = "hotp"
+		query.Set("counter", strconv.FormatUint(s.Counter, 10))
+	} else {
+		query.Set("period", strconv.Itoa(int(s.Period/time.Second)))
+	}
+
+	label := s.Account
+	if s.Issuer != "" {
+		label = s.Issuer + ":" + s.Account
+	}
+	u.Path = "/" + label
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// ParseOTPURI parses an `otpauth://` URI as used by KeePassXC and authenticator apps
+func ParseOTPURI(uri string) (*OTPSettings, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOTPSettings, err)
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("%w: unsupported scheme %s", ErrInvalidOTPSettings, u.Scheme)
+	}
+
+	settings := &OTPSettings{}
+	switch strings.ToLower(u.Host) {
+	case "totp":
+		settings.Type = OTPTypeTOTP
+	case "hotp":
+		settings.Type = OTPTypeHOTP
+	default:
+		return nil, fmt.Errorf("%w: unsupported type %s", ErrInvalidOTPSettings, u.Host)
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		settings.Issuer, settings.Account = issuer, strings.TrimSpace(account)
+	} else {
+		settings.Account = label
+	}
+
+	query := u.Query()
+	if encoder := query.Get("encoder"); encoder != "" {
+		return nil, fmt.Errorf("%w: unsupported encoder %s", ErrInvalidOTPSettings, encoder)
+	}
+	if settings.Secret, err = decodeOTPBase32(query.Get("secret")); err != nil {
+		return nil, err
+	}
+	if issuer := query.Get("issuer"); issuer != "" {
+		settings.Issuer = issuer
+	}
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		settings.Algorithm = OTPAlgorithm(strings.ToUpper(algorithm))
+	}
+	if settings.Digits, err = parseOTPInt(query.Get("digits")); err != nil {
+		return nil, err
+	}
+	period, err := parseOTPInt(query.Get("period"))
+	if err != nil {
+		return nil, err
+	}
+	settings.Period = time.Duration(period) * time.Second
+	if counter := query.Get("counter"); counter != "" {
+		if settings.Counter, err = strconv.ParseUint(counter, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidOTPSettings, err)
+		}
+	}
+
+	*settings = settings.withDefaults()
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s OTPSettings) withDefaults() OTPSettings {
+	if s.Algorithm == "" {
+		s.Algorithm = OTPAlgorithmSHA1
+	}
+	if s.Digits == 0 {
+		s.Digits = DefaultOTPDigits
+	}
+	if s.Period == 0 {
+		s.Period = DefaultOTPPeriod
+	}
+	return s
+}
+
+func (s OTPSettings) validate() error {
+	switch {
+	case len(s.Secret) == 0:
+		return fmt.Errorf("%w: missing secret", ErrInvalidOTPSettings)
+	case s.Algorithm.hash() == nil:
+		return fmt.Errorf("%w: unsupported algorithm %s", ErrInvalidOTPSettings, s.Algorithm)
+	case s.Digits < 1 || s.Digits > 10:
+		return fmt.Errorf("%w: unsupported number of digits %d", ErrInvalidOTPSettings, s.Digits)
+	case s.Type == OTPTypeTOTP && s.Period < time.Second:
+		return fmt.Errorf("%w: unsupported period %v", ErrInvalidOTPSettings, s.Period)
+	}
+	return nil
+}
+
+func (a OTPAlgorithm) hash() func() hash.Hash {
+	switch a {
+	case OTPAlgorithmSHA1:
+		return sha1.New
+	case OTPAlgorithmSHA256:
+		return sha256.New
+	case OTPAlgorithmSHA512:
+		return sha512.New
+	}
+	return nil
+}
+
+// hasKeePassOTPSecret returns whether the entry has a secret string with the given KeePass prefix
+func (e *Entry) hasKeePassOTPSecret(prefix string) bool {
+	for _, suffix := range []string{"Secret", "Secret-Hex", "Secret-Base32", "Secret-Base64"} {
+		if e.Get(prefix+suffix) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Entry) parseKeePassOTP(otpType OTPType, prefix string) (*OTPSettings, error) {
+	settings := &OTPSettings{Type: otpType}
+
+	var err error
+	switch {
+	case e.Get(prefix+"Secret") != nil:
+		settings.Secret = []byte(e.GetContent(prefix + "Secret"))
+	case e.Get(prefix+"Secret-Hex") != nil:
+		settings.Secret, err = hex.DecodeString(strings.ReplaceAll(e.GetContent(prefix+"Secret-Hex"), " ", ""))
+	case e.Get(prefix+"Secret-Base32") != nil:
+		settings.Secret, err = decodeOTPBase32(e.GetContent(prefix + "Secret-Base32"))
+	default:
+		settings.Secret, err = base64.StdEncoding.DecodeString(e.GetContent(prefix + "Secret-Base64"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOTPSettings, err)
+	}
+
+	if otpType == OTPTypeHOTP {
+		if counter := e.GetContent(prefix + "Counter"); counter != "" {
+			if settings.Counter, err = strconv.ParseUint(counter, 10, 64); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidOTPSettings, err)
+			}
+		}
+	} else {
+		if settings.Digits, err = parseOTPInt(e.GetContent(prefix + "Length")); err != nil {
+			return nil, err
+		}
+		var period int
+		if period, err = parseOTPInt(e.GetContent(prefix + "Period")); err != nil {
+			return nil, err
+		}
+		settings.Period = time.Duration(period) * time.Second
+		if algorithm := e.GetContent(prefix + "Algorithm"); algorithm != "" {
+			var ok bool
+			if settings.Algorithm, ok = keePassOTPAlgorithms[algorithm]; !ok {
+				return nil, fmt.Errorf("%w: unsupported algorithm %s", ErrInvalidOTPSettings, algorithm)
+			}
+		}
+	}
+
+	*settings = settings.withDefaults()
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// setKeePassOTP stores the settings like KeePass, omitting default values
+func (e *Entry) setKeePassOTP(settings OTPSettings) {
+	secret := otpBase32Encoding.EncodeToString(settings.Secret)
+	if settings.Type == OTPTypeHOTP {
+		e.setValue(keePassHOTPPrefix+"Secret-Base32", secret, true)
+		e.setValue(keePassHOTPPrefix+"Counter", strconv.FormatUint(settings.Counter, 10), false)
+		return
+	}
+
+	e.setValue(keePassTOTPPrefix+"Secret-Base32", secret, true)
+	if settings.Digits != DefaultOTPDigits {
+		e.setValue(keePassTOTPPrefix+"Length", strconv.Itoa(settings.Digits), false)
+	}
+	if settings.Period != DefaultOTPPeriod {
+		e.setValue(keePassTOTPPrefix+"Period", strconv.Itoa(int(settings.Period/time.Second)), false)
+	}
+	if settings.Algorithm != OTPAlgorithmSHA1 {
+		for name, algorithm := range keePassOTPAlgorithms {
+			if algorithm == settings.Algorithm {
+				e.setValue(keePassTOTPPrefix+"Algorithm", name, false)
+			}
+		}
+	}
+}
+
+// setValue sets the content of the value with the given key, adding the value if it does not exist
+func (e *Entry) setValue(key string, content string, protected bool) {
+	if value := e.Get(key); value != nil {
+		value.Value.Content = content
+		value.Value.Protected = w.NewBoolWrapper(protected)
+		return
+	}
+	e.Values = append(e.Values, ValueData{Key: key, Value: V{Content: content, Protected: w.NewBoolWrapper(protected)}})
+}
+
+// removeValues removes the values whose keys match
+func (e *Entry) removeValues(match func(key string) bool) {
+	values := make([]ValueData, 0, len(e.Values))
+	for _, value := range e.Values {
+		if !match(value.Key) {
+			values = append(values, value)
+		}
+	}
+	e.Values = values
+}
+
+// decodeOTPBase32 decodes a base32 secret ignoring its case, spaces and padding
+func decodeOTPBase32(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "=", "").Replace(secret))
+	decoded, err := otpBase32Encoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOTPSettings, err)
+	}
+	return decoded, nil
+}
+
+// parseOTPInt parses an optional integer setting, returning 0 for an empty value
+func parseOTPInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidOTPSettings, err)
+	}
+	return parsed, nil
+}
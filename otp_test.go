@@ -0,0 +1,272 @@
+package gokeepasslib
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 4226 and RFC 6238 test secrets
+const (
+	otpSecretSHA1   = "12345678901234567890"
+	otpSecretSHA256 = "12345678901234567890123456789012"
+	otpSecretSHA512 = "1234567890123456789012345678901234567890123456789012345678901234"
+)
+
+func newOTPEntry(values ...ValueData) *Entry {
+	entry := NewEntry()
+	entry.Values = values
+	return &entry
+}
+
+func TestOTPSettings_HOTP(t *testing.T) {
+	expected := []string{"755224", "287082", "359152", "969429", "338314"}
+	settings := OTPSettings{Type: OTPTypeHOTP, Secret: []byte(otpSecretSHA1)}
+
+	for counter, code := range expected {
+		received, err := settings.HOTP(uint64(counter))
+		if err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		if received != code {
+			t.Errorf("Expected %s for counter %d, received %s", code, counter, received)
+		}
+	}
+}
+
+func TestOTPSettings_TOTP(t *testing.T) {
+	cases := []struct {
+		title     string
+		secret    string
+		algorithm OTPAlgorithm
+		at        int64
+		expected  string
+	}{
+		{title: "sha1", secret: otpSecretSHA1, algorithm: OTPAlgorithmSHA1, at: 59, expected: "94287082"},
+		{title: "sha1 later", secret: otpSecretSHA1, algorithm: OTPAlgorithmSHA1, at: 1111111109, expected: "07081804"},
+		{title: "sha256", secret: otpSecretSHA256, algorithm: OTPAlgorithmSHA256, at: 59, expected: "46119246"},
+		{title: "sha512", secret: otpSecretSHA512, algorithm: OTPAlgorithmSHA512, at: 59, expected: "90693936"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			settings := OTPSettings{Secret: []byte(c.secret), Algorithm: c.algorithm, Digits: 8}
+			code, err := settings.TOTP(time.Unix(c.at, 0))
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if code != c.expected {
+				t.Errorf("Expected %s, received %s", c.expected, code)
+			}
+		})
+	}
+}
+
+func TestEntry_GetOTPSettings(t *testing.T) {
+	base32Secret := otpBase32Encoding.EncodeToString([]byte(otpSecretSHA1))
+
+	cases := []struct {
+		title    string
+		values   []ValueData
+		expected OTPSettings
+	}{
+		{
+			title: "keepass totp",
+			values: []ValueData{
+				{Key: "TimeOtp-Secret-Base32", Value: V{Content: strings.ToLower(base32Secret)}},
+				{Key: "TimeOtp-Length", Value: V{Content: "8"}},
+				{Key: "TimeOtp-Period", Value: V{Content: "60"}},
+				{Key: "TimeOtp-Algorithm", Value: V{Content: "HMAC-SHA-256"}},
+			},
+			expected: OTPSettings{
+				Type:      OTPTypeTOTP,
+				Secret:    []byte(otpSecretSHA1),
+				Algorithm: OTPAlgorithmSHA256,
+				Digits:    8,
+				Period:    time.Minute,
+			},
+		},
+		{
+			title: "keepass hotp",
+			values: []ValueData{
+				{Key: "HmacOtp-Secret-Hex", Value: V{Content: "3132333435363738393031323334353637383930"}},
+				{Key: "HmacOtp-Counter", Value: V{Content: "3"}},
+			},
+			expected: OTPSettings{
+				Type:      OTPTypeHOTP,
+				Secret:    []byte(otpSecretSHA1),
+				Algorithm: OTPAlgorithmSHA1,
+				Digits:    DefaultOTPDigits,
+				Period:    DefaultOTPPeriod,
+				Counter:   3,
+			},
+		},
+		{
+			title: "keepassxc totp",
+			values: []ValueData{
+				{Key: "otp", Value: V{Content: "otpauth://totp/Example:alice@example.com?secret=" + base32Secret + "&period=30&digits=6&issuer=Example"}},
+			},
+			expected: OTPSettings{
+				Type:      OTPTypeTOTP,
+				Secret:    []byte(otpSecretSHA1),
+				Algorithm: OTPAlgorithmSHA1,
+				Digits:    6,
+				Period:    30 * time.Second,
+				Issuer:    "Example",
+				Account:   "alice@example.com",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			settings, err := newOTPEntry(c.values...).GetOTPSettings()
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(*settings, c.expected) {
+				t.Errorf("Expected %+v, received %+v", c.expected, *settings)
+			}
+		})
+	}
+}
+
+func TestEntry_GetOTPSettingsErrors(t *testing.T) {
+	cases := []struct {
+		title       string
+		values      []ValueData
+		expectedErr error
+	}{
+		{
+			title:       "no settings",
+			expectedErr: ErrNoOTPSettings,
+		},
+		{
+			title:       "invalid secret",
+			values:      []ValueData{{Key: "TimeOtp-Secret-Base32", Value: V{Content: "1!"}}},
+			expectedErr: ErrInvalidOTPSettings,
+		},
+		{
+			title: "invalid algorithm",
+			values: []ValueData{
+				{Key: "TimeOtp-Secret", Value: V{Content: "secret"}},
+				{Key: "TimeOtp-Algorithm", Value: V{Content: "HMAC-MD5"}},
+			},
+			expectedErr: ErrInvalidOTPSettings,
+		},
+		{
+			title:       "steam encoder",
+			values:      []ValueData{{Key: "otp", Value: V{Content: "otpauth://totp/x?secret=GEZDGNBV&encoder=steam"}}},
+			expectedErr: ErrInvalidOTPSettings,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			if _, err := newOTPEntry(c.values...).GetOTPSettings(); !errors.Is(err, c.expectedErr) {
+				t.Errorf("Expected %v, received %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestEntry_GenerateTOTP(t *testing.T) {
+	entry := newOTPEntry(ValueData{Key: "TimeOtp-Secret", Value: V{Content: otpSecretSHA1}})
+
+	code, err := entry.GenerateTOTP(time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if code != "287082" {
+		t.Errorf("Expected 287082, received %s", code)
+	}
+
+	if _, err := entry.GenerateHOTP(); !errors.Is(err, ErrInvalidOTPSettings) {
+		t.Errorf("Expected ErrInvalidOTPSettings for HOTP, received %v", err)
+	}
+}
+
+func TestEntry_GenerateHOTP(t *testing.T) {
+	entry := newOTPEntry()
+	settings := OTPSettings{Type: OTPTypeHOTP, Secret: []byte(otpSecretSHA1), Counter: 1}
+	for _, format := range []OTPFormat{OTPFormatKeePass, OTPFormatKeePassXC} {
+		if err := entry.SetOTPSettings(settings, format); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+	}
+
+	for _, expected := range []string{"287082", "359152"} {
+		code, err := entry.GenerateHOTP()
+		if err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		if code != expected {
+			t.Errorf("Expected %s, received %s", expected, code)
+		}
+	}
+
+	if entry.GetContent("HmacOtp-Counter") != "3" {
+		t.Errorf("Expected the KeePass counter to be advanced, received %s", entry.GetContent("HmacOtp-Counter"))
+	}
+	xcSettings, err := ParseOTPURI(entry.GetContent("otp"))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if xcSettings.Counter != 3 {
+		t.Errorf("Expected the KeePassXC counter to be advanced, received %d", xcSettings.Counter)
+	}
+}
+
+func TestEntry_SetOTPSettings(t *testing.T) {
+	settings := OTPSettings{
+		Secret:    []byte(otpSecretSHA1),
+		Algorithm: OTPAlgorithmSHA512,
+		Digits:    8,
+		Period:    time.Minute,
+		Issuer:    "Example Corp",
+		Account:   "alice",
+	}
+
+	for _, format := range []OTPFormat{OTPFormatKeePass, OTPFormatKeePassXC} {
+		entry := newOTPEntry(
+			ValueData{Key: "TimeOtp-Secret-Hex", Value: V{Content: "00"}},
+			ValueData{Key: "Title", Value: V{Content: "kept"}},
+		)
+		if format == OTPFormatKeePassXC {
+			entry.Values = entry.Values[1:]
+		}
+
+		if err := entry.SetOTPSettings(settings, format); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		if entry.GetTitle() != "kept" {
+			t.Errorf("Expected other values to be kept")
+		}
+
+		received, err := entry.GetOTPSettings()
+		if err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		if string(received.Secret) != otpSecretSHA1 || received.Algorithm != settings.Algorithm ||
+			received.Digits != settings.Digits || received.Period != settings.Period {
+			t.Errorf("Expected the stored settings for format %d, received %+v", format, received)
+		}
+	}
+
+	entry := newOTPEntry()
+	if err := entry.SetOTPSettings(settings, OTPFormatKeePass); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if !entry.Get("TimeOtp-Secret-Base32").Value.Protected.Bool {
+		t.Errorf("Expected the secret to be protected")
+	}
+	if entry.GetContent("TimeOtp-Algorithm") != "HMAC-SHA-512" {
+		t.Errorf("Expected the KeePass algorithm name, received %s", entry.GetContent("TimeOtp-Algorithm"))
+	}
+
+	if err := entry.SetOTPSettings(OTPSettings{}, OTPFormatKeePass); !errors.Is(err, ErrInvalidOTPSettings) {
+		t.Errorf("Expected ErrInvalidOTPSettings without secret, received %v", err)
+	}
+}
@@ -0,0 +1,404 @@
+package gokeepasslib
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxPlaceholderDepth is the maximum number of nested placeholders which are expanded
+const MaxPlaceholderDepth = 12
+
+// Errors returned when expanding placeholders, wrapped in a PlaceholderError
+var (
+	ErrInvalidPlaceholder        = errors.New("gokeepasslib: invalid placeholder")
+	ErrUnsafePlaceholder         = errors.New("gokeepasslib: placeholder is not allowed in safe mode")
+	ErrPlaceholderDepthExceeded  = errors.New("gokeepasslib: placeholders are nested too deep")
+	errPlaceholderNotImplemented = errors.New("placeholder not implemented")
+)
+
+// PlaceholderError is returned if a placeholder can not be expanded
+type PlaceholderError struct {
+	Placeholder string
+	Err         error
+}
+
+func (e *PlaceholderError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, e.Placeholder)
+}
+
+func (e *PlaceholderError) Unwrap() error {
+	return e.Err
+}
+
+// PlaceholderFunc returns the value of a custom placeholder for the entry e of db.
+// argument is the text after the colon of placeholders like `{NAME:argument}`.
+type PlaceholderFunc func(e *Entry, db *Database, argument string) (string, error)
+
+// PlaceholderEngine expands KeePass placeholders like `{USERNAME}`, `{URL:HOST}`,
+// `{S:CustomField}` or `{T-CONV:/{TITLE}/upper/}` and field references in the values of entries.
+// Unknown placeholders are kept as they are.
+type PlaceholderEngine struct {
+	custom      map[string]PlaceholderFunc
+	environment bool
+	safeMode    bool
+	now         func() time.Time
+}
+
+// PlaceholderOption is the option function type for use with NewPlaceholderEngine
+type PlaceholderOption func(*PlaceholderEngine)
+
+// WithPlaceholder registers a custom placeholder, see PlaceholderEngine.Register
+func WithPlaceholder(name string, fn PlaceholderFunc) PlaceholderOption {
+	return func(pe *PlaceholderEngine) {
+		pe.Register(name, fn)
+	}
+}
+
+// WithPlaceholderEnvironment enables the expansion of environment variables with `{ENV:NAME}`
+func WithPlaceholderEnvironment() PlaceholderOption {
+	return func(pe *PlaceholderEngine) {
+		pe.environment = true
+	}
+}
+
+// WithPlaceholderSafeMode makes the engine return ErrUnsafePlaceholder
+// for environment (`{ENV:...}`) and command (`{CMD:...}`) placeholders
+func WithPlaceholderSafeMode() PlaceholderOption {
+	return func(pe *PlaceholderEngine) {
+		pe.safeMode = true
+	}
+}
+
+// WithPlaceholderClock sets the function returning the time used for the `{DT_...}` placeholders
+func WithPlaceholderClock(now func() time.Time) PlaceholderOption {
+	return func(pe *PlaceholderEngine) {
+		pe.now = now
+	}
+}
+
+// NewPlaceholderEngine creates a placeholder engine configured with the given options
+func NewPlaceholderEngine(options ...PlaceholderOption) *PlaceholderEngine {
+	pe := &PlaceholderEngine{
+		custom: map[string]PlaceholderFunc{},
+		now:    time.Now,
+	}
+	for _, option := range options {
+		option(pe)
+	}
+	return pe
+}
+
+// Register adds a custom placeholder `{NAME}` or `{NAME:argument}`.
+// Names are case insensitive and custom placeholders take precedence over the built-in ones.
+func (pe *PlaceholderEngine) Register(name string, fn PlaceholderFunc) {
+	pe.custom[strings.ToUpper(name)] = fn
+}
+
+// Expand returns value with all placeholders and field references expanded
+// for the entry e of db. The protected values of the database have to be unlocked.
+func (pe *PlaceholderEngine) Expand(e *Entry, db *Database, value string) (string, error) {
+	if e == nil {
+		return "", ErrRequiredAttributeMissing("Entry")
+	}
+	if db == nil {
+		return "", ErrRequiredAttributeMissing("Database")
+	}
+
+	x := &placeholderExpander{
+		engine:     pe,
+		entry:      e,
+		db:         db,
+		references: &referenceResolver{db: db},
+	}
+	return x.expand(value, 0)
+}
+
+// ExpandContent returns the content of the value of e with the given key with all placeholders expanded
+func (pe *PlaceholderEngine) ExpandContent(e *Entry, db *Database, key string) (string, error) {
+	if e == nil {
+		return "", ErrRequiredAttributeMissing("Entry")
+	}
+	return pe.Expand(e, db, e.GetContent(key))
+}
+
+var placeholderFields = map[string]string{
+	"TITLE":    "Title",
+	"USERNAME": "UserName",
+	"PASSWORD": "Password",
+	"URL":      "URL",
+	"NOTES":    "Notes",
+}
+
+var placeholderTimeLayouts = map[string]string{
+	"SIMPLE": "20060102150405",
+	"YEAR":   "2006",
+	"MONTH":  "01",
+	"DAY":    "02",
+	"HOUR":   "15",
+	"MINUTE": "04",
+	"SECOND": "05",
+}
+
+var placeholderDefaultPorts = map[string]string{
+	"ftp":    "21",
+	"ssh":    "22",
+	"sftp":   "22",
+	"telnet": "23",
+	"http":   "80",
+	"https":  "443",
+	"ftps":   "990",
+}
+
+type placeholderExpander struct {
+	engine     *PlaceholderEngine
+	entry      *Entry
+	db         *Database
+	references *referenceResolver
+}
+
+func (x *placeholderExpander) expand(value string, depth int) (string, error) {
+	if depth > MaxPlaceholderDepth {
+		return "", ErrPlaceholderDepthExceeded
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(value); {
+		if value[i] != '{' {
+			result.WriteByte(value[i])
+			i++
+			continue
+		}
+
+		end := matchingBrace(value, i)
+		if end < 0 {
+			result.WriteString(value[i:])
+			break
+		}
+
+		expanded, err := x.expandPlaceholder(value[i+1:end], depth)
+		if errors.Is(err, errPlaceholderNotImplemented) {
+			result.WriteByte(value[i])
+			i++
+			continue
+		}
+		if err != nil {
+			var placeholderErr *PlaceholderError
+			var referenceErr *ReferenceError
+			if errors.As(err, &placeholderErr) || errors.As(err, &referenceErr) {
+				return "", err
+			}
+			return "", &PlaceholderError{Placeholder: value[i : end+1], Err: err}
+		}
+		result.WriteString(expanded)
+		i = end + 1
+	}
+	return result.String(), nil
+}
+
+// matchingBrace returns the index of the brace closing the one at start, or -1 if there is none
+func matchingBrace(value string, start int) int {
+	level := 0
+	for i := start; i < len(value); i++ {
+		switch value[i] {
+		case '{':
+			level++
+		case '}':
+			level--
+			if level == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (x *placeholderExpander) expandPlaceholder(placeholder string, depth int) (string, error) {
+	name, argument, hasArgument := strings.Cut(placeholder, ":")
+	name = strings.ToUpper(name)
+
+	switch name {
+	case "ENV", "CMD":
+		if x.engine.safeMode {
+			return "", ErrUnsafePlaceholder
+		}
+	}
+
+	if fn, ok := x.engine.custom[name]; ok {
+		return fn(x.entry, x.db, argument)
+	}
+
+	if key, ok := placeholderFields[name]; ok && !hasArgument {
+		return x.expand(x.entry.GetContent(key), depth+1)
+	}
+
+	switch {
+	case name == "REF" && hasArgument:
+		return x.references.resolveReference("{" + placeholder + "}")
+	case name == "S" && hasArgument:
+		return x.expand(x.entry.GetContent(argument), depth+1)
+	case name == "URL" && hasArgument:
+		return x.expandURL(strings.ToUpper(argument), depth)
+	case name == "UUID" && !hasArgument:
+		return strings.ToUpper(hex.EncodeToString(x.entry.UUID[:])), nil
+	case name == "GROUP" && !hasArgument:
+		if group := x.db.FindParentGroup(x.entry.UUID); group != nil {
+			return group.Name, nil
+		}
+		return "", nil
+	case name == "GROUP_PATH" && !hasArgument:
+		return x.groupPath(), nil
+	case strings.HasPrefix(name, "DT_") && !hasArgument:
+		return x.expandTime(strings.TrimPrefix(name, "DT_"))
+	case name == "ENV" && hasArgument && x.engine.environment:
+		return os.Getenv(argument), nil
+	case name == "T-CONV" && hasArgument:
+		return x.convert(argument, depth)
+	case name == "T-REPLACE-RX" && hasArgument:
+		return x.replace(argument, depth)
+	}
+	return "", errPlaceholderNotImplemented
+}
+
+// expandURL returns a part of the URL of the entry like KeePass `{URL:HOST}`
+func (x *placeholderExpander) expandURL(part string, depth int) (string, error) {
+	value, err := x.expand(x.entry.GetContent("URL"), depth+1)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		// KeePass expands the parts of invalid URLs to empty strings
+		return "", nil
+	}
+
+	switch part {
+	case "RMVSCM":
+		if u.Scheme == "" {
+			return value, nil
+		}
+		return strings.TrimPrefix(value[len(u.Scheme)+1:], "//"), nil
+	case "SCM":
+		return u.Scheme, nil
+	case "HOST":
+		return u.Hostname(), nil
+	case "PORT":
+		if port := u.Port(); port != "" {
+			return port, nil
+		}
+		return placeholderDefaultPorts[strings.ToLower(u.Scheme)], nil
+	case "PATH":
+		return u.Path, nil
+	case "QUERY":
+		if u.RawQuery == "" {
+			return "", nil
+		}
+		return "?" + u.RawQuery, nil
+	case "USERINFO":
+		return u.User.String(), nil
+	case "USERNAME":
+		return u.User.Username(), nil
+	case "PASSWORD":
+		password, _ := u.User.Password()
+		return password, nil
+	}
+	return "", errPlaceholderNotImplemented
+}
+
+// groupPath returns the names of the groups containing the entry separated by dots,
+// without the root group like KeePass
+func (x *placeholderExpander) groupPath() string {
+	if x.db.Content == nil || x.db.Content.Root == nil {
+		return ""
+	}
+	names, ok := groupPathNames(x.db.Content.Root.Groups, x.entry.UUID)
+	if !ok || len(names) < 2 {
+		return ""
+	}
+	return strings.Join(names[1:len(names)-1], ".")
+}
+
+func (x *placeholderExpander) expandTime(name string) (string, error) {
+	now := x.engine.now().Local()
+	if strings.HasPrefix(name, "UTC_") {
+		now = now.UTC()
+		name = strings.TrimPrefix(name, "UTC_")
+	}
+
+	layout, ok := placeholderTimeLayouts[name]
+	if !ok {
+		return "", errPlaceholderNotImplemented
+	}
+	return now.Format(layout), nil
+}
+
+// convert transforms text like KeePass `{T-CONV:/text/type/}`
+func (x *placeholderExpander) convert(argument string, depth int) (string, error) {
+	parts, err := splitTransformation(argument, 2)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := x.expand(parts[0], depth+1)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToUpper(parts[1]) {
+	case "U", "UPPER":
+		return strings.ToUpper(text), nil
+	case "L", "LOWER":
+		return strings.ToLower(text), nil
+	case "BASE64":
+		return base64.StdEncoding.EncodeToString([]byte(text)), nil
+	case "HEX":
+		return strings.ToUpper(hex.EncodeToString([]byte(text))), nil
+	case "URI":
+		return strings.ReplaceAll(url.QueryEscape(text), "+", "%20"), nil
+	case "URI-DEC":
+		return url.PathUnescape(text)
+	case "RAW":
+		return text, nil
+	}
+	return "", ErrInvalidPlaceholder
+}
+
+// replace replaces the matches of a regular expression like KeePass `{T-REPLACE-RX:/text/search/replace/}`
+func (x *placeholderExpander) replace(argument string, depth int) (string, error) {
+	parts, err := splitTransformation(argument, 3)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := x.expand(parts[0], depth+1)
+	if err != nil {
+		return "", err
+	}
+
+	expression, err := regexp.Compile(parts[1])
+	if err != nil {
+		return "", err
+	}
+	return expression.ReplaceAllString(text, parts[2]), nil
+}
+
+// splitTransformation splits the argument of a transformation placeholder into count parts.
+// The first character of the argument is the separator, which also has to terminate it.
+func splitTransformation(argument string, count int) ([]string, error) {
+	if argument == "" {
+		return nil, ErrInvalidPlaceholder
+	}
+
+	parts := strings.Split(argument[1:], argument[:1])
+	if len(parts) != count+1 || parts[count] != "" {
+		return nil, ErrInvalidPlaceholder
+	}
+	return parts[:count], nil
+}
@@ -0,0 +1,186 @@
+package gokeepasslib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPlaceholderDatabase() (*Database, *Entry) {
+	db := newReferenceDatabase()
+	entry := db.FindEntryByUUID(mergeEntryUUID)
+	entry.Get("URL").Value.Content = "https://admin:pw@example.com/login?next=home"
+	entry.Values = append(entry.Values, ValueData{Key: "Nested", Value: V{Content: "{USERNAME}@{URL:HOST}"}})
+	return db, entry
+}
+
+func TestPlaceholderEngine_Expand(t *testing.T) {
+	db, entry := newPlaceholderDatabase()
+	now := time.Date(2024, time.March, 5, 6, 7, 8, 0, time.UTC)
+	engine := NewPlaceholderEngine(
+		WithPlaceholderClock(func() time.Time { return now }),
+		WithPlaceholder("Reverse", func(e *Entry, db *Database, argument string) (string, error) {
+			runes := []rune(argument)
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			return string(runes), nil
+		}),
+	)
+
+	cases := []struct {
+		title    string
+		value    string
+		expected string
+	}{
+		{
+			title:    "entry fields",
+			value:    "{TITLE} {username} {PASSWORD} {NOTES}",
+			expected: "entry user secret some notes",
+		},
+		{
+			title:    "url parts",
+			value:    "{URL:SCM}|{URL:HOST}|{URL:PORT}|{URL:PATH}|{URL:QUERY}|{URL:USERNAME}|{URL:PASSWORD}",
+			expected: "https|example.com|443|/login|?next=home|admin|pw",
+		},
+		{
+			title:    "url without scheme",
+			value:    "{URL:RMVSCM}",
+			expected: "admin:pw@example.com/login?next=home",
+		},
+		{
+			title:    "custom string field",
+			value:    "{S:Custom}",
+			expected: "custom value",
+		},
+		{
+			title:    "nested placeholders in field",
+			value:    "{S:Nested}",
+			expected: "user@example.com",
+		},
+		{
+			title:    "group",
+			value:    "{GROUP} {GROUP_PATH}",
+			expected: "group group",
+		},
+		{
+			title:    "utc time",
+			value:    "{DT_UTC_SIMPLE} {DT_UTC_YEAR}-{DT_UTC_MONTH}-{DT_UTC_DAY}",
+			expected: "20240305060708 2024-03-05",
+		},
+		{
+			title:    "local time",
+			value:    "{DT_SIMPLE}",
+			expected: now.Local().Format("20060102150405"),
+		},
+		{
+			title:    "reference",
+			value:    "{REF:U@I:04000000000000000000000000000000}",
+			expected: "user",
+		},
+		{
+			title:    "conversion",
+			value:    "{T-CONV:/{TITLE}/upper/}|{T-CONV:/a b/uri/}|{T-CONV:/ab/hex/}|{T-CONV:/ab/base64/}",
+			expected: "ENTRY|a%20b|6162|YWI=",
+		},
+		{
+			title:    "regular expression replacement",
+			value:    "{T-REPLACE-RX:/{URL:HOST}/\\.com$/.org/}",
+			expected: "example.org",
+		},
+		{
+			title:    "custom placeholder",
+			value:    "{REVERSE:abc}",
+			expected: "cba",
+		},
+		{
+			title:    "unknown placeholders are kept",
+			value:    "{UNKNOWN} {ENV:HOME} {CMD:/ls/} {URL:OTHER} {TITLE",
+			expected: "{UNKNOWN} {ENV:HOME} {CMD:/ls/} {URL:OTHER} {TITLE",
+		},
+		{
+			title:    "placeholders within unknown placeholders",
+			value:    "{{TITLE}}",
+			expected: "{entry}",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			expanded, err := engine.Expand(entry, db, c.value)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if expanded != c.expected {
+				t.Errorf("Expected %s, received %s", c.expected, expanded)
+			}
+		})
+	}
+}
+
+func TestPlaceholderEngine_Environment(t *testing.T) {
+	db, entry := newPlaceholderDatabase()
+	t.Setenv("GOKEEPASSLIB_TEST", "value")
+
+	expanded, err := NewPlaceholderEngine(WithPlaceholderEnvironment()).Expand(entry, db, "{ENV:GOKEEPASSLIB_TEST}")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if expanded != "value" {
+		t.Errorf("Expected the environment variable, received %s", expanded)
+	}
+
+	entry.Values = append(entry.Values, ValueData{Key: "Cmd", Value: V{Content: "{CMD:/ls/}"}})
+	engine := NewPlaceholderEngine(WithPlaceholderEnvironment(), WithPlaceholderSafeMode())
+	for _, value := range []string{"{ENV:GOKEEPASSLIB_TEST}", "{S:Cmd}"} {
+		if _, err := engine.Expand(entry, db, value); !errors.Is(err, ErrUnsafePlaceholder) {
+			t.Errorf("Expected ErrUnsafePlaceholder for %s, received %v", value, err)
+		}
+	}
+}
+
+func TestPlaceholderEngine_ExpandErrors(t *testing.T) {
+	db, entry := newPlaceholderDatabase()
+	entry.Values = append(entry.Values, ValueData{Key: "Loop", Value: V{Content: "{S:Loop}"}})
+	engine := NewPlaceholderEngine()
+
+	cases := []struct {
+		title       string
+		value       string
+		expectedErr error
+	}{
+		{
+			title:       "invalid conversion",
+			value:       "{T-CONV:/text/unknown/}",
+			expectedErr: ErrInvalidPlaceholder,
+		},
+		{
+			title:       "unterminated conversion",
+			value:       "{T-CONV:/text/upper}",
+			expectedErr: ErrInvalidPlaceholder,
+		},
+		{
+			title:       "recursion",
+			value:       "{S:Loop}",
+			expectedErr: ErrPlaceholderDepthExceeded,
+		},
+		{
+			title:       "reference",
+			value:       "{REF:P@I:" + strings.Repeat("0", 32) + "}",
+			expectedErr: ErrReferenceNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			if _, err := engine.Expand(entry, db, c.value); !errors.Is(err, c.expectedErr) {
+				t.Errorf("Expected %v, received %v", c.expectedErr, err)
+			}
+		})
+	}
+
+	if _, err := engine.Expand(nil, db, "{TITLE}"); err == nil {
+		t.Errorf("Expected an error without entry")
+	}
+}
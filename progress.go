@@ -0,0 +1,143 @@
+package gokeepasslib
+
+import (
+	"context"
+	"io"
+)
+
+// ProgressPhase is a phase of decoding or encoding a database
+type ProgressPhase int
+
+const (
+	ProgressKdf        ProgressPhase = iota // Key derivation, counting AES-KDF rounds or Argon2 iterations
+	ProgressDecrypt                         // Decoding, counting the bytes of the encrypted content read
+	ProgressDecompress                      // Decoding, counting the bytes decompressed
+	ProgressParse                           // Decoding, counting the bytes of XML parsed
+	ProgressSerialize                       // Encoding, counting the bytes of XML written
+	ProgressEncrypt                         // Encoding, counting the bytes of the encrypted content written
+)
+
+func (p ProgressPhase) String() string {
+	switch p {
+	case ProgressKdf:
+		return "kdf"
+	case ProgressDecrypt:
+		return "decrypt"
+	case ProgressDecompress:
+		return "decompress"
+	case ProgressParse:
+		return "parse"
+	case ProgressSerialize:
+		return "serialize"
+	case ProgressEncrypt:
+		return "encrypt"
+	}
+	return "unknown"
+}
+
+// Progress is reported during decoding and encoding.
+// The content is streamed, so the phases after the key derivation are reported interleaved.
+type Progress struct {
+	Phase ProgressPhase
+	Done  uint64
+	Total uint64 // 0 if unknown, the last report of a phase has Done equal to Total
+}
+
+// ProgressFunc is called with the progress of decoding or encoding a database
+type ProgressFunc func(Progress)
+
+// report calls f if it is set
+func (f ProgressFunc) report(phase ProgressPhase, done, total uint64) {
+	if f != nil {
+		f(Progress{Phase: phase, Done: done, Total: total})
+	}
+}
+
+// progressReader reports the bytes read from r and checks ctx before every read
+type progressReader struct {
+	ctx      context.Context
+	r        io.Reader
+	phase    ProgressPhase
+	done     uint64
+	total    uint64
+	progress ProgressFunc
+}
+
+func newProgressReader(
+	ctx context.Context,
+	r io.Reader,
+	phase ProgressPhase,
+	total uint64,
+	progress ProgressFunc,
+) *progressReader {
+	return &progressReader{ctx: ctx, r: r, phase: phase, total: total, progress: progress}
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	if err := pr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.done += uint64(n)
+		pr.progress.report(pr.phase, pr.done, pr.total)
+	}
+	return n, err
+}
+
+// finish reports the phase as completed
+func (pr *progressReader) finish() {
+	pr.progress.report(pr.phase, pr.done, pr.done)
+}
+
+// progressWriter reports the bytes written to w and checks ctx before every write
+type progressWriter struct {
+	ctx      context.Context
+	w        io.Writer
+	phase    ProgressPhase
+	done     uint64
+	progress ProgressFunc
+}
+
+func newProgressWriter(ctx context.Context, w io.Writer, phase ProgressPhase, progress ProgressFunc) *progressWriter {
+	return &progressWriter{ctx: ctx, w: w, phase: phase, progress: progress}
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	if err := pw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := pw.w.Write(p)
+	if n > 0 {
+		pw.done += uint64(n)
+		pw.progress.report(pw.phase, pw.done, 0)
+	}
+	return n, err
+}
+
+// finish reports the phase as completed
+func (pw *progressWriter) finish() {
+	pw.progress.report(pw.phase, pw.done, pw.done)
+}
+
+// remainingSize returns the number of bytes left to read from r, or 0 if it is unknown
+func remainingSize(r io.Reader) uint64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return uint64(r.Len())
+	case io.Seeker:
+		current, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0
+		}
+		if _, err := r.Seek(current, io.SeekStart); err != nil || end < current {
+			return 0
+		}
+		return uint64(end - current)
+	}
+	return 0
+}
@@ -0,0 +1,222 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+// progressRecorder records the last progress reported for every phase
+type progressRecorder map[ProgressPhase]Progress
+
+func (pr progressRecorder) record(p Progress) {
+	pr[p.Phase] = p
+}
+
+// checkCompleted checks that every phase has been reported as completed
+func (pr progressRecorder) checkCompleted(t *testing.T, phases ...ProgressPhase) {
+	t.Helper()
+
+	for _, phase := range phases {
+		p, ok := pr[phase]
+		if !ok {
+			t.Errorf("Expected the %s phase to be reported", phase)
+			continue
+		}
+		if p.Done == 0 || p.Done != p.Total {
+			t.Errorf("Expected the %s phase to be completed, received %d of %d", phase, p.Done, p.Total)
+		}
+	}
+}
+
+func TestDecodeContextProgress(t *testing.T) {
+	cases := []struct {
+		title      string
+		dbFilePath string
+		phases     []ProgressPhase
+	}{
+		{
+			title:      "Database Format v3.1",
+			dbFilePath: "tests/kdbx3/example.kdbx",
+			phases:     []ProgressPhase{ProgressKdf, ProgressDecrypt, ProgressDecompress, ProgressParse},
+		},
+		{
+			title:      "Database Format v4",
+			dbFilePath: "tests/kdbx4/example.kdbx",
+			phases:     []ProgressPhase{ProgressKdf, ProgressDecrypt, ProgressDecompress, ProgressParse},
+		},
+		{
+			title:      "Database Format v4 without compression",
+			dbFilePath: "tests/kdbx4/example-nocompression.kdbx",
+			phases:     []ProgressPhase{ProgressKdf, ProgressDecrypt, ProgressParse},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			file, err := os.Open(c.dbFilePath)
+			if err != nil {
+				t.Fatalf("Failed to open keepass file: %s", err)
+			}
+			defer file.Close()
+
+			recorder := progressRecorder{}
+			db := NewDatabase()
+			db.Credentials = NewPasswordCredentials("abcdefg12345678")
+			if err := NewDecoder(file, WithDecoderProgress(recorder.record)).DecodeContext(context.Background(), db); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			recorder.checkCompleted(t, c.phases...)
+		})
+	}
+}
+
+func TestDecodeContextCanceled(t *testing.T) {
+	data, err := os.ReadFile("tests/kdbx4/example.kdbx")
+	if err != nil {
+		t.Fatalf("Failed to read keepass file: %s", err)
+	}
+
+	cases := []struct {
+		title  string
+		data   []byte
+		cancel func(cancel context.CancelFunc) ProgressFunc
+	}{
+		{
+			title: "before decoding",
+			data:  data,
+			cancel: func(cancel context.CancelFunc) ProgressFunc {
+				cancel()
+				return nil
+			},
+		},
+		{
+			title: "between blocks",
+			data:  data,
+			cancel: func(cancel context.CancelFunc) ProgressFunc {
+				return func(p Progress) {
+					if p.Phase == ProgressDecrypt {
+						cancel()
+					}
+				}
+			},
+		},
+		{
+			title: "during aes-kdf",
+			data: malformedHeader(t, DefaultKDBX3Sig, func(w io.Writer) error {
+				// The most rounds allowed by the default limits
+				rounds := make([]byte, 8)
+				binary.LittleEndian.PutUint64(rounds, DefaultMaxKdfRounds)
+				if err := writeTo31Header(w, headerIDTransformSeed, make([]byte, 32)); err != nil {
+					return err
+				}
+				if err := writeTo31Header(w, headerIDTransformRounds, rounds); err != nil {
+					return err
+				}
+				return writeTo31Header(w, headerIDHeaderEnd, []byte{0x0D, 0x0A, 0x0D, 0x0A})
+			}),
+			cancel: func(cancel context.CancelFunc) ProgressFunc {
+				return func(p Progress) {
+					if p.Phase == ProgressKdf && p.Done > 0 {
+						cancel()
+					}
+				}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			progress := c.cancel(cancel)
+
+			db := NewDatabase()
+			db.Credentials = NewPasswordCredentials("abcdefg12345678")
+			err := NewDecoder(bytes.NewReader(c.data), WithDecoderProgress(progress)).DecodeContext(ctx, db)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected error %v, received %v", context.Canceled, err)
+			}
+			var decodeErr *DecodeError
+			if errors.As(err, &decodeErr) {
+				t.Errorf("Expected the error not to be a DecodeError, received %v", err)
+			}
+		})
+	}
+}
+
+func TestEncodeContextProgress(t *testing.T) {
+	db := decodeTestFile(t, "tests/kdbx4/example.kdbx", NewPasswordCredentials("abcdefg12345678"))
+
+	recorder := progressRecorder{}
+	var buffer bytes.Buffer
+	if err := NewEncoder(&buffer, WithEncoderProgress(recorder.record)).EncodeContext(context.Background(), db); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	recorder.checkCompleted(t, ProgressKdf, ProgressSerialize, ProgressEncrypt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewEncoder(io.Discard).EncodeContext(ctx, db); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error %v, received %v", context.Canceled, err)
+	}
+}
+
+func TestCryptAESKeyContextProgress(t *testing.T) {
+	var reports []Progress
+	_, err := cryptAESKeyContext(
+		context.Background(),
+		make([]byte, 32),
+		make([]byte, 32),
+		3*aesKdfCheckRounds,
+		func(p Progress) { reports = append(reports, p) },
+	)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	expected := []uint64{0, aesKdfCheckRounds, 2 * aesKdfCheckRounds, 3 * aesKdfCheckRounds}
+	if len(reports) != len(expected) {
+		t.Fatalf("Expected %d reports, received %v", len(expected), reports)
+	}
+	for i, done := range expected {
+		if reports[i].Done != done || reports[i].Total != 3*aesKdfCheckRounds {
+			t.Errorf("Expected %d of %d rounds, received %v", done, 3*aesKdfCheckRounds, reports[i])
+		}
+	}
+}
+
+func TestRemainingSize(t *testing.T) {
+	file, err := os.Open("tests/kdbx4/example.kdbx")
+	if err != nil {
+		t.Fatalf("Failed to open keepass file: %s", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	if _, err := file.Seek(10, io.SeekStart); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if size := remainingSize(file); size != uint64(info.Size()-10) {
+		t.Errorf("Expected %d bytes, received %d", info.Size()-10, size)
+	}
+	if offset, _ := file.Seek(0, io.SeekCurrent); offset != 10 {
+		t.Errorf("Expected the offset to be kept, received %d", offset)
+	}
+
+	if size := remainingSize(bytes.NewReader(make([]byte, 5))); size != 5 {
+		t.Errorf("Expected 5 bytes, received %d", size)
+	}
+	if size := remainingSize(io.LimitReader(file, math.MaxInt64)); size != 0 {
+		t.Errorf("Expected an unknown size, received %d", size)
+	}
+}
@@ -0,0 +1,171 @@
+package quality
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+// ErrInvalidBreachList is returned if a line of a breach list is not a SHA-1 hash
+var ErrInvalidBreachList = errors.New("quality: invalid breach list")
+
+// breachScanSize is the size of the part of the list which is scanned instead of bisected further
+const breachScanSize = 4096
+
+// BreachList looks up passwords in an offline list of breached passwords,
+// like the Pwned Passwords list of Have I Been Pwned ordered by hash.
+// Each line of the list starts with the upper case hexadecimal SHA-1 hash of a password,
+// optionally followed by a colon and the number of occurrences, and the lines are sorted by hash.
+// The list is searched by bisection, so it is never read completely.
+type BreachList struct {
+	r    io.ReaderAt
+	size int64
+	file *os.File
+}
+
+// NewBreachList returns a breach list reading size bytes from r
+func NewBreachList(r io.ReaderAt, size int64) *BreachList {
+	return &BreachList{r: r, size: size}
+}
+
+// OpenBreachList opens the breach list file at path, it has to be closed after use
+func OpenBreachList(path string) (*BreachList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	list := NewBreachList(file, info.Size())
+	list.file = file
+	return list, nil
+}
+
+// Close closes the file of a breach list opened with OpenBreachList
+func (b *BreachList) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	return b.file.Close()
+}
+
+// Lookup returns how often password occurs in the list, and whether it is contained at all
+func (b *BreachList) Lookup(password string) (int64, bool, error) {
+	sum := sha1.Sum([]byte(password))
+	return b.LookupHash(hex.EncodeToString(sum[:]))
+}
+
+// LookupHash returns how often the password with the given hexadecimal SHA-1 hash occurs in the list,
+// and whether it is contained at all
+func (b *BreachList) LookupHash(hash string) (int64, bool, error) {
+	target := bytes.ToUpper([]byte(hash))
+
+	// The line of the hash starts within [low, high) if it is contained, low is always the start of a line
+	low, high := int64(0), b.size
+	for high-low > breachScanSize {
+		middle := low + (high-low)/2
+		start, line, err := b.lineAfter(middle)
+		if err != nil {
+			return 0, false, err
+		}
+		if start >= high {
+			high = middle
+			continue
+		}
+
+		lineHash, count, err := parseBreachLine(line)
+		if err != nil {
+			return 0, false, err
+		}
+		switch c := bytes.Compare(lineHash, target); {
+		case c == 0:
+			return count, true, nil
+		case c < 0:
+			low = start + int64(len(line))
+		default:
+			high = start
+		}
+	}
+
+	scanner := bufio.NewScanner(io.NewSectionReader(b.r, low, b.size-low))
+	position := low
+	for position < high && scanner.Scan() {
+		line := scanner.Bytes()
+		position += int64(len(line)) + 1
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		lineHash, count, err := parseBreachLine(line)
+		if err != nil {
+			return 0, false, err
+		}
+		switch c := bytes.Compare(lineHash, target); {
+		case c == 0:
+			return count, true, nil
+		case c > 0:
+			return 0, false, nil
+		}
+	}
+	return 0, false, scanner.Err()
+}
+
+// lineAfter returns the first line starting at or after offset including its line break,
+// and the position it starts at
+func (b *BreachList) lineAfter(offset int64) (int64, []byte, error) {
+	start := offset
+	reader := bufio.NewReader(io.NewSectionReader(b.r, offset, b.size-offset))
+	if offset > 0 {
+		// Skip the rest of the line the offset points into, unless it is the start of a line
+		var previous [1]byte
+		if _, err := b.r.ReadAt(previous[:], offset-1); err != nil {
+			return 0, nil, err
+		}
+		if previous[0] != '\n' {
+			skipped, err := reader.ReadBytes('\n')
+			start += int64(len(skipped))
+			if errors.Is(err, io.EOF) {
+				return b.size, nil, nil
+			}
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+
+	line, err := reader.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, nil, err
+	}
+	if len(line) == 0 {
+		return b.size, nil, nil
+	}
+	return start, line, nil
+}
+
+// parseBreachLine returns the upper case hash and the count of a line like "HASH:COUNT"
+func parseBreachLine(line []byte) ([]byte, int64, error) {
+	line = bytes.TrimSpace(line)
+	hash, countText, hasCount := bytes.Cut(line, []byte(":"))
+	if len(hash) != 2*sha1.Size {
+		return nil, 0, ErrInvalidBreachList
+	}
+
+	var count int64
+	if hasCount {
+		var err error
+		if count, err = strconv.ParseInt(string(countText), 10, 64); err != nil {
+			return nil, 0, ErrInvalidBreachList
+		}
+	}
+	return bytes.ToUpper(hash), count, nil
+}
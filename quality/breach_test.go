@@ -0,0 +1,81 @@
+package quality
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newBreachListData(passwords []string) []byte {
+	var lines []string
+	for i, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i+1))
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+func TestBreachList_Lookup(t *testing.T) {
+	var passwords []string
+	for i := 0; i < 2000; i++ {
+		passwords = append(passwords, fmt.Sprintf("breached%d", i))
+	}
+	data := newBreachListData(passwords)
+	list := NewBreachList(bytes.NewReader(data), int64(len(data)))
+
+	for i, password := range passwords {
+		count, found, err := list.Lookup(password)
+		if err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		if !found || count != int64(i+1) {
+			t.Fatalf("Expected %s to be found %d times, received %v %d", password, i+1, found, count)
+		}
+	}
+
+	for _, password := range []string{"", "not breached", "breached2000"} {
+		_, found, err := list.Lookup(password)
+		if err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		if found {
+			t.Errorf("Expected %q to not be found", password)
+		}
+	}
+}
+
+func TestOpenBreachList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, newBreachListData([]string{"password", "123456"}), 0o600); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	list, err := OpenBreachList(path)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	defer list.Close()
+
+	if _, found, err := list.Lookup("123456"); err != nil || !found {
+		t.Errorf("Expected the password to be found, received %v %v", found, err)
+	}
+	if _, found, err := list.LookupHash("0000000000000000000000000000000000000000"); err != nil || found {
+		t.Errorf("Expected the hash to not be found, received %v %v", found, err)
+	}
+}
+
+func TestBreachList_Invalid(t *testing.T) {
+	data := []byte("not a hash\n")
+	list := NewBreachList(bytes.NewReader(data), int64(len(data)))
+	if _, _, err := list.Lookup("password"); !errors.Is(err, ErrInvalidBreachList) {
+		t.Errorf("Expected ErrInvalidBreachList, received %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package quality
+
+import (
+	"strings"
+
+	"github.com/tobischo/gokeepasslib/v3/generator"
+)
+
+// commonPasswords are frequently used passwords, most common first
+var commonPasswords = strings.Fields(`
+123456 password 123456789 12345678 12345 qwerty 1234567 111111 1234567890 123123 abc123 1234
+password1 iloveyou 1q2w3e4r 000000 qwerty123 zaq12wsx dragon sunshine princess letmein 654321
+monkey 27653 1qaz2wsx 123321 qwertyuiop superman asdfghjkl trustno1 football baseball welcome
+admin master hello freedom whatever qazwsx shadow michael jennifer 666666 121212 charlie donald
+password123 starwars login passw0rd solo access flower hottie loveme zxcvbnm batman 696969
+mustang jordan liverpool hunter ranger buster soccer harley andrew tigger robert thomas hockey
+daniel jessica pepper ginger joshua cheese amanda summer love ashley nicole chelsea biteme
+matthew yankees austin computer taylor thunder internet secret orange maggie silver banana
+purple cookie chocolate killer maverick merlin diamond anthony corvette hello123 whatever1
+nothing samsung apple pokemon naruto blink182 spiderman qwe123 asdf asdfgh 1q2w3e 112233 159753
+987654321 7777777 888888 555555 999999 123qwe qwerty1 1qazxsw2 google monkey1 welcome1 iloveyou1
+princess1 sunshine1 football1 charlie1 aaaaaa 11111111 abcdef abcd1234 test test123 guest root
+changeme default secret123 pass1234 temp temp123 user admin123 administrator letmein1 lovely
+angel angels baby babygirl beautiful bailey buddy butterfly calvin camaro captain carlos casper
+chicken chris coffee cowboy cowboys crystal dakota dallas dolphin eagle eagles edward family
+forever frank friends gandalf garfield george golden golf hammer hannah happy heather
+helpmeplease horses hunter2 jackson jasmine jasper jesus joseph junior justin kitten knight
+lakers lauren legend lemon london lucky madison marina martin matrix melissa michelle midnight
+mickey miller monica morgan mother muffin music nathan newyork nicholas ninja oliver packers
+panther panthers patrick peanut phoenix player please pookie power rabbit rachel rainbow redsox
+richard rocket rosebud samantha sammy sandra scooter scorpion september shannon sierra simpson
+skippy smokey snoopy sophie sparky spider spring startrek steelers steven stupid sugar summer1
+sunny superstar sweet swordfish tennis tiger tigers tinkerbell toyota trinity tucker turtle
+united victoria viking voodoo warrior william wilson winner winter wizard xavier yamaha yellow
+zxcvbn zxcvbnm1 abc password12 qwerty12 master1 dragon1 shadow1 monkey12 access14 mercedes
+ferrari porsche hello1 flower1 lovers lover money money1 qwertz azerty asdfg zxcv qwer trustme
+mypass mypassword secure security keepass letmein123 welcome123 summer2020 winter2020 spring2021
+autumn
+`)
+
+// dictionary maps lower case words to their rank, the number of guesses to find them
+type dictionary map[string]int
+
+// rankedDictionary returns a dictionary ranking the words by their position in words
+func rankedDictionary(words []string) dictionary {
+	d := make(dictionary, len(words))
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if _, ok := d[word]; !ok {
+			d[word] = i + 1
+		}
+	}
+	return d
+}
+
+// uniformDictionary returns a dictionary where each word is ranked like a random choice of words
+func uniformDictionary(words []string) dictionary {
+	d := make(dictionary, len(words))
+	for _, word := range words {
+		d[strings.ToLower(word)] = len(words)
+	}
+	return d
+}
+
+var (
+	passwordDictionary = rankedDictionary(commonPasswords)
+	wordDictionary     = uniformDictionary(generator.WordList())
+)
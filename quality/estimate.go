@@ -0,0 +1,245 @@
+// Package quality estimates the strength of passwords and checks them against lists of breached passwords.
+package quality
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// MaxLength is the number of characters of a password which are analyzed,
+// the remaining characters are counted as random characters
+const MaxLength = 100
+
+const (
+	// bruteforceCardinality is the number of guesses per character of parts not matching any pattern
+	bruteforceCardinality = 10
+	minGuessesSingleChar  = 10
+	minGuessesMultiChar   = 50
+	// minGuessesBeforeGrowingSequence makes passwords of many short patterns stronger than few long ones
+	minGuessesBeforeGrowingSequence = 10000
+)
+
+// Pattern is the kind of a part of a password
+type Pattern string
+
+// Patterns recognized in passwords
+const (
+	PatternDictionary Pattern = "dictionary" // Common password, word or user input, also reversed or with l33t substitutions
+	PatternSequence   Pattern = "sequence"   // Sequence of characters like "abc" or "9753"
+	PatternRepeat     Pattern = "repeat"     // Repetitions like "aaa" or "abcabc"
+	PatternSpatial    Pattern = "spatial"    // Adjacent keys of a QWERTY keyboard like "qwerty" or "zxcvb"
+	PatternYear       Pattern = "year"       // Recent years like "1987"
+	PatternBruteforce Pattern = "bruteforce" // Characters not matching any other pattern
+)
+
+// Match is a part of a password matching a pattern
+type Match struct {
+	Pattern Pattern
+	Token   string
+	Guesses float64 // Estimated number of guesses needed to find the token
+
+	start, end int // Rune indexes of the token within the password
+}
+
+// Strength is the estimated strength of a password
+type Strength struct {
+	Guesses float64 // Estimated number of guesses needed to find the password
+	Entropy float64 // Base 2 logarithm of Guesses in bits
+	Score   int     // 0 (too guessable) to 4 (very unguessable) like zxcvbn
+	Matches []Match // Parts of the password, in order
+}
+
+// scoreThresholds are the base 2 logarithms of the guesses needed for the scores 1 to 4
+var scoreThresholds = []float64{
+	math.Log2(1e3 + 5),
+	math.Log2(1e6 + 5),
+	math.Log2(1e8 + 5),
+	math.Log2(1e10 + 5),
+}
+
+// Estimate returns the strength of password similar to the zxcvbn estimator:
+// The password is split into the patterns needing the fewest guesses to find all of them.
+// userInputs like the title or user name of an entry are considered as dictionary words.
+func Estimate(password string, userInputs ...string) Strength {
+	dictionaries := []dictionary{passwordDictionary, wordDictionary}
+	if len(userInputs) > 0 {
+		var words []string
+		for _, input := range userInputs {
+			words = append(words, strings.Fields(strings.ToLower(input))...)
+		}
+		dictionaries = append(dictionaries, rankedDictionary(words))
+	}
+
+	runes := []rune(password)
+	var rest int
+	if len(runes) > MaxLength {
+		rest = len(runes) - MaxLength
+		runes = runes[:MaxLength]
+	}
+
+	e := estimator{dictionaries: dictionaries, repeats: map[string]float64{}}
+	entropy, matches := e.mostGuessable(runes)
+	entropy += float64(rest) * math.Log2(bruteforceCardinality)
+
+	strength := Strength{
+		Guesses: math.Pow(2, entropy),
+		Entropy: entropy,
+		Matches: matches,
+	}
+	for _, threshold := range scoreThresholds {
+		if entropy >= threshold {
+			strength.Score++
+		}
+	}
+	return strength
+}
+
+type estimator struct {
+	dictionaries []dictionary
+	repeats      map[string]float64 // Guesses of repeated units
+}
+
+// optimum is the best way found to guess a prefix of the password with a number of matches
+type optimum struct {
+	entropy   float64 // log2 of the guesses including the penalty for the number of matches
+	product   float64 // Sum of the log2 guesses of the matches
+	match     Match
+	previousL int
+}
+
+// mostGuessable returns the log2 guesses of the best sequence of matches covering the password
+func (e *estimator) mostGuessable(runes []rune) (float64, []Match) {
+	n := len(runes)
+	if n == 0 {
+		return 0, nil
+	}
+
+	byEnd := make([][]Match, n+1)
+	for _, m := range e.matches(runes) {
+		byEnd[m.end] = append(byEnd[m.end], m)
+	}
+
+	optimal := make([]map[int]optimum, n+1)
+	for k := range optimal {
+		optimal[k] = map[int]optimum{}
+	}
+
+	update := func(m Match, l int, product float64, previousL int) {
+		product += math.Log2(guesses(m, n))
+		entropy := logFactorial(l) + product
+		if l > 1 {
+			entropy = logSum(entropy, float64(l-1)*math.Log2(minGuessesBeforeGrowingSequence))
+		}
+		for otherL, other := range optimal[m.end] {
+			if otherL <= l && other.entropy <= entropy {
+				return
+			}
+		}
+		optimal[m.end][l] = optimum{entropy: entropy, product: product, match: m, previousL: previousL}
+	}
+
+	extend := func(m Match, skipBruteforce bool) {
+		if m.start == 0 {
+			update(m, 1, 0, 0)
+			return
+		}
+		for l, previous := range optimal[m.start] {
+			if skipBruteforce && previous.match.Pattern == PatternBruteforce {
+				continue
+			}
+			update(m, l+1, previous.product, l)
+		}
+	}
+
+	for k := 1; k <= n; k++ {
+		for _, m := range byEnd[k] {
+			extend(m, false)
+		}
+		for start := 0; start < k; start++ {
+			extend(bruteforceMatch(runes, start, k), true)
+		}
+	}
+
+	bestL := -1
+	for l, o := range optimal[n] {
+		if bestL < 0 || o.entropy < optimal[n][bestL].entropy {
+			bestL = l
+		}
+	}
+
+	entropy := optimal[n][bestL].entropy
+	var matches []Match
+	for k, l := n, bestL; k > 0; {
+		o := optimal[k][l]
+		o.match.Guesses = guesses(o.match, n)
+		matches = append([]Match{o.match}, matches...)
+		k, l = o.match.start, o.previousL
+	}
+	return entropy, matches
+}
+
+// matches returns all matches of patterns within the password
+func (e *estimator) matches(runes []rune) []Match {
+	var matches []Match
+	matches = append(matches, e.dictionaryMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, e.repeatMatches(runes)...)
+	matches = append(matches, spatialMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+	return matches
+}
+
+// guesses returns the guesses of m with a lower bound for matches not covering the whole password
+func guesses(m Match, passwordLength int) float64 {
+	minimum := 1.0
+	if m.end-m.start < passwordLength {
+		minimum = minGuessesMultiChar
+		if m.end-m.start == 1 {
+			minimum = minGuessesSingleChar
+		}
+	}
+	return math.Max(m.Guesses, minimum)
+}
+
+func bruteforceMatch(runes []rune, start, end int) Match {
+	length := end - start
+	minimum := float64(minGuessesMultiChar + 1)
+	if length == 1 {
+		minimum = minGuessesSingleChar + 1
+	}
+	return Match{
+		Pattern: PatternBruteforce,
+		Token:   string(runes[start:end]),
+		Guesses: math.Max(math.Pow(bruteforceCardinality, float64(length)), minimum),
+		start:   start,
+		end:     end,
+	}
+}
+
+// logSum returns log2(2^a + 2^b)
+func logSum(a, b float64) float64 {
+	high, low := math.Max(a, b), math.Min(a, b)
+	return high + math.Log2(1+math.Pow(2, low-high))
+}
+
+// logFactorial returns log2(n!)
+func logFactorial(n int) float64 {
+	result, _ := math.Lgamma(float64(n + 1))
+	return result / math.Ln2
+}
+
+// binomial returns the binomial coefficient n choose k
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package quality
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	cases := []struct {
+		title           string
+		password        string
+		userInputs      []string
+		expectedScore   int
+		expectedPattern Pattern
+	}{
+		{title: "empty", password: "", expectedScore: 0},
+		{title: "common password", password: "password", expectedScore: 0, expectedPattern: PatternDictionary},
+		{title: "capitalized l33t", password: "P@ssw0rd", expectedScore: 0, expectedPattern: PatternDictionary},
+		{title: "reversed", password: "drowssap", expectedScore: 0, expectedPattern: PatternDictionary},
+		{title: "sequence", password: "abcdefghij", expectedScore: 0, expectedPattern: PatternSequence},
+		{title: "descending digits", password: "97531", expectedScore: 0, expectedPattern: PatternSequence},
+		{title: "repeat", password: "xyzxyzxyzxyz", expectedScore: 0, expectedPattern: PatternRepeat},
+		{title: "keyboard", password: "zaq1xsw2", expectedScore: 1, expectedPattern: PatternSpatial},
+		{title: "year", password: "1987", expectedScore: 0, expectedPattern: PatternYear},
+		{title: "user input", password: "JohnDoe", userInputs: []string{"John", "doe"}, expectedScore: 1},
+		{title: "random", password: "kX9#vL2$mQ8!wZ", expectedScore: 4, expectedPattern: PatternBruteforce},
+		{title: "passphrase", password: "walrus-gecko-ember-quill-tundra", expectedScore: 4},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			strength := Estimate(c.password, c.userInputs...)
+			if strength.Score != c.expectedScore {
+				t.Errorf("Expected score %d, received %d (%+v)", c.expectedScore, strength.Score, strength)
+			}
+			if c.expectedPattern != "" && (len(strength.Matches) == 0 || strength.Matches[0].Pattern != c.expectedPattern) {
+				t.Errorf("Expected a %s match, received %+v", c.expectedPattern, strength.Matches)
+			}
+
+			var tokens strings.Builder
+			for _, m := range strength.Matches {
+				tokens.WriteString(m.Token)
+			}
+			if tokens.String() != c.password {
+				t.Errorf("Expected the matches to cover the password, received %+v", strength.Matches)
+			}
+		})
+	}
+}
+
+func TestEstimate_Ordering(t *testing.T) {
+	weak := Estimate("monkey1")
+	strong := Estimate("monkey1-ginger-Wombat-97!")
+	if weak.Entropy >= strong.Entropy {
+		t.Errorf("Expected the longer password to be stronger, received %f and %f", weak.Entropy, strong.Entropy)
+	}
+
+	withInputs := Estimate("JohnDoe", "John Doe")
+	withoutInputs := Estimate("JohnDoe")
+	if withInputs.Entropy >= withoutInputs.Entropy {
+		t.Errorf("Expected user inputs to weaken the password, received %f and %f", withInputs.Entropy, withoutInputs.Entropy)
+	}
+
+	long := Estimate(strings.Repeat("a", MaxLength+10))
+	if expected := 10 * math.Log2(10); long.Entropy < expected {
+		t.Errorf("Expected the characters after MaxLength to be counted as random, received %f", long.Entropy)
+	}
+}
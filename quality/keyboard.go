@@ -0,0 +1,145 @@
+package quality
+
+import "math"
+
+// qwertyRows are the unshifted and shifted characters of the rows of a QWERTY keyboard.
+// Each row is shifted by half a key to the right relative to the row above.
+var qwertyRows = [][2]string{
+	{"1234567890-=", "!@#$%^&*()_+"},
+	{"qwertyuiop[]\\", "QWERTYUIOP{}|"},
+	{"asdfghjkl;'", "ASDFGHJKL:\""},
+	{"zxcvbnm,./", "ZXCVBNM<>?"},
+}
+
+// keyPosition is the position of a key on the keyboard
+type keyPosition struct {
+	row, column int
+	shifted     bool
+}
+
+// keyNeighbors are the relative positions of the adjacent keys, the index is the direction
+var keyNeighbors = [][2]int{
+	{0, -1}, // left
+	{0, 1},  // right
+	{-1, 0}, // upper left
+	{-1, 1}, // upper right
+	{1, -1}, // lower left
+	{1, 0},  // lower right
+}
+
+var (
+	keyPositions        = qwertyKeyPositions()
+	keyCount, keyDegree = qwertyStatistics()
+)
+
+func qwertyKeyPositions() map[rune]keyPosition {
+	positions := map[rune]keyPosition{}
+	for row, keys := range qwertyRows {
+		for column, key := range []rune(keys[0]) {
+			positions[key] = keyPosition{row: row, column: column}
+		}
+		for column, key := range []rune(keys[1]) {
+			positions[key] = keyPosition{row: row, column: column, shifted: true}
+		}
+	}
+	return positions
+}
+
+// qwertyStatistics returns the number of keys and the average number of their neighbors
+func qwertyStatistics() (float64, float64) {
+	var keys, neighbors int
+	for row, chars := range qwertyRows {
+		for column := range chars[0] {
+			keys++
+			for _, neighbor := range keyNeighbors {
+				if keyAt(row+neighbor[0], column+neighbor[1]) {
+					neighbors++
+				}
+			}
+		}
+	}
+	return float64(keys), float64(neighbors) / float64(keys)
+}
+
+func keyAt(row, column int) bool {
+	return row >= 0 && row < len(qwertyRows) && column >= 0 && column < len(qwertyRows[row][0])
+}
+
+// keyDirection returns the direction from key a to key b, or -1 if they are not adjacent
+func keyDirection(a, b rune) int {
+	pa, okA := keyPositions[a]
+	pb, okB := keyPositions[b]
+	if !okA || !okB {
+		return -1
+	}
+	for direction, neighbor := range keyNeighbors {
+		if pa.row+neighbor[0] == pb.row && pa.column+neighbor[1] == pb.column {
+			return direction
+		}
+	}
+	return -1
+}
+
+// spatialMatches returns sequences of at least 3 adjacent keys like "qwerty" or "zaq1"
+func spatialMatches(runes []rune) []Match {
+	var matches []Match
+	for start := 0; start+2 < len(runes); {
+		end := start + 1
+		turns, lastDirection := 0, -1
+		for end < len(runes) {
+			direction := keyDirection(runes[end-1], runes[end])
+			if direction < 0 {
+				break
+			}
+			if direction != lastDirection {
+				turns++
+				lastDirection = direction
+			}
+			end++
+		}
+
+		if end-start >= 3 {
+			matches = append(matches, Match{
+				Pattern: PatternSpatial,
+				Token:   string(runes[start:end]),
+				Guesses: spatialGuesses(runes[start:end], turns),
+				start:   start,
+				end:     end,
+			})
+			start = end - 1
+		} else {
+			start++
+		}
+	}
+	return matches
+}
+
+func spatialGuesses(token []rune, turns int) float64 {
+	length := len(token)
+	var guesses float64
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= turns && j <= i-1; j++ {
+			guesses += binomial(i-1, j-1) * keyCount * math.Pow(keyDegree, float64(j))
+		}
+	}
+
+	var shifted int
+	for _, r := range token {
+		if keyPositions[r].shifted {
+			shifted++
+		}
+	}
+	unshifted := length - shifted
+	switch {
+	case shifted == 0:
+	case unshifted == 0:
+		guesses *= 2
+	default:
+		var variations float64
+		for i := 1; i <= shifted && i <= unshifted; i++ {
+			variations += binomial(shifted+unshifted, i)
+		}
+		guesses *= variations
+	}
+	return guesses
+}
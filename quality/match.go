@@ -0,0 +1,306 @@
+package quality
+
+import (
+	"math"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// leetSubstitutions maps l33t characters to the letters they may substitute
+var leetSubstitutions = map[rune][]rune{
+	'4': {'a'},
+	'@': {'a'},
+	'8': {'b'},
+	'(': {'c'},
+	'{': {'c'},
+	'[': {'c'},
+	'<': {'c'},
+	'3': {'e'},
+	'6': {'g'},
+	'9': {'g'},
+	'1': {'i', 'l'},
+	'!': {'i'},
+	'|': {'i', 'l'},
+	'7': {'l', 't'},
+	'0': {'o'},
+	'$': {'s'},
+	'5': {'s'},
+	'+': {'t'},
+	'%': {'x'},
+	'2': {'z'},
+}
+
+// maxLeetCandidates limits the number of substitutions tried for tokens with ambiguous l33t characters
+const maxLeetCandidates = 16
+
+// dictionaryMatches returns the tokens found in the dictionaries, also reversed or with l33t substitutions
+func (e *estimator) dictionaryMatches(runes []rune) []Match {
+	lower := []rune(string(runes))
+	for i, r := range lower {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var matches []Match
+	for start := range runes {
+		for end := start + 1; end <= len(runes); end++ {
+			token := lower[start:end]
+			variations := uppercaseVariations(runes[start:end])
+
+			best := math.Inf(1)
+			if rank, ok := e.lookup(string(token)); ok {
+				best = rank * variations
+			}
+			if rank, ok := e.lookup(reverse(token)); ok && end-start > 1 {
+				best = math.Min(best, rank*variations*2)
+			}
+			for _, substitution := range leetCandidates(token) {
+				subbed := make([]rune, len(token))
+				for i, r := range token {
+					if letter, ok := substitution[r]; ok {
+						r = letter
+					}
+					subbed[i] = r
+				}
+				if rank, ok := e.lookup(string(subbed)); ok {
+					best = math.Min(best, rank*variations*leetVariations(token, substitution))
+				}
+			}
+
+			if !math.IsInf(best, 1) {
+				matches = append(matches, Match{
+					Pattern: PatternDictionary,
+					Token:   string(runes[start:end]),
+					Guesses: best,
+					start:   start,
+					end:     end,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+// lookup returns the lowest rank of word in the dictionaries
+func (e *estimator) lookup(word string) (float64, bool) {
+	best, found := 0, false
+	for _, d := range e.dictionaries {
+		if rank, ok := d[word]; ok && (!found || rank < best) {
+			best, found = rank, true
+		}
+	}
+	return float64(best), found
+}
+
+// uppercaseVariations returns the number of ways to capitalize a word like token
+func uppercaseVariations(token []rune) float64 {
+	var upper, lower int
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+
+	first, last := token[0], token[len(token)-1]
+	if lower == 0 ||
+		upper == 1 && (unicode.IsUpper(first) || unicode.IsUpper(last)) {
+		return 2
+	}
+
+	var variations float64
+	for i := 1; i <= upper && i <= lower; i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+// leetCandidates returns the possible substitutions of the l33t characters of token
+func leetCandidates(token []rune) []map[rune]rune {
+	candidates := []map[rune]rune{{}}
+	seen := map[rune]bool{}
+	for _, r := range token {
+		letters, ok := leetSubstitutions[r]
+		if !ok || seen[r] {
+			continue
+		}
+		seen[r] = true
+
+		var extended []map[rune]rune
+		for _, candidate := range candidates {
+			for _, letter := range letters {
+				if len(extended) >= maxLeetCandidates {
+					break
+				}
+				substitution := map[rune]rune{r: letter}
+				for k, v := range candidate {
+					substitution[k] = v
+				}
+				extended = append(extended, substitution)
+			}
+		}
+		candidates = extended
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+	return candidates
+}
+
+// leetVariations returns the number of ways to substitute letters of a word like in token
+func leetVariations(token []rune, substitution map[rune]rune) float64 {
+	variations := 1.0
+	for leet, letter := range substitution {
+		var subbed, unsubbed int
+		for _, r := range token {
+			switch r {
+			case leet:
+				subbed++
+			case letter:
+				unsubbed++
+			}
+		}
+
+		if subbed == 0 || unsubbed == 0 {
+			variations *= 2
+			continue
+		}
+		var possibilities float64
+		for i := 1; i <= subbed && i <= unsubbed; i++ {
+			possibilities += binomial(subbed+unsubbed, i)
+		}
+		variations *= possibilities
+	}
+	return variations
+}
+
+// sequenceMatches returns sequences of at least 3 characters with a constant step like "abc" or "8642"
+func sequenceMatches(runes []rune) []Match {
+	var matches []Match
+	for start := 0; start+2 < len(runes); {
+		delta := runes[start+1] - runes[start]
+		end := start + 1
+		for end < len(runes) && runes[end]-runes[end-1] == delta && sameClass(runes[start], runes[end]) {
+			end++
+		}
+
+		if end-start >= 3 && delta != 0 && delta >= -5 && delta <= 5 {
+			matches = append(matches, Match{
+				Pattern: PatternSequence,
+				Token:   string(runes[start:end]),
+				Guesses: sequenceGuesses(runes[start], delta, end-start),
+				start:   start,
+				end:     end,
+			})
+		}
+
+		if end-start > 2 {
+			start = end - 1
+		} else {
+			start++
+		}
+	}
+	return matches
+}
+
+func sameClass(a, b rune) bool {
+	switch {
+	case 'a' <= a && a <= 'z':
+		return 'a' <= b && b <= 'z'
+	case 'A' <= a && a <= 'Z':
+		return 'A' <= b && b <= 'Z'
+	case '0' <= a && a <= '9':
+		return '0' <= b && b <= '9'
+	}
+	return false
+}
+
+func sequenceGuesses(first rune, delta rune, length int) float64 {
+	var base float64
+	switch {
+	case first == 'a' || first == 'A' || first == 'z' || first == 'Z' || first == '0' || first == '1' || first == '9':
+		base = 4
+	case '0' <= first && first <= '9':
+		base = 10
+	default:
+		base = 26
+	}
+	if delta < 0 {
+		base *= 2
+	}
+	return base * float64(length)
+}
+
+// repeatMatches returns repetitions of units like "aaa" or "abcabc"
+func (e *estimator) repeatMatches(runes []rune) []Match {
+	var matches []Match
+	for start := range runes {
+		for unit := 1; start+2*unit <= len(runes); unit++ {
+			count := 1
+			for start+(count+1)*unit <= len(runes) &&
+				string(runes[start+count*unit:start+(count+1)*unit]) == string(runes[start:start+unit]) {
+				count++
+			}
+			if count < 2 {
+				continue
+			}
+
+			end := start + count*unit
+			matches = append(matches, Match{
+				Pattern: PatternRepeat,
+				Token:   string(runes[start:end]),
+				Guesses: e.unitGuesses(runes[start:start+unit]) * float64(count),
+				start:   start,
+				end:     end,
+			})
+		}
+	}
+	return matches
+}
+
+// unitGuesses returns the guesses of a repeated unit
+func (e *estimator) unitGuesses(unit []rune) float64 {
+	key := string(unit)
+	if guesses, ok := e.repeats[key]; ok {
+		return guesses
+	}
+	entropy, _ := e.mostGuessable(unit)
+	guesses := math.Pow(2, entropy)
+	e.repeats[key] = guesses
+	return guesses
+}
+
+// yearMatches returns years between 1900 and 2099
+func yearMatches(runes []rune) []Match {
+	reference := time.Now().Year()
+	var matches []Match
+	for start := 0; start+4 <= len(runes); start++ {
+		token := string(runes[start : start+4])
+		year, err := strconv.Atoi(token)
+		if err != nil || year < 1900 || year > 2099 || token[0] == '+' || token[0] == '-' {
+			continue
+		}
+		matches = append(matches, Match{
+			Pattern: PatternYear,
+			Token:   token,
+			Guesses: math.Max(math.Abs(float64(year-reference)), 20),
+			start:   start,
+			end:     start + 4,
+		})
+	}
+	return matches
+}
+
+func reverse(runes []rune) string {
+	reversed := make([]rune, len(runes))
+	for i, r := range runes {
+		reversed[len(runes)-1-i] = r
+	}
+	return string(reversed)
+}
@@ -0,0 +1,169 @@
+package gokeepasslib
+
+import (
+	"errors"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+const (
+	// RecycleBinName is the name of a newly created recycle bin group
+	RecycleBinName = "Recycle Bin"
+	// RecycleBinIconID is the standard trash bin icon used for the recycle bin group
+	RecycleBinIconID = 43
+)
+
+// ErrNotInRecycleBin is returned when restoring an object which is not in the recycle bin
+var ErrNotInRecycleBin = errors.New("gokeepasslib: object is not in the recycle bin")
+
+// RecycleBin returns the recycle bin group, or nil if the database does not have one
+func (db *Database) RecycleBin() *Group {
+	if db.Content == nil || db.Content.Meta == nil || db.Content.Meta.RecycleBinUUID == (UUID{}) {
+		return nil
+	}
+	return db.FindGroupByUUID(db.Content.Meta.RecycleBinUUID)
+}
+
+// EnsureRecycleBin returns the recycle bin group, creating it in the root group if it does not exist.
+// A new recycle bin is excluded from auto-type and searching like in KeePass.
+func (db *Database) EnsureRecycleBin() (*Group, error) {
+	if bin := db.RecycleBin(); bin != nil {
+		return bin, nil
+	}
+	if db.Content == nil || db.Content.Meta == nil || db.Content.Root == nil ||
+		len(db.Content.Root.Groups) == 0 {
+		return nil, ErrRequiredAttributeMissing("Content")
+	}
+
+	bin := NewGroup()
+	bin.Name = RecycleBinName
+	bin.IconID = RecycleBinIconID
+	bin.EnableAutoType = w.NewNullableBoolWrapper(false)
+	bin.EnableSearching = w.NewNullableBoolWrapper(false)
+	bin.Times = db.copiedTimeData(TimeData{})
+
+	group, err := db.AddGroup(db.Content.Root.Groups[0].UUID, bin)
+	if err != nil {
+		return nil, err
+	}
+
+	db.Content.Meta.RecycleBinUUID = group.UUID
+	db.Content.Meta.RecycleBinChanged = db.now()
+	return group, nil
+}
+
+// RecycleEntry moves the entry with the given uuid into the recycle bin.
+// The entry is deleted permanently if the recycle bin is disabled
+// or the entry is in the recycle bin already.
+func (db *Database) RecycleEntry(uuid UUID) error {
+	if db.FindEntryByUUID(uuid) == nil {
+		return ErrEntryNotFound
+	}
+	if !db.isRecycleBinEnabled() || db.isInRecycleBin(uuid) {
+		return db.DeleteEntry(uuid)
+	}
+
+	bin, err := db.EnsureRecycleBin()
+	if err != nil {
+		return err
+	}
+	_, err = db.MoveEntry(uuid, bin.UUID)
+	return err
+}
+
+// RecycleGroup moves the group with the given uuid including its entries and sub groups
+// into the recycle bin.
+// The group is deleted permanently if the recycle bin is disabled,
+// the group is in the recycle bin already or is the recycle bin itself.
+func (db *Database) RecycleGroup(uuid UUID) error {
+	if db.FindGroupByUUID(uuid) == nil {
+		return ErrGroupNotFound
+	}
+	if !db.isRecycleBinEnabled() || db.isInRecycleBin(uuid) ||
+		uuid.Compare(db.Content.Meta.RecycleBinUUID) {
+		return db.DeleteGroup(uuid)
+	}
+
+	bin, err := db.EnsureRecycleBin()
+	if err != nil {
+		return err
+	}
+	_, err = db.MoveGroup(uuid, bin.UUID)
+	return err
+}
+
+// RestoreEntry moves the entry with the given uuid out of the recycle bin
+// into its previous parent group, or into the root group if that does not exist anymore
+func (db *Database) RestoreEntry(uuid UUID) (*Entry, error) {
+	entry := db.FindEntryByUUID(uuid)
+	if entry == nil {
+		return nil, ErrEntryNotFound
+	}
+	if !db.isInRecycleBin(uuid) {
+		return nil, ErrNotInRecycleBin
+	}
+
+	return db.MoveEntry(uuid, db.restoreTarget(entry.PreviousParentGroup))
+}
+
+// RestoreGroup moves the group with the given uuid out of the recycle bin
+// into its previous parent group, or into the root group if that does not exist anymore
+func (db *Database) RestoreGroup(uuid UUID) (*Group, error) {
+	group := db.FindGroupByUUID(uuid)
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+	if uuid.Compare(db.Content.Meta.RecycleBinUUID) || !db.isInRecycleBin(uuid) {
+		return nil, ErrNotInRecycleBin
+	}
+
+	return db.MoveGroup(uuid, db.restoreTarget(group.PreviousParentGroup))
+}
+
+// EmptyRecycleBin deletes all entries and groups in the recycle bin permanently
+// and records their deletion in the deleted objects
+func (db *Database) EmptyRecycleBin() error {
+	bin := db.RecycleBin()
+	if bin == nil {
+		return nil
+	}
+
+	for len(bin.Groups) > 0 {
+		if err := db.DeleteGroup(bin.Groups[0].UUID); err != nil {
+			return err
+		}
+	}
+	for len(bin.Entries) > 0 {
+		if err := db.DeleteEntry(bin.Entries[0].UUID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Database) isRecycleBinEnabled() bool {
+	return db.Content != nil && db.Content.Meta != nil && db.Content.Meta.RecycleBinEnabled.Bool
+}
+
+// isInRecycleBin returns whether the group or entry with the given uuid is below the recycle bin
+func (db *Database) isInRecycleBin(uuid UUID) bool {
+	bin := db.RecycleBin()
+	if bin == nil || bin.UUID.Compare(uuid) {
+		return false
+	}
+	if entry, _, _ := findEntry([]Group{*bin}, uuid); entry != nil {
+		return true
+	}
+	group, _, _ := findGroup(bin.Groups, uuid)
+	return group != nil
+}
+
+// restoreTarget returns the UUID of the group to restore an object with the given
+// previous parent group into
+func (db *Database) restoreTarget(previousParent *UUID) UUID {
+	if previousParent != nil && db.FindGroupByUUID(*previousParent) != nil &&
+		!previousParent.Compare(db.Content.Meta.RecycleBinUUID) && !db.isInRecycleBin(*previousParent) {
+		return *previousParent
+	}
+	return db.Content.Root.Groups[0].UUID
+}
@@ -0,0 +1,166 @@
+package gokeepasslib
+
+import (
+	"errors"
+	"testing"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+func newRecycleBinDatabase(enabled bool) *Database {
+	db := newMergeDatabase()
+	db.Content.Meta.RecycleBinEnabled = w.NewBoolWrapper(enabled)
+	return db
+}
+
+func TestDatabase_RecycleEntry(t *testing.T) {
+	db := newRecycleBinDatabase(true)
+
+	if db.RecycleBin() != nil {
+		t.Fatalf("Expected no recycle bin before deleting")
+	}
+
+	if err := db.RecycleEntry(mergeEntryUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	bin := db.RecycleBin()
+	if bin == nil {
+		t.Fatalf("Expected the recycle bin to be created")
+	}
+	if bin.Name != RecycleBinName || bin.IconID != RecycleBinIconID ||
+		bin.EnableSearching.Bool || db.Content.Meta.RecycleBinChanged == nil {
+		t.Errorf("Expected a KeePass like recycle bin, received %+v", bin)
+	}
+	if db.FindParentGroup(bin.UUID).UUID != mergeRootUUID {
+		t.Errorf("Expected the recycle bin in the root group")
+	}
+
+	entry := db.FindEntryByUUID(mergeEntryUUID)
+	if db.FindParentGroup(mergeEntryUUID).UUID != bin.UUID {
+		t.Errorf("Expected the entry to be in the recycle bin")
+	}
+	if entry.PreviousParentGroup == nil || *entry.PreviousParentGroup != mergeGroupUUID {
+		t.Errorf("Expected the previous parent group to be recorded, received %v", entry.PreviousParentGroup)
+	}
+	if len(db.Content.Root.DeletedObjects) != 0 {
+		t.Errorf("Expected no deleted objects for a recycled entry")
+	}
+
+	// Deleting from the recycle bin deletes permanently
+	if err := db.RecycleEntry(mergeEntryUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.FindEntryByUUID(mergeEntryUUID) != nil || len(db.Content.Root.DeletedObjects) != 1 {
+		t.Errorf("Expected the entry to be deleted permanently")
+	}
+}
+
+func TestDatabase_RecycleRecycleBin(t *testing.T) {
+	db := newRecycleBinDatabase(true)
+
+	if err := db.RecycleEntry(mergeEntryUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	bin := db.RecycleBin()
+
+	// Recycling the recycle bin deletes it permanently including its content
+	if err := db.RecycleGroup(bin.UUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.RecycleBin() != nil || db.FindEntryByUUID(mergeEntryUUID) != nil {
+		t.Errorf("Expected the recycle bin to be deleted")
+	}
+	if len(db.Content.Root.DeletedObjects) != 2 {
+		t.Errorf("Expected deleted objects for the recycle bin and its entry, received %+v", db.Content.Root.DeletedObjects)
+	}
+}
+
+func TestDatabase_RecycleDisabled(t *testing.T) {
+	db := newRecycleBinDatabase(false)
+
+	if err := db.RecycleEntry(mergeEntryUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.RecycleBin() != nil || db.FindEntryByUUID(mergeEntryUUID) != nil {
+		t.Errorf("Expected the entry to be deleted without a recycle bin")
+	}
+	if len(db.Content.Root.DeletedObjects) != 1 {
+		t.Errorf("Expected a deleted object for the entry")
+	}
+}
+
+func TestDatabase_RestoreFromRecycleBin(t *testing.T) {
+	db := newRecycleBinDatabase(true)
+
+	if err := db.RecycleEntry(mergeEntryUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if err := db.RecycleGroup(mergeOtherUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	entry, err := db.RestoreEntry(mergeEntryUUID)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.FindParentGroup(entry.UUID).UUID != mergeGroupUUID {
+		t.Errorf("Expected the entry to be restored into its original group")
+	}
+
+	group, err := db.RestoreGroup(mergeOtherUUID)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.FindParentGroup(group.UUID).UUID != mergeRootUUID {
+		t.Errorf("Expected the group to be restored into the root group")
+	}
+
+	if _, err := db.RestoreEntry(mergeEntryUUID); !errors.Is(err, ErrNotInRecycleBin) {
+		t.Errorf("Expected ErrNotInRecycleBin, received %v", err)
+	}
+}
+
+func TestDatabase_RestoreIntoRootGroup(t *testing.T) {
+	db := newRecycleBinDatabase(true)
+
+	if err := db.RecycleEntry(mergeEntryUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if err := db.DeleteGroup(mergeGroupUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	if _, err := db.RestoreEntry(mergeEntryUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.FindParentGroup(mergeEntryUUID).UUID != mergeRootUUID {
+		t.Errorf("Expected the entry to be restored into the root group")
+	}
+}
+
+func TestDatabase_EmptyRecycleBin(t *testing.T) {
+	db := newRecycleBinDatabase(true)
+
+	if err := db.EmptyRecycleBin(); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	if err := db.RecycleGroup(mergeGroupUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if err := db.EmptyRecycleBin(); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	bin := db.RecycleBin()
+	if bin == nil || len(bin.Groups) != 0 || len(bin.Entries) != 0 {
+		t.Errorf("Expected an empty recycle bin, received %+v", bin)
+	}
+	if db.FindEntryByUUID(mergeEntryUUID) != nil {
+		t.Errorf("Expected the entry to be deleted")
+	}
+	if len(db.Content.Root.DeletedObjects) != 2 {
+		t.Errorf("Expected deleted objects for the group and its entry, received %+v", db.Content.Root.DeletedObjects)
+	}
+}
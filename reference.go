@@ -0,0 +1,228 @@
+package gokeepasslib
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxReferenceDepth is the maximum number of nested field references which are resolved
+const MaxReferenceDepth = 12
+
+const referencePrefix = "{REF:"
+
+// ReferenceField identifies an entry field in a field reference
+type ReferenceField byte
+
+// Reference fields as used by KeePass in `{REF:<field>@<search field>:<text>}`
+const (
+	ReferenceTitle    ReferenceField = 'T'
+	ReferenceUserName ReferenceField = 'U'
+	ReferencePassword ReferenceField = 'P'
+	ReferenceURL      ReferenceField = 'A'
+	ReferenceNotes    ReferenceField = 'N'
+	ReferenceUUID     ReferenceField = 'I'
+	ReferenceOther    ReferenceField = 'O' // Custom string fields, only valid as search field
+)
+
+var referenceFieldKeys = map[ReferenceField]string{
+	ReferenceTitle:    "Title",
+	ReferenceUserName: "UserName",
+	ReferencePassword: "Password",
+	ReferenceURL:      "URL",
+	ReferenceNotes:    "Notes",
+}
+
+// Errors returned when resolving field references, wrapped in a ReferenceError
+var (
+	ErrInvalidReference       = errors.New("gokeepasslib: invalid field reference")
+	ErrReferenceNotFound      = errors.New("gokeepasslib: referenced entry not found")
+	ErrReferenceCycle         = errors.New("gokeepasslib: field references form a cycle")
+	ErrReferenceDepthExceeded = errors.New("gokeepasslib: field references are nested too deep")
+)
+
+// ReferenceError is returned if a field reference can not be resolved
+type ReferenceError struct {
+	Reference string
+	Err       error
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, e.Reference)
+}
+
+func (e *ReferenceError) Unwrap() error {
+	return e.Err
+}
+
+// NewFieldReference returns a reference to the field of the entry with the given uuid,
+// like `{REF:P@I:46C9B1FFBD4ABC4BBB260C6190BAD20C}`
+func NewFieldReference(field ReferenceField, uuid UUID) string {
+	return fmt.Sprintf(
+		"%s%c@%c:%s}",
+		referencePrefix,
+		field,
+		ReferenceUUID,
+		strings.ToUpper(hex.EncodeToString(uuid[:])),
+	)
+}
+
+// NewReferenceValue returns a value with the given key referencing the field of the entry
+// with the given uuid, e.g. to share a password between entries
+func NewReferenceValue(key string, field ReferenceField, uuid UUID) ValueData {
+	return ValueData{
+		Key:   key,
+		Value: V{Content: NewFieldReference(field, uuid)},
+	}
+}
+
+// ResolveReferences replaces all field references in value by the content of the referenced fields.
+// Referenced fields containing references themselves are resolved recursively.
+// The protected values of the database have to be unlocked.
+func (db *Database) ResolveReferences(value string) (string, error) {
+	r := &referenceResolver{db: db}
+	return r.resolve(value)
+}
+
+// GetResolvedContent returns the content of the value of e with the given key
+// with all field references resolved
+func (db *Database) GetResolvedContent(e *Entry, key string) (string, error) {
+	return db.ResolveReferences(e.GetContent(key))
+}
+
+// GetResolvedPassword returns the password of e with all field references resolved
+func (db *Database) GetResolvedPassword(e *Entry) (string, error) {
+	return db.GetResolvedContent(e, "Password")
+}
+
+// referenceKey identifies a referenced field while resolving, to detect cycles
+type referenceKey struct {
+	uuid  UUID
+	field ReferenceField
+}
+
+type referenceResolver struct {
+	db    *Database
+	stack []referenceKey
+}
+
+func (r *referenceResolver) resolve(value string) (string, error) {
+	var result strings.Builder
+	for {
+		start := indexReference(value)
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+
+		resolved, err := r.resolveReference(value[start:end])
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(value[:start])
+		result.WriteString(resolved)
+		value = value[end:]
+	}
+	result.WriteString(value)
+	return result.String(), nil
+}
+
+// indexReference returns the index of the first reference prefix in value, ignoring its case
+func indexReference(value string) int {
+	for i := 0; i+len(referencePrefix) <= len(value); i++ {
+		if strings.EqualFold(value[i:i+len(referencePrefix)], referencePrefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *referenceResolver) resolveReference(reference string) (string, error) {
+	inner := reference[len(referencePrefix) : len(reference)-1]
+	if len(inner) < 4 || inner[1] != '@' || inner[3] != ':' {
+		return "", &ReferenceError{Reference: reference, Err: ErrInvalidReference}
+	}
+
+	wanted := ReferenceField(strings.ToUpper(inner[:1])[0])
+	searchIn := ReferenceField(strings.ToUpper(inner[2:3])[0])
+	if _, ok := referenceFieldKeys[wanted]; !ok && wanted != ReferenceUUID {
+		return "", &ReferenceError{Reference: reference, Err: ErrInvalidReference}
+	}
+
+	entry, err := r.findEntry(searchIn, inner[4:])
+	if err != nil {
+		return "", &ReferenceError{Reference: reference, Err: err}
+	}
+
+	if wanted == ReferenceUUID {
+		return strings.ToUpper(hex.EncodeToString(entry.UUID[:])), nil
+	}
+
+	key := referenceKey{uuid: entry.UUID, field: wanted}
+	for _, visited := range r.stack {
+		if visited == key {
+			return "", &ReferenceError{Reference: reference, Err: ErrReferenceCycle}
+		}
+	}
+	if len(r.stack) >= MaxReferenceDepth {
+		return "", &ReferenceError{Reference: reference, Err: ErrReferenceDepthExceeded}
+	}
+
+	r.stack = append(r.stack, key)
+	defer func() {
+		r.stack = r.stack[:len(r.stack)-1]
+	}()
+
+	return r.resolve(entry.GetContent(referenceFieldKeys[wanted]))
+}
+
+// findEntry returns the first entry whose search field contains text ignoring the case,
+// or whose UUID matches the hex encoded text
+func (r *referenceResolver) findEntry(searchIn ReferenceField, text string) (*Entry, error) {
+	if r.db.Content == nil || r.db.Content.Root == nil {
+		return nil, ErrReferenceNotFound
+	}
+
+	if searchIn == ReferenceUUID {
+		id, err := hex.DecodeString(text)
+		if err != nil || len(id) != len(UUID{}) {
+			return nil, ErrInvalidReference
+		}
+		entry := r.db.FindEntryByUUID(UUID(id))
+		if entry == nil {
+			return nil, ErrReferenceNotFound
+		}
+		return entry, nil
+	}
+
+	fields := SearchCustomFields
+	if searchIn != ReferenceOther {
+		key, ok := referenceFieldKeys[searchIn]
+		if !ok {
+			return nil, ErrInvalidReference
+		}
+		fields = standardFields[key]
+	}
+
+	s := searcher{
+		opts: SearchOptions{
+			Fields:            fields,
+			IncludeRecycleBin: true,
+			IgnoreSearchFlags: true,
+		},
+	}
+	s.match, _ = newSearchMatcher(text, s.opts)
+	for i := range r.db.Content.Root.Groups {
+		s.searchGroup(&r.db.Content.Root.Groups[i], true)
+	}
+
+	if len(s.results) == 0 {
+		return nil, ErrReferenceNotFound
+	}
+	return s.results[0], nil
+}
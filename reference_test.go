@@ -0,0 +1,197 @@
+package gokeepasslib
+
+import (
+	"errors"
+	"testing"
+)
+
+func newReferenceDatabase() *Database {
+	db := newMergeDatabase()
+	entry := db.FindEntryByUUID(mergeEntryUUID)
+	entry.Values = append(
+		entry.Values,
+		ValueData{Key: "UserName", Value: V{Content: "user"}},
+		ValueData{Key: "Password", Value: V{Content: "secret"}},
+		ValueData{Key: "URL", Value: V{Content: "https://example.com"}},
+		ValueData{Key: "Notes", Value: V{Content: "some notes"}},
+		ValueData{Key: "Custom", Value: V{Content: "custom value"}},
+	)
+	return db
+}
+
+func addReferenceEntry(db *Database, uuid UUID, title string, values ...ValueData) {
+	entry := newMergeEntry(uuid, title, 0)
+	entry.Values = append(entry.Values, values...)
+	group := &db.Content.Root.Groups[0].Groups[1]
+	group.Entries = append(group.Entries, entry)
+}
+
+func TestNewFieldReference(t *testing.T) {
+	uuid := UUID{0x46, 0xc9, 0xb1, 0xff, 0xbd, 0x4a, 0xbc, 0x4b, 0xbb, 0x26, 0x0c, 0x61, 0x90, 0xba, 0xd2, 0x0c}
+
+	reference := NewFieldReference(ReferencePassword, uuid)
+	if reference != "{REF:P@I:46C9B1FFBD4ABC4BBB260C6190BAD20C}" {
+		t.Errorf("Received unexpected reference %s", reference)
+	}
+
+	value := NewReferenceValue("Password", ReferenceUserName, uuid)
+	if value.Key != "Password" || value.Value.Content != "{REF:U@I:46C9B1FFBD4ABC4BBB260C6190BAD20C}" {
+		t.Errorf("Received unexpected reference value %+v", value)
+	}
+}
+
+func TestDatabase_ResolveReferences(t *testing.T) {
+	db := newReferenceDatabase()
+
+	cases := []struct {
+		title    string
+		value    string
+		expected string
+	}{
+		{
+			title:    "without reference",
+			value:    "plain",
+			expected: "plain",
+		},
+		{
+			title:    "password by uuid",
+			value:    NewFieldReference(ReferencePassword, mergeEntryUUID),
+			expected: "secret",
+		},
+		{
+			title:    "all fields",
+			value:    "{REF:T@I:04000000000000000000000000000000}|{REF:U@I:04000000000000000000000000000000}|{REF:A@I:04000000000000000000000000000000}|{REF:N@I:04000000000000000000000000000000}|{REF:I@I:04000000000000000000000000000000}",
+			expected: "entry|user|https://example.com|some notes|04000000000000000000000000000000",
+		},
+		{
+			title:    "lower case with surrounding text",
+			value:    "pre-{ref:p@i:04000000000000000000000000000000}-post",
+			expected: "pre-secret-post",
+		},
+		{
+			title:    "search by title",
+			value:    "{REF:U@T:ENTR}",
+			expected: "user",
+		},
+		{
+			title:    "search by custom field",
+			value:    "{REF:P@O:custom value}",
+			expected: "secret",
+		},
+		{
+			title:    "unterminated reference",
+			value:    "{REF:P@I:04",
+			expected: "{REF:P@I:04",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			resolved, err := db.ResolveReferences(c.value)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if resolved != c.expected {
+				t.Errorf("Expected %s, received %s", c.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestDatabase_GetResolvedPassword(t *testing.T) {
+	db := newReferenceDatabase()
+	addReferenceEntry(db, mergeNewUUID, "shared",
+		NewReferenceValue("Password", ReferencePassword, mergeEntryUUID),
+	)
+	nestedUUID := UUID{0x06}
+	addReferenceEntry(db, nestedUUID, "nested",
+		NewReferenceValue("Password", ReferencePassword, mergeNewUUID),
+	)
+
+	nested := db.FindEntryByUUID(nestedUUID)
+	if nested.GetPassword() != NewFieldReference(ReferencePassword, mergeNewUUID) {
+		t.Errorf("Expected the unresolved reference, received %s", nested.GetPassword())
+	}
+
+	password, err := db.GetResolvedPassword(nested)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if password != "secret" {
+		t.Errorf("Expected the nested reference to be resolved, received %s", password)
+	}
+}
+
+func TestDatabase_ResolveReferencesErrors(t *testing.T) {
+	cycleUUID := UUID{0x06}
+	cases := []struct {
+		title       string
+		value       string
+		prepare     func(db *Database)
+		expectedErr error
+	}{
+		{
+			title:       "invalid field",
+			value:       "{REF:X@I:04000000000000000000000000000000}",
+			expectedErr: ErrInvalidReference,
+		},
+		{
+			title:       "invalid search field",
+			value:       "{REF:P@X:entry}",
+			expectedErr: ErrInvalidReference,
+		},
+		{
+			title:       "invalid uuid",
+			value:       "{REF:P@I:0400}",
+			expectedErr: ErrInvalidReference,
+		},
+		{
+			title:       "not found",
+			value:       NewFieldReference(ReferencePassword, NewUUID()),
+			expectedErr: ErrReferenceNotFound,
+		},
+		{
+			title: "cycle",
+			value: NewFieldReference(ReferencePassword, mergeNewUUID),
+			prepare: func(db *Database) {
+				addReferenceEntry(db, mergeNewUUID, "first",
+					NewReferenceValue("Password", ReferencePassword, cycleUUID),
+				)
+				addReferenceEntry(db, cycleUUID, "second",
+					NewReferenceValue("Password", ReferencePassword, mergeNewUUID),
+				)
+			},
+			expectedErr: ErrReferenceCycle,
+		},
+		{
+			title: "depth exceeded",
+			value: NewFieldReference(ReferencePassword, UUID{0x10}),
+			prepare: func(db *Database) {
+				for i := 0; i <= MaxReferenceDepth; i++ {
+					addReferenceEntry(db, UUID{0x10 + byte(i)}, "chain",
+						NewReferenceValue("Password", ReferencePassword, UUID{0x11 + byte(i)}),
+					)
+				}
+			},
+			expectedErr: ErrReferenceDepthExceeded,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := newReferenceDatabase()
+			if c.prepare != nil {
+				c.prepare(db)
+			}
+
+			_, err := db.ResolveReferences(c.value)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("Expected %v, received %v", c.expectedErr, err)
+			}
+			var referenceErr *ReferenceError
+			if !errors.As(err, &referenceErr) || referenceErr.Reference == "" {
+				t.Errorf("Expected a ReferenceError naming the reference, received %v", err)
+			}
+		})
+	}
+}
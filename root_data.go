@@ -1,5 +1,9 @@
 package gokeepasslib
 
+import (
+	"encoding/xml"
+)
+
 type RootDataOption func(*RootData)
 
 func WithRootDataFormattedTime(formatted bool) RootDataOption {
@@ -17,6 +21,27 @@ func WithRootDataFormattedTime(formatted bool) RootDataOption {
 type RootData struct {
 	Groups         []Group             `xml:"Group"`
 	DeletedObjects []DeletedObjectData `xml:"DeletedObjects>DeletedObject"`
+	unknownXML     unknownXMLData      `xml:"-"`
+}
+
+// MarshalXML marshals the root data into e, including elements unknown to the library
+func (rd *RootData) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type rootData RootData
+
+	return marshalXMLWithUnknown(e, start, (*rootData)(rd), rd.unknownXML)
+}
+
+// UnmarshalXML unmarshals the root data from d, keeping elements unknown to the library
+func (rd *RootData) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type rootData RootData
+
+	unknownXML, err := unmarshalXMLWithUnknown(d, start, (*rootData)(rd))
+	if err != nil {
+		return err
+	}
+
+	rd.unknownXML = unknownXML
+	return nil
 }
 
 // NewRootData returns a RootData struct with good defaults
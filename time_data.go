@@ -64,3 +64,22 @@ func NewTimeData(options ...TimeDataOption) TimeData {
 
 	return td
 }
+
+// clone returns a copy of td which does not share the time values with td
+func (td TimeData) clone() TimeData {
+	clone := td
+	clone.CreationTime = cloneTimeWrapper(td.CreationTime)
+	clone.LastModificationTime = cloneTimeWrapper(td.LastModificationTime)
+	clone.LastAccessTime = cloneTimeWrapper(td.LastAccessTime)
+	clone.ExpiryTime = cloneTimeWrapper(td.ExpiryTime)
+	clone.LocationChanged = cloneTimeWrapper(td.LocationChanged)
+	return clone
+}
+
+func cloneTimeWrapper(t *w.TimeWrapper) *w.TimeWrapper {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	return &clone
+}
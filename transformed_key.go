@@ -0,0 +1,105 @@
+package gokeepasslib
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+)
+
+// transformedKeyCache keeps a transformed key masked with a random pad, together with the hash
+// of the composite key and key derivation parameters it has been derived from
+type transformedKeyCache struct {
+	masked []byte
+	pad    []byte
+	source [sha256.Size]byte
+}
+
+func newTransformedKeyCache(key []byte, source [sha256.Size]byte) *transformedKeyCache {
+	c := &transformedKeyCache{
+		masked: make([]byte, len(key)),
+		pad:    make([]byte, len(key)),
+		source: source,
+	}
+	rand.Read(c.pad)
+	subtle.XORBytes(c.masked, key, c.pad)
+	return c
+}
+
+// get returns the cached key if it has been derived from source, or nil
+func (c *transformedKeyCache) get(source [sha256.Size]byte) []byte {
+	if c == nil || subtle.ConstantTimeCompare(c.source[:], source[:]) == 0 {
+		return nil
+	}
+	key := make([]byte, len(c.masked))
+	subtle.XORBytes(key, c.masked, c.pad)
+	return key
+}
+
+// wipe overwrites the cached key
+func (c *transformedKeyCache) wipe() {
+	if c == nil {
+		return
+	}
+	clear(c.masked)
+	clear(c.pad)
+	clear(c.source[:])
+}
+
+// Wipe clears the transformed key which is kept after decoding and encoding,
+// so that the next Encode derives it from the credentials again
+func (db *Database) Wipe() {
+	db.transformedKey.wipe()
+	db.transformedKey = nil
+}
+
+// transformedKeySource returns the hash of everything the transformed key is derived from:
+// the composite key of the credentials and the key derivation parameters
+func (db *Database) transformedKeySource() ([sha256.Size]byte, error) {
+	var source [sha256.Size]byte
+
+	compositeKey, err := db.Credentials.buildCompositeKey()
+	if err != nil {
+		return source, err
+	}
+	defer clear(compositeKey)
+
+	h := sha256.New()
+	writeSourceField(h, compositeKey)
+	fh := db.Header.FileHeaders
+	if db.Header.IsKdbx4() && fh.KdfParameters != nil {
+		k := fh.KdfParameters
+		writeSourceField(h, []byte{0x04})
+		writeSourceField(h, k.UUID)
+		writeSourceField(h, k.Salt[:])
+		binary.Write(h, binary.LittleEndian, []uint64{k.Rounds, k.Memory, k.Iterations})
+		binary.Write(h, binary.LittleEndian, []uint32{k.Parallelism, k.Version})
+		writeSourceField(h, k.SecretKey)
+		writeSourceField(h, k.AssocData)
+	} else {
+		writeSourceField(h, []byte{0x03})
+		writeSourceField(h, fh.TransformSeed)
+		binary.Write(h, binary.LittleEndian, fh.TransformRounds)
+	}
+	h.Sum(source[:0])
+	return source, nil
+}
+
+// writeSourceField writes data with its length, so that adjacent fields can not be confused
+func writeSourceField(h hash.Hash, data []byte) {
+	binary.Write(h, binary.LittleEndian, uint64(len(data)))
+	h.Write(data)
+}
+
+// kdfProgressTotal returns the rounds or iterations the key derivation is reported with
+func (h *DBHeader) kdfProgressTotal() uint64 {
+	k := h.FileHeaders.KdfParameters
+	switch {
+	case !h.IsKdbx4() || k == nil:
+		return h.FileHeaders.TransformRounds
+	case k.IsArgon2():
+		return k.Iterations
+	}
+	return k.Rounds
+}
@@ -0,0 +1,137 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestTransformedKeyCache(t *testing.T) {
+	cases := []struct {
+		title    string
+		change   func(db *Database)
+		expected bool
+	}{
+		{
+			title:    "unchanged",
+			change:   func(db *Database) {},
+			expected: true,
+		},
+		{
+			title:    "renewed seeds",
+			change:   func(db *Database) { db.renewSeeds(nil) },
+			expected: true,
+		},
+		{
+			title:  "changed credentials",
+			change: func(db *Database) { db.Credentials = NewPasswordCredentials("new password") },
+		},
+		{
+			title:  "changed kdf iterations",
+			change: func(db *Database) { db.Header.FileHeaders.KdfParameters.Iterations++ },
+		},
+		{
+			title:  "changed kdf salt",
+			change: func(db *Database) { db.Header.FileHeaders.KdfParameters.Salt[0]++ },
+		},
+		{
+			title:  "wiped",
+			change: func(db *Database) { db.Wipe() },
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := decodeTestFile(t, "tests/kdbx4/example.kdbx", NewPasswordCredentials("abcdefg12345678"))
+			c.change(db)
+
+			source, err := db.transformedKeySource()
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			key := db.transformedKey.get(source)
+			if (key != nil) != c.expected {
+				t.Fatalf("Expected the cached key to be used: %t, received %x", c.expected, key)
+			}
+			if key == nil {
+				return
+			}
+
+			derived, err := db.Credentials.buildTransformedKey(t.Context(), db, nil)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if !bytes.Equal(key, derived) {
+				t.Errorf("Expected the cached key %x to match the derived key %x", key, derived)
+			}
+			if bytes.Equal(db.transformedKey.masked, key) {
+				t.Errorf("Expected the cached key to be masked")
+			}
+		})
+	}
+}
+
+func TestEncodeReusesTransformedKey(t *testing.T) {
+	db := decodeTestFile(t, "tests/kdbx4/example.kdbx", NewPasswordCredentials("abcdefg12345678"))
+
+	// A derivation is reported when it starts and completes, a reused key only as completed
+	var kdfReports int
+	progress := func(p Progress) {
+		if p.Phase == ProgressKdf {
+			kdfReports++
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := NewEncoder(&buffer, WithEncoderProgress(progress)).Encode(db); err != nil {
+		t.Fatalf("Failed to encode file: %s", err)
+	}
+	if kdfReports != 1 {
+		t.Errorf("Expected the transformed key to be reused, received %d reports", kdfReports)
+	}
+
+	decoded := NewDatabase()
+	decoded.Credentials = NewPasswordCredentials("abcdefg12345678")
+	if err := NewDecoder(bytes.NewReader(buffer.Bytes())).Decode(decoded); err != nil {
+		t.Fatalf("Failed to decode file: %s", err)
+	}
+
+	db.Credentials = NewPasswordCredentials("new password")
+	kdfReports = 0
+	if err := NewEncoder(&buffer, WithEncoderProgress(progress)).Encode(db); err != nil {
+		t.Fatalf("Failed to encode file: %s", err)
+	}
+	if kdfReports != 2 {
+		t.Errorf("Expected the transformed key to be derived, received %d reports", kdfReports)
+	}
+}
+
+func TestDatabase_Wipe(t *testing.T) {
+	db := decodeTestFile(t, "tests/kdbx3/example.kdbx", NewPasswordCredentials("abcdefg12345678"))
+	cache := db.transformedKey
+	if cache == nil {
+		t.Fatalf("Expected the transformed key to be kept after decoding")
+	}
+
+	db.Wipe()
+	if db.transformedKey != nil {
+		t.Errorf("Expected the transformed key to be removed")
+	}
+	if !bytes.Equal(cache.masked, make([]byte, len(cache.masked))) || !bytes.Equal(cache.pad, make([]byte, len(cache.pad))) {
+		t.Errorf("Expected the transformed key to be overwritten")
+	}
+
+	// A failed decode keeps no key
+	file, err := os.Open("tests/kdbx3/example.kdbx")
+	if err != nil {
+		t.Fatalf("Failed to open keepass file: %s", err)
+	}
+	defer file.Close()
+	db.Credentials = NewPasswordCredentials("wrong")
+	if err := NewDecoder(file).Decode(db); err == nil {
+		t.Fatalf("Expected the wrong credentials to be rejected")
+	}
+	if db.transformedKey != nil {
+		t.Errorf("Expected no transformed key to be kept after a failed decode")
+	}
+}
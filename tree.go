@@ -0,0 +1,288 @@
+package gokeepasslib
+
+import (
+	"errors"
+	"slices"
+
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// ErrGroupNotFound is returned if a group with the given UUID does not exist
+var ErrGroupNotFound = errors.New("gokeepasslib: group not found")
+
+// ErrEntryNotFound is returned if an entry with the given UUID does not exist
+var ErrEntryNotFound = errors.New("gokeepasslib: entry not found")
+
+// ErrDuplicateUUID is returned when adding a group or entry whose UUID exists already
+var ErrDuplicateUUID = errors.New("gokeepasslib: UUID exists already")
+
+// ErrInvalidMove is returned when moving a group into itself or one of its sub groups,
+// or when moving or deleting a root group
+var ErrInvalidMove = errors.New("gokeepasslib: group can not be moved there")
+
+// The operations below modify the slices of the group tree,
+// so pointers returned by previous lookups or operations may become invalid.
+
+// AddEntry adds entry to the group with the UUID parent and returns a pointer to it.
+// The LocationChanged time of the entry and the LastModificationTime of the group
+// are set to the current time.
+func (db *Database) AddEntry(parent UUID, entry Entry) (*Entry, error) {
+	target := db.FindGroupByUUID(parent)
+	if target == nil {
+		return nil, ErrGroupNotFound
+	}
+	if db.FindEntryByUUID(entry.UUID) != nil {
+		return nil, ErrDuplicateUUID
+	}
+
+	entry.Times.LocationChanged = db.now()
+	db.touchModification(target)
+	target.Entries = append(target.Entries, entry)
+	return &target.Entries[len(target.Entries)-1], nil
+}
+
+// AddGroup adds group to the group with the UUID parent and returns a pointer to it.
+// The LocationChanged time of the group and the LastModificationTime of the parent group
+// are set to the current time.
+func (db *Database) AddGroup(parent UUID, group Group) (*Group, error) {
+	target := db.FindGroupByUUID(parent)
+	if target == nil {
+		return nil, ErrGroupNotFound
+	}
+	if db.hasUUID(group) {
+		return nil, ErrDuplicateUUID
+	}
+
+	group.Times.LocationChanged = db.now()
+	db.touchModification(target)
+	target.Groups = append(target.Groups, group)
+	return &target.Groups[len(target.Groups)-1], nil
+}
+
+// MoveEntry moves the entry with the given uuid to the group with the UUID target.
+// It updates the LocationChanged, LastModificationTime and LastAccessTime of the entry
+// and stores the previous parent group in PreviousParentGroup.
+// The LastModificationTime of the previous and the new parent group is set to the current time.
+func (db *Database) MoveEntry(uuid UUID, target UUID) (*Entry, error) {
+	if db.FindGroupByUUID(target) == nil {
+		return nil, ErrGroupNotFound
+	}
+
+	entry, parent, index := db.findEntry(uuid)
+	if entry == nil {
+		return nil, ErrEntryNotFound
+	}
+	if parent.UUID.Compare(target) {
+		return entry, nil
+	}
+
+	moved := *entry
+	previousParent := parent.UUID
+	moved.PreviousParentGroup = &previousParent
+	db.touchLocation(&moved.Times)
+	db.touchModification(parent)
+	parent.Entries = slices.Delete(parent.Entries, index, index+1)
+
+	return db.AddEntry(target, moved)
+}
+
+// MoveGroup moves the group with the given uuid to the group with the UUID target.
+// It updates the LocationChanged, LastModificationTime and LastAccessTime of the group
+// and stores the previous parent group in PreviousParentGroup.
+// The LastModificationTime of the previous and the new parent group is set to the current time.
+func (db *Database) MoveGroup(uuid UUID, target UUID) (*Group, error) {
+	group, parent, index := db.findGroup(uuid)
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+	if db.FindGroupByUUID(target) == nil {
+		return nil, ErrGroupNotFound
+	}
+	if parent == nil || group.UUID.Compare(target) {
+		return nil, ErrInvalidMove
+	}
+	if sub, _, _ := findGroup(group.Groups, target); sub != nil {
+		return nil, ErrInvalidMove
+	}
+	if parent.UUID.Compare(target) {
+		return group, nil
+	}
+
+	moved := *group
+	previousParent := parent.UUID
+	moved.PreviousParentGroup = &previousParent
+	db.touchLocation(&moved.Times)
+	db.touchModification(parent)
+	parent.Groups = slices.Delete(parent.Groups, index, index+1)
+
+	// The group has been removed, so its UUIDs are not part of the tree for AddGroup
+	return db.AddGroup(target, moved)
+}
+
+// DeleteEntry removes the entry with the given uuid from the database
+// and records its deletion in the deleted objects, so that it is not restored by synchronization.
+// The LastModificationTime of its parent group is set to the current time.
+func (db *Database) DeleteEntry(uuid UUID) error {
+	entry, parent, index := db.findEntry(uuid)
+	if entry == nil {
+		return ErrEntryNotFound
+	}
+
+	db.addDeletedObject(entry.UUID)
+	db.touchModification(parent)
+	parent.Entries = slices.Delete(parent.Entries, index, index+1)
+	return nil
+}
+
+// DeleteGroup removes the group with the given uuid including its entries and sub groups
+// from the database and records their deletion in the deleted objects.
+// The LastModificationTime of its parent group is set to the current time.
+func (db *Database) DeleteGroup(uuid UUID) error {
+	group, parent, index := db.findGroup(uuid)
+	if group == nil {
+		return ErrGroupNotFound
+	}
+	if parent == nil {
+		return ErrInvalidMove
+	}
+
+	db.addDeletedGroup(group)
+	db.touchModification(parent)
+	parent.Groups = slices.Delete(parent.Groups, index, index+1)
+	return nil
+}
+
+// CopyEntry adds a copy of the entry with the given uuid with a new UUID
+// to the group with the UUID target.
+// All times of the copy except for the expiry time are set to the current time.
+func (db *Database) CopyEntry(uuid UUID, target UUID) (*Entry, error) {
+	entry := db.FindEntryByUUID(uuid)
+	if entry == nil {
+		return nil, ErrEntryNotFound
+	}
+
+	clone := entry.Clone()
+	db.prepareCopiedEntry(&clone)
+	return db.AddEntry(target, clone)
+}
+
+// CopyGroup adds a copy of the group with the given uuid including its entries and sub groups
+// to the group with the UUID target. The copies receive new UUIDs.
+// All times of the copies except for the expiry times are set to the current time.
+func (db *Database) CopyGroup(uuid UUID, target UUID) (*Group, error) {
+	group := db.FindGroupByUUID(uuid)
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+
+	clone := group.Clone()
+	db.prepareCopiedGroup(&clone)
+	return db.AddGroup(target, clone)
+}
+
+// now returns the current time, formatted for the format version of the database
+func (db *Database) now() *w.TimeWrapper {
+	now := w.Now()
+	if db.Header != nil && db.Header.IsKdbx4() {
+		now.Formatted = false
+	}
+	return &now
+}
+
+func (db *Database) findGroup(uuid UUID) (*Group, *Group, int) {
+	if db.Content == nil || db.Content.Root == nil {
+		return nil, nil, -1
+	}
+	return findGroup(db.Content.Root.Groups, uuid)
+}
+
+func (db *Database) findEntry(uuid UUID) (*Entry, *Group, int) {
+	if db.Content == nil || db.Content.Root == nil {
+		return nil, nil, -1
+	}
+	return findEntry(db.Content.Root.Groups, uuid)
+}
+
+// hasUUID returns whether the UUID of g or any of its entries or sub groups
+// exists in the database already
+func (db *Database) hasUUID(g Group) bool {
+	if db.FindGroupByUUID(g.UUID) != nil {
+		return true
+	}
+	for _, entry := range g.Entries {
+		if db.FindEntryByUUID(entry.UUID) != nil {
+			return true
+		}
+	}
+	for _, group := range g.Groups {
+		if db.hasUUID(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// touchLocation sets the location change, modification and access time to the current time
+func (db *Database) touchLocation(td *TimeData) {
+	td.LocationChanged = db.now()
+	td.LastModificationTime = db.now()
+	td.LastAccessTime = db.now()
+}
+
+// touchModification sets the modification time of the group g, whose children changed, to the current time
+func (db *Database) touchModification(g *Group) {
+	g.Times.LastModificationTime = db.now()
+}
+
+func (db *Database) addDeletedObject(uuid UUID) {
+	db.Content.Root.DeletedObjects = append(db.Content.Root.DeletedObjects, DeletedObjectData{
+		UUID:         uuid,
+		DeletionTime: db.now(),
+	})
+}
+
+func (db *Database) addDeletedGroup(g *Group) {
+	for _, entry := range g.Entries {
+		db.addDeletedObject(entry.UUID)
+	}
+	for i := range g.Groups {
+		db.addDeletedGroup(&g.Groups[i])
+	}
+	db.addDeletedObject(g.UUID)
+}
+
+// copiedTimeData returns the times for a copy of an object with the times td,
+// keeping only the expiry
+func (db *Database) copiedTimeData(td TimeData) TimeData {
+	copied := TimeData{
+		CreationTime: db.now(),
+		ExpiryTime:   cloneTimeWrapper(td.ExpiryTime),
+		Expires:      td.Expires,
+	}
+	db.touchLocation(&copied)
+	return copied
+}
+
+func (db *Database) prepareCopiedEntry(e *Entry) {
+	e.Times = db.copiedTimeData(e.Times)
+	e.PreviousParentGroup = nil
+
+	// The history of the copy belongs to the new UUID
+	for i := range e.Histories {
+		for j := range e.Histories[i].Entries {
+			e.Histories[i].Entries[j].UUID = e.UUID
+		}
+	}
+}
+
+func (db *Database) prepareCopiedGroup(g *Group) {
+	g.Times = db.copiedTimeData(g.Times)
+	g.PreviousParentGroup = nil
+
+	for i := range g.Entries {
+		db.prepareCopiedEntry(&g.Entries[i])
+	}
+	for i := range g.Groups {
+		db.prepareCopiedGroup(&g.Groups[i])
+	}
+}
@@ -0,0 +1,201 @@
+package gokeepasslib
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// checkGroupsModified checks that the LastModificationTime of the groups has been updated
+func checkGroupsModified(t *testing.T, db *Database, uuids ...UUID) {
+	t.Helper()
+
+	for _, uuid := range uuids {
+		group := db.FindGroupByUUID(uuid)
+		if group == nil || !group.Times.LastModificationTime.Time.After(mergeBaseTime) {
+			t.Errorf("Expected the modification time of group %v to be updated, received %+v", uuid, group)
+		}
+	}
+}
+
+func TestDatabase_AddEntryAndGroup(t *testing.T) {
+	db := newMergeDatabase()
+
+	entry, err := db.AddEntry(mergeOtherUUID, newMergeEntry(mergeNewUUID, "new", 0))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.FindParentGroup(entry.UUID).UUID != mergeOtherUUID {
+		t.Errorf("Expected the entry to be added to the other group")
+	}
+	if !entry.Times.LocationChanged.Time.After(mergeBaseTime) {
+		t.Errorf("Expected LocationChanged to be updated, received %v", entry.Times.LocationChanged)
+	}
+	checkGroupsModified(t, db, mergeOtherUUID)
+
+	if _, err := db.AddEntry(mergeOtherUUID, newMergeEntry(mergeNewUUID, "duplicate", 0)); !errors.Is(err, ErrDuplicateUUID) {
+		t.Errorf("Expected ErrDuplicateUUID, received %v", err)
+	}
+	if _, err := db.AddEntry(NewUUID(), NewEntry()); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, received %v", err)
+	}
+
+	group := newMergeGroup(UUID{0x10}, "added")
+	group.Entries = []Entry{newMergeEntry(mergeEntryUUID, "duplicate", 0)}
+	if _, err := db.AddGroup(mergeRootUUID, group); !errors.Is(err, ErrDuplicateUUID) {
+		t.Errorf("Expected ErrDuplicateUUID for a contained entry, received %v", err)
+	}
+
+	group.Entries = nil
+	added, err := db.AddGroup(mergeRootUUID, group)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if added.Name != "added" || len(db.Content.Root.Groups[0].Groups) != 3 {
+		t.Errorf("Expected the group to be added, received %+v", added)
+	}
+	checkGroupsModified(t, db, mergeRootUUID)
+}
+
+func TestDatabase_MoveEntry(t *testing.T) {
+	db := newMergeDatabase()
+
+	entry, err := db.MoveEntry(mergeEntryUUID, mergeOtherUUID)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	if len(db.FindGroupByUUID(mergeGroupUUID).Entries) != 0 ||
+		db.FindParentGroup(mergeEntryUUID).UUID != mergeOtherUUID {
+		t.Errorf("Expected the entry to be moved")
+	}
+	if entry.PreviousParentGroup == nil || *entry.PreviousParentGroup != mergeGroupUUID {
+		t.Errorf("Expected PreviousParentGroup to be set, received %v", entry.PreviousParentGroup)
+	}
+	if !entry.Times.LocationChanged.Time.After(mergeBaseTime) ||
+		!entry.Times.LastModificationTime.Time.After(mergeBaseTime) {
+		t.Errorf("Expected the times to be updated, received %+v", entry.Times)
+	}
+	checkGroupsModified(t, db, mergeGroupUUID, mergeOtherUUID)
+
+	if _, err := db.MoveEntry(NewUUID(), mergeOtherUUID); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("Expected ErrEntryNotFound, received %v", err)
+	}
+}
+
+func TestDatabase_MoveGroup(t *testing.T) {
+	cases := []struct {
+		title       string
+		uuid        UUID
+		target      UUID
+		expectedErr error
+	}{
+		{
+			title:  "into sibling",
+			uuid:   mergeOtherUUID,
+			target: mergeGroupUUID,
+		},
+		{
+			title:       "into itself",
+			uuid:        mergeGroupUUID,
+			target:      mergeGroupUUID,
+			expectedErr: ErrInvalidMove,
+		},
+		{
+			title:       "into own sub group",
+			uuid:        mergeRootUUID,
+			target:      mergeGroupUUID,
+			expectedErr: ErrInvalidMove,
+		},
+		{
+			title:       "unknown target",
+			uuid:        mergeGroupUUID,
+			target:      NewUUID(),
+			expectedErr: ErrGroupNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			db := newMergeDatabase()
+
+			group, err := db.MoveGroup(c.uuid, c.target)
+			if !errors.Is(err, c.expectedErr) {
+				t.Fatalf("Expected %v, received %v", c.expectedErr, err)
+			}
+			if c.expectedErr != nil {
+				return
+			}
+
+			if db.FindParentGroup(c.uuid).UUID != c.target {
+				t.Errorf("Expected the group to be moved")
+			}
+			if group.PreviousParentGroup == nil || *group.PreviousParentGroup != mergeRootUUID {
+				t.Errorf("Expected PreviousParentGroup to be set, received %v", group.PreviousParentGroup)
+			}
+			checkGroupsModified(t, db, mergeRootUUID, c.target)
+		})
+	}
+}
+
+func TestDatabase_Delete(t *testing.T) {
+	db := newMergeDatabase()
+	before := time.Now().Add(-time.Second)
+
+	if err := db.DeleteEntry(mergeEntryUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.FindEntryByUUID(mergeEntryUUID) != nil {
+		t.Errorf("Expected the entry to be removed")
+	}
+	checkGroupsModified(t, db, mergeGroupUUID)
+	if len(db.Content.Root.DeletedObjects) != 1 ||
+		db.Content.Root.DeletedObjects[0].UUID != mergeEntryUUID ||
+		db.Content.Root.DeletedObjects[0].DeletionTime.Time.Before(before) {
+		t.Errorf("Expected a deleted object for the entry, received %+v", db.Content.Root.DeletedObjects)
+	}
+	if err := db.DeleteEntry(mergeEntryUUID); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("Expected ErrEntryNotFound, received %v", err)
+	}
+
+	db = newMergeDatabase()
+	if err := db.DeleteGroup(mergeGroupUUID); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if db.FindGroupByUUID(mergeGroupUUID) != nil {
+		t.Errorf("Expected the group to be removed")
+	}
+	checkGroupsModified(t, db, mergeRootUUID)
+	if len(db.Content.Root.DeletedObjects) != 2 {
+		t.Errorf("Expected deleted objects for the group and its entry, received %+v", db.Content.Root.DeletedObjects)
+	}
+	if err := db.DeleteGroup(mergeRootUUID); !errors.Is(err, ErrInvalidMove) {
+		t.Errorf("Expected ErrInvalidMove for the root group, received %v", err)
+	}
+}
+
+func TestDatabase_Copy(t *testing.T) {
+	db := newMergeDatabase()
+
+	entry, err := db.CopyEntry(mergeEntryUUID, mergeOtherUUID)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if entry.UUID == mergeEntryUUID || entry.GetTitle() != "entry" {
+		t.Errorf("Expected a copy with a new UUID, received %+v", entry)
+	}
+	if !entry.Times.CreationTime.Time.After(mergeBaseTime) {
+		t.Errorf("Expected a new creation time, received %v", entry.Times.CreationTime)
+	}
+	if db.FindEntryByUUID(mergeEntryUUID) == nil {
+		t.Errorf("Expected the original entry to be kept")
+	}
+
+	group, err := db.CopyGroup(mergeGroupUUID, mergeOtherUUID)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if group.UUID == mergeGroupUUID || len(group.Entries) != 1 || group.Entries[0].UUID == mergeEntryUUID {
+		t.Errorf("Expected a copy with new UUIDs, received %+v", group)
+	}
+}
@@ -0,0 +1,456 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// unknownXMLData stores the XML attributes and child elements of an element
+// which are not known to the library.
+// They are kept as raw tokens so that they can be written back on encoding
+// and data written by other tools (e.g. plugins or newer schema versions) is not lost.
+type unknownXMLData struct {
+	Attrs    []xml.Attr
+	Elements []unknownXMLElement
+}
+
+// unknownXMLElement is a single unknown child element.
+// After and Index reference the preceding known sibling element
+// (the Index-th occurrence of an element named After),
+// an empty After means it was the first child element.
+// Parent is the name of the known container element, like CustomData,
+// the unknown element is nested in, it is empty for direct child elements.
+type unknownXMLElement struct {
+	Parent string
+	After  string
+	Index  int
+	Tokens []xml.Token
+}
+
+// unknownXMLTracker keeps track of the known sibling elements while decoding
+// to be able to record the position of unknown elements
+type unknownXMLTracker struct {
+	data   unknownXMLData
+	last   string
+	counts map[string]int
+}
+
+func newUnknownXMLTracker(start xml.StartElement) *unknownXMLTracker {
+	tracker := &unknownXMLTracker{
+		counts: map[string]int{},
+	}
+	tracker.data.Attrs = append(tracker.data.Attrs, start.Attr...)
+	return tracker
+}
+
+// known records that a known element with the given name has been decoded
+func (t *unknownXMLTracker) known(name string) {
+	t.last = name
+	t.counts[name]++
+}
+
+// unknown reads the unknown element start from d and stores its tokens
+func (t *unknownXMLTracker) unknown(d *xml.Decoder, start xml.StartElement) error {
+	return t.unknownIn(d, start, "", t.last, t.counts[t.last])
+}
+
+// unknownIn reads the unknown element start nested in the known element parent from d
+// and stores its tokens with the position after the index-th element named after
+func (t *unknownXMLTracker) unknownIn(d *xml.Decoder, start xml.StartElement, parent, after string, index int) error {
+	tokens, err := readXMLElementTokens(d, start)
+	if err != nil {
+		return err
+	}
+
+	t.data.Elements = append(t.data.Elements, unknownXMLElement{
+		Parent: parent,
+		After:  after,
+		Index:  index,
+		Tokens: tokens,
+	})
+	return nil
+}
+
+// readXMLElementTokens reads all tokens of the element start from d,
+// including start itself and its end element
+func readXMLElementTokens(d *xml.Decoder, start xml.StartElement) ([]xml.Token, error) {
+	tokens := []xml.Token{start.Copy()}
+	depth := 1
+	for depth > 0 {
+		token, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+		tokens = append(tokens, xml.CopyToken(token))
+	}
+	return trimXMLIndentation(tokens), nil
+}
+
+// trimXMLIndentation removes the whitespace-only character data between elements,
+// as the indentation is written by the encoder again
+func trimXMLIndentation(tokens []xml.Token) []xml.Token {
+	trimmed := make([]xml.Token, 0, len(tokens))
+	for i, token := range tokens {
+		if charData, ok := token.(xml.CharData); ok && len(bytes.TrimSpace(charData)) == 0 {
+			_, afterStart := tokens[i-1].(xml.StartElement)
+			_, beforeEnd := tokens[i+1].(xml.EndElement)
+			if !afterStart || !beforeEnd {
+				continue
+			}
+		}
+		trimmed = append(trimmed, token)
+	}
+	return trimmed
+}
+
+// xmlChildField describes a struct field which is decoded from a child element
+type xmlChildField struct {
+	index     int
+	name      string
+	child     string // Name of the nested element for tags like `xml:"CustomData>Item"`
+	omitEmpty bool
+}
+
+// xmlChildFields are the fields of a struct type which are decoded from child elements,
+// in the order of the struct and by the name of the child element
+type xmlChildFields struct {
+	list   []xmlChildField
+	byName map[string]xmlChildField
+}
+
+var xmlChildFieldsCache sync.Map
+
+// getXMLChildFields returns the fields of the struct type t which are decoded from child elements
+func getXMLChildFields(t reflect.Type) *xmlChildFields {
+	if cached, ok := xmlChildFieldsCache.Load(t); ok {
+		return cached.(*xmlChildFields)
+	}
+
+	fields := &xmlChildFields{byName: map[string]xmlChildField{}}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || field.Name == "XMLName" {
+			continue
+		}
+
+		tag := field.Tag.Get("xml")
+		name, flags, _ := strings.Cut(tag, ",")
+		if name == "-" || strings.Contains(flags, "attr") ||
+			strings.Contains(flags, "chardata") || strings.Contains(flags, "innerxml") {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		parent, child, _ := strings.Cut(name, ">")
+		childField := xmlChildField{
+			index:     i,
+			name:      parent,
+			child:     child,
+			omitEmpty: strings.Contains(flags, "omitempty"),
+		}
+		fields.list = append(fields.list, childField)
+		fields.byName[parent] = childField
+	}
+
+	xmlChildFieldsCache.Store(t, fields)
+	return fields
+}
+
+// unmarshalXMLWithUnknown decodes the children of start from d into the struct v points to,
+// based on the xml tags of its fields.
+// Attributes and children without a matching field are returned as unknown XML data.
+func unmarshalXMLWithUnknown(d *xml.Decoder, start xml.StartElement, v any) (unknownXMLData, error) {
+	value := reflect.ValueOf(v).Elem()
+	fields := getXMLChildFields(value.Type())
+	tracker := newUnknownXMLTracker(start)
+
+	for {
+		token, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return unknownXMLData{}, err
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		field, ok := fields.byName[element.Name.Local]
+		if !ok {
+			if err := tracker.unknown(d, element); err != nil {
+				return unknownXMLData{}, err
+			}
+			continue
+		}
+
+		if err := decodeXMLChildField(d, element, value.Field(field.index), field.child, tracker); err != nil {
+			return unknownXMLData{}, err
+		}
+		tracker.known(element.Name.Local)
+	}
+
+	return tracker.data, nil
+}
+
+// decodeXMLChildField decodes the element start into the given field value,
+// appending to slices and resolving nested `parent>child` elements.
+// Other elements nested in start are kept by tracker.
+func decodeXMLChildField(
+	d *xml.Decoder,
+	start xml.StartElement,
+	field reflect.Value,
+	child string,
+	tracker *unknownXMLTracker,
+) error {
+	if child == "" {
+		return decodeXMLValue(d, start, field)
+	}
+
+	after, index := "", 0
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Local != child {
+				if err := tracker.unknownIn(d, element, start.Name.Local, after, index); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := decodeXMLValue(d, element, field); err != nil {
+				return err
+			}
+			after, index = child, index+1
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func decodeXMLValue(d *xml.Decoder, start xml.StartElement, field reflect.Value) error {
+	if isXMLSlice(field) {
+		item := reflect.New(field.Type().Elem())
+		if err := d.DecodeElement(item.Interface(), &start); err != nil {
+			return err
+		}
+		field.Set(reflect.Append(field, item.Elem()))
+		return nil
+	}
+
+	return d.DecodeElement(field.Addr().Interface(), &start)
+}
+
+// marshalXMLWithUnknown encodes v as element start into e
+// and writes the unknown attributes and elements back in their original position.
+func marshalXMLWithUnknown(e *xml.Encoder, start xml.StartElement, v any, unknown unknownXMLData) error {
+	start.Attr = append(start.Attr, unknown.Attrs...)
+
+	if len(unknown.Elements) == 0 {
+		return e.EncodeElement(v, start)
+	}
+
+	w := &unknownXMLWriter{e: e, unknown: unknown, written: make([]bool, len(unknown.Elements))}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := w.write("", "", 0); err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(v).Elem()
+	counts := map[string]int{}
+	for _, field := range getXMLChildFields(value.Type()).list {
+		if err := w.encodeField(field, value.Field(field.index), counts); err != nil {
+			return err
+		}
+	}
+
+	// Write the elements whose anchor no longer exists before closing the element
+	if err := w.writeRemaining(""); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+// unknownXMLWriter writes the known fields of a struct
+// and the unknown elements after their preceding known sibling elements
+type unknownXMLWriter struct {
+	e       *xml.Encoder
+	unknown unknownXMLData
+	written []bool
+}
+
+// encodeField encodes the field value as child elements like encoding/xml does,
+// counting the written elements by name to position the unknown elements
+func (w *unknownXMLWriter) encodeField(field xmlChildField, value reflect.Value, counts map[string]int) error {
+	if field.child != "" {
+		// encoding/xml writes the parent element even if the value is omitted
+		if (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) && value.IsNil() &&
+			!w.hasUnknownIn(field.name) {
+			return nil
+		}
+		return w.encodeContainer(field, value, counts)
+	}
+	if field.omitEmpty && isEmptyXMLValue(value) {
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: field.name}}
+	if isXMLSlice(value) {
+		for i := range value.Len() {
+			if err := w.e.EncodeElement(xmlValue(value.Index(i)), start); err != nil {
+				return err
+			}
+			counts[field.name]++
+			if err := w.write("", field.name, counts[field.name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) && value.IsNil() {
+		return nil
+	}
+
+	if err := w.e.EncodeElement(xmlValue(value), start); err != nil {
+		return err
+	}
+	counts[field.name]++
+	return w.write("", field.name, counts[field.name])
+}
+
+// encodeContainer encodes a `parent>child` field with the unknown elements nested in parent
+func (w *unknownXMLWriter) encodeContainer(field xmlChildField, value reflect.Value, counts map[string]int) error {
+	start := xml.StartElement{Name: xml.Name{Local: field.name}}
+	if err := w.e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := w.write(field.name, "", 0); err != nil {
+		return err
+	}
+
+	child := xml.StartElement{Name: xml.Name{Local: field.child}}
+	items := []reflect.Value{value}
+	if isXMLSlice(value) {
+		items = nil
+		for i := range value.Len() {
+			items = append(items, value.Index(i))
+		}
+	}
+	if field.omitEmpty && isEmptyXMLValue(value) {
+		items = nil
+	}
+	for i, item := range items {
+		if err := w.e.EncodeElement(xmlValue(item), child); err != nil {
+			return err
+		}
+		if err := w.write(field.name, field.child, i+1); err != nil {
+			return err
+		}
+	}
+
+	if err := w.writeRemaining(field.name); err != nil {
+		return err
+	}
+	if err := w.e.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	counts[field.name]++
+	return w.write("", field.name, counts[field.name])
+}
+
+// write writes the unknown elements nested in parent after the index-th element named after
+func (w *unknownXMLWriter) write(parent, after string, index int) error {
+	for i, element := range w.unknown.Elements {
+		if w.written[i] || element.Parent != parent || element.After != after || element.Index != index {
+			continue
+		}
+		if err := w.writeElement(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRemaining writes the unknown elements nested in parent which have not been written yet
+func (w *unknownXMLWriter) writeRemaining(parent string) error {
+	for i, element := range w.unknown.Elements {
+		if w.written[i] || element.Parent != parent {
+			continue
+		}
+		if err := w.writeElement(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *unknownXMLWriter) writeElement(i int) error {
+	w.written[i] = true
+	for _, token := range w.unknown.Elements[i].Tokens {
+		if err := w.e.EncodeToken(token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasUnknownIn returns whether there are unknown elements nested in the element parent
+func (w *unknownXMLWriter) hasUnknownIn(parent string) bool {
+	for _, element := range w.unknown.Elements {
+		if element.Parent == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// isXMLSlice returns whether v is encoded as one element per item, which is every slice except []byte
+func isXMLSlice(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8
+}
+
+// xmlValue returns the value to encode for v,
+// a pointer if possible so that MarshalXML methods with pointer receivers are used
+func xmlValue(v reflect.Value) any {
+	if v.CanAddr() {
+		return v.Addr().Interface()
+	}
+	return v.Interface()
+}
+
+// isEmptyXMLValue returns whether v is left out by encoding/xml for fields tagged with omitempty
+func isEmptyXMLValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
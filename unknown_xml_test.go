@@ -0,0 +1,173 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestUnknownXMLRoundTrip(t *testing.T) {
+	cases := []struct {
+		title    string
+		value    func() any
+		xmlData  string
+		expected []string
+	}{
+		{
+			title: "entry",
+			value: func() any { return new(Entry) },
+			xmlData: `<Entry plugin="value">` +
+				`<Plugin><Setting enabled="True">x</Setting></Plugin>` +
+				`<UUID>uJSFMJ8KrUSO0Qiivnk2Eg==</UUID>` +
+				`<String><Key>Title</Key><Value>title</Value></String>` +
+				`<String><Key>UserName</Key><Value>user</Value></String>` +
+				`<Future>  </Future>` +
+				`<AutoType><Enabled>True</Enabled></AutoType>` +
+				`</Entry>`,
+			expected: []string{
+				`<Entry plugin="value">`,
+				`<Plugin><Setting enabled="True">x</Setting></Plugin><UUID>`,
+				`<Key>UserName</Key><Value Protected="False">user</Value></String><Future>  </Future><AutoType>`,
+			},
+		},
+		{
+			title: "group",
+			value: func() any { return new(Group) },
+			xmlData: `<Group>` +
+				`<UUID>uJSFMJ8KrUSO0Qiivnk2Eg==</UUID>` +
+				`<Name>name</Name>` +
+				`<Plugin>value</Plugin>` +
+				`<Entry><UUID>uJSFMJ8KrUSO0Qiivnk2Eg==</UUID><Nested>value</Nested></Entry>` +
+				`</Group>`,
+			expected: []string{
+				`<Name>name</Name><Plugin>value</Plugin><Notes>`,
+				`<UUID>uJSFMJ8KrUSO0Qiivnk2Eg==</UUID><Nested>value</Nested><IconID>`,
+			},
+		},
+		{
+			title: "meta data",
+			value: func() any { return new(MetaData) },
+			xmlData: `<Meta>` +
+				`<Generator>generator</Generator>` +
+				`<Plugin>value</Plugin>` +
+				`</Meta>`,
+			expected: []string{
+				`<Generator>generator</Generator><Plugin>value</Plugin>`,
+			},
+		},
+		{
+			title: "entry custom data",
+			value: func() any { return new(Entry) },
+			xmlData: `<Entry>` +
+				`<CustomData><Plugin>first</Plugin><Item><Key>k</Key><Value>v</Value></Item><Plugin>last</Plugin></CustomData>` +
+				`</Entry>`,
+			expected: []string{
+				`<CustomData><Plugin>first</Plugin><Item><Key>k</Key><Value>v</Value></Item><Plugin>last</Plugin></CustomData>`,
+			},
+		},
+		{
+			title: "meta data custom data",
+			value: func() any { return new(MetaData) },
+			xmlData: `<Meta>` +
+				`<CustomData><Plugin>value</Plugin></CustomData>` +
+				`</Meta>`,
+			expected: []string{
+				`<CustomData><Plugin>value</Plugin></CustomData>`,
+			},
+		},
+		{
+			title: "root data",
+			value: func() any { return new(RootData) },
+			xmlData: `<Root>` +
+				`<Group><Name>name</Name></Group>` +
+				`<Plugin>value</Plugin>` +
+				`</Root>`,
+			expected: []string{
+				`</Group><Plugin>value</Plugin><DeletedObjects>`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			value := c.value()
+			if err := xml.Unmarshal([]byte(c.xmlData), value); err != nil {
+				t.Fatalf("Received an unexpected error unmarshaling: %v", err)
+			}
+
+			data, err := xml.Marshal(value)
+			if err != nil {
+				t.Fatalf("Received an unexpected error marshaling: %v", err)
+			}
+
+			for _, expected := range c.expected {
+				if !strings.Contains(string(data), expected) {
+					t.Errorf("Expected %s to contain %s", data, expected)
+				}
+			}
+
+			// A second round trip with indentation must be stable
+			indented, err := xml.MarshalIndent(value, "", "\t")
+			if err != nil {
+				t.Fatalf("Received an unexpected error marshaling: %v", err)
+			}
+
+			value = c.value()
+			if err := xml.Unmarshal(indented, value); err != nil {
+				t.Fatalf("Received an unexpected error unmarshaling: %v", err)
+			}
+
+			reindented, err := xml.MarshalIndent(value, "", "\t")
+			if err != nil {
+				t.Fatalf("Received an unexpected error marshaling: %v", err)
+			}
+
+			if !bytes.Equal(indented, reindented) {
+				t.Errorf("Expected stable output %s, received %s", indented, reindented)
+			}
+		})
+	}
+}
+
+func TestUnknownXMLDatabaseRoundTrip(t *testing.T) {
+	unknownElements := []string{
+		`<Plugin>meta</Plugin>`,
+		`<Plugin>meta custom data</Plugin>`,
+		`<Plugin>group</Plugin>`,
+		`<Plugin>entry</Plugin>`,
+		`<Plugin>entry custom data</Plugin>`,
+		`<Plugin>root</Plugin>`,
+	}
+	content := `<KeePassFile><Meta><Generator>generator</Generator>` + unknownElements[0] +
+		`<CustomData><Item><Key>k</Key><Value>v</Value></Item>` + unknownElements[1] + `</CustomData></Meta>` +
+		`<Root><Group><UUID>uJSFMJ8KrUSO0Qiivnk2Eg==</UUID><Name>Root</Name>` + unknownElements[2] +
+		`<Entry><UUID>XvpA9ii+QkiWQEkaHn3YCw==</UUID><String><Key>Title</Key><Value>title</Value></String>` +
+		unknownElements[3] + `<CustomData>` + unknownElements[4] + `</CustomData></Entry>` +
+		`</Group>` + unknownElements[5] + `</Root></KeePassFile>`
+
+	db := NewDatabase(WithDatabaseKDBXVersion3())
+	db.Header.FileHeaders.TransformRounds = 1
+	db.Credentials = NewPasswordCredentials("abcdefg12345678")
+	data := encodeRawContent(t, db, content)
+
+	// Decode, encode and decode again, the unknown elements have to be kept by the decoded database
+	for range 2 {
+		decoded := NewDatabase()
+		decoded.Credentials = NewPasswordCredentials("abcdefg12345678")
+		if err := NewDecoder(bytes.NewReader(data), WithDecoderRawData(true)).Decode(decoded); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		for _, element := range unknownElements {
+			if !bytes.Contains(decoded.Content.RawData, []byte(element)) {
+				t.Errorf("Expected the content to contain %s", element)
+			}
+		}
+
+		var buffer bytes.Buffer
+		if err := NewEncoder(&buffer).Encode(decoded); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		data = buffer.Bytes()
+	}
+}
@@ -0,0 +1,196 @@
+package gokeepasslib
+
+import (
+	"encoding/binary"
+)
+
+// variantDictionaryVersion is the version of variant dictionaries written by this library
+const variantDictionaryVersion = 0x0100
+
+// NewVariantDictionary creates a new empty VariantDictionary,
+// for example to be used as FileHeaders.PublicCustomData
+func NewVariantDictionary() *VariantDictionary {
+	return &VariantDictionary{Version: variantDictionaryVersion}
+}
+
+// Get a VariantDictionaryItem via its key
+func (vd *VariantDictionary) Get(key string) *VariantDictionaryItem {
+	if vd == nil {
+		return nil
+	}
+	for _, item := range vd.Items {
+		if string(item.Name) == key {
+			return item
+		}
+	}
+	return nil
+}
+
+// Keys returns the keys of the items in order
+func (vd *VariantDictionary) Keys() []string {
+	if vd == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(vd.Items))
+	for _, item := range vd.Items {
+		keys = append(keys, string(item.Name))
+	}
+	return keys
+}
+
+// Delete removes the item with the given key, it returns false if there was no such item
+func (vd *VariantDictionary) Delete(key string) bool {
+	if vd == nil {
+		return false
+	}
+	for i, item := range vd.Items {
+		if string(item.Name) == key {
+			vd.Items = append(vd.Items[:i:i], vd.Items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GetUInt32 returns the value of the UInt32 item with the given key,
+// ok is false if there is no such item or it has another type
+func (vd *VariantDictionary) GetUInt32(key string) (value uint32, ok bool) {
+	if data, ok := vd.value(key, variantDictionaryTypeUInt32, 4); ok {
+		return binary.LittleEndian.Uint32(data), true
+	}
+	return 0, false
+}
+
+// GetUInt64 returns the value of the UInt64 item with the given key,
+// ok is false if there is no such item or it has another type
+func (vd *VariantDictionary) GetUInt64(key string) (value uint64, ok bool) {
+	if data, ok := vd.value(key, variantDictionaryTypeUInt64, 8); ok {
+		return binary.LittleEndian.Uint64(data), true
+	}
+	return 0, false
+}
+
+// GetBool returns the value of the Bool item with the given key,
+// ok is false if there is no such item or it has another type
+func (vd *VariantDictionary) GetBool(key string) (value bool, ok bool) {
+	if data, ok := vd.value(key, variantDictionaryTypeFlag, 1); ok {
+		return data[0] != 0, true
+	}
+	return false, false
+}
+
+// GetInt32 returns the value of the Int32 item with the given key,
+// ok is false if there is no such item or it has another type
+func (vd *VariantDictionary) GetInt32(key string) (value int32, ok bool) {
+	if data, ok := vd.value(key, variantDictionaryTypeInt32, 4); ok {
+		return int32(binary.LittleEndian.Uint32(data)), true
+	}
+	return 0, false
+}
+
+// GetInt64 returns the value of the Int64 item with the given key,
+// ok is false if there is no such item or it has another type
+func (vd *VariantDictionary) GetInt64(key string) (value int64, ok bool) {
+	if data, ok := vd.value(key, variantDictionaryTypeInt64, 8); ok {
+		return int64(binary.LittleEndian.Uint64(data)), true
+	}
+	return 0, false
+}
+
+// GetString returns the value of the String item with the given key,
+// ok is false if there is no such item or it has another type
+func (vd *VariantDictionary) GetString(key string) (value string, ok bool) {
+	if data, ok := vd.value(key, variantDictionaryTypeString, -1); ok {
+		return string(data), true
+	}
+	return "", false
+}
+
+// GetByteArray returns a copy of the value of the ByteArray item with the given key,
+// ok is false if there is no such item or it has another type
+func (vd *VariantDictionary) GetByteArray(key string) (value []byte, ok bool) {
+	if data, ok := vd.value(key, variantDictionaryTypeBinary, -1); ok {
+		return append([]byte{}, data...), true
+	}
+	return nil, false
+}
+
+// value returns the value of the item with the given key if it has the given type and size,
+// any size is accepted if size is negative
+func (vd *VariantDictionary) value(key string, itemType byte, size int) ([]byte, bool) {
+	item := vd.Get(key)
+	if item == nil || item.Type != itemType || size >= 0 && len(item.Value) != size {
+		return nil, false
+	}
+	return item.Value, true
+}
+
+// SetUInt32 sets the item with the given key to a UInt32 value
+func (vd *VariantDictionary) SetUInt32(key string, value uint32) {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	vd.set(key, variantDictionaryTypeUInt32, data)
+}
+
+// SetUInt64 sets the item with the given key to a UInt64 value
+func (vd *VariantDictionary) SetUInt64(key string, value uint64) {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, value)
+	vd.set(key, variantDictionaryTypeUInt64, data)
+}
+
+// SetBool sets the item with the given key to a Bool value
+func (vd *VariantDictionary) SetBool(key string, value bool) {
+	data := []byte{0}
+	if value {
+		data[0] = 1
+	}
+	vd.set(key, variantDictionaryTypeFlag, data)
+}
+
+// SetInt32 sets the item with the given key to an Int32 value
+func (vd *VariantDictionary) SetInt32(key string, value int32) {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, uint32(value))
+	vd.set(key, variantDictionaryTypeInt32, data)
+}
+
+// SetInt64 sets the item with the given key to an Int64 value
+func (vd *VariantDictionary) SetInt64(key string, value int64) {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, uint64(value))
+	vd.set(key, variantDictionaryTypeInt64, data)
+}
+
+// SetString sets the item with the given key to a UTF-8 String value
+func (vd *VariantDictionary) SetString(key string, value string) {
+	vd.set(key, variantDictionaryTypeString, []byte(value))
+}
+
+// SetByteArray sets the item with the given key to a copy of a ByteArray value
+func (vd *VariantDictionary) SetByteArray(key string, value []byte) {
+	vd.set(key, variantDictionaryTypeBinary, append([]byte{}, value...))
+}
+
+// set replaces the type and value of the item with the given key, or appends a new item,
+// and updates the lengths of the item
+func (vd *VariantDictionary) set(key string, itemType byte, value []byte) {
+	if vd.Version == 0 {
+		vd.Version = variantDictionaryVersion
+	}
+
+	item := vd.Get(key)
+	if item == nil {
+		item = &VariantDictionaryItem{Name: []byte(key)}
+		vd.Items = append(vd.Items, item)
+	}
+	item.Type = itemType
+	item.Value = value
+	item.updateLengths()
+}
+
+// updateLengths sets NameLength and ValueLength to the lengths of Name and Value
+func (vdi *VariantDictionaryItem) updateLengths() {
+	vdi.NameLength = int32(len(vdi.Name))
+	vdi.ValueLength = int32(len(vdi.Value))
+}
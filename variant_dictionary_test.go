@@ -0,0 +1,215 @@
+package gokeepasslib
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestVariantDictionaryTypedValues(t *testing.T) {
+	vd := NewVariantDictionary()
+	vd.SetUInt32("uint32", 0xdeadbeef)
+	vd.SetUInt64("uint64", 0xdeadbeefcafe)
+	vd.SetBool("bool", true)
+	vd.SetInt32("int32", -42)
+	vd.SetInt64("int64", -1<<40)
+	vd.SetString("string", "platform team")
+	vd.SetByteArray("bytes", []byte{0x01, 0x02, 0x03})
+
+	cases := []struct {
+		title    string
+		get      func() (any, bool)
+		expected any
+	}{
+		{
+			title:    "uint32",
+			get:      func() (any, bool) { return vd.GetUInt32("uint32") },
+			expected: uint32(0xdeadbeef),
+		},
+		{
+			title:    "uint64",
+			get:      func() (any, bool) { return vd.GetUInt64("uint64") },
+			expected: uint64(0xdeadbeefcafe),
+		},
+		{
+			title:    "bool",
+			get:      func() (any, bool) { return vd.GetBool("bool") },
+			expected: true,
+		},
+		{
+			title:    "int32",
+			get:      func() (any, bool) { return vd.GetInt32("int32") },
+			expected: int32(-42),
+		},
+		{
+			title:    "int64",
+			get:      func() (any, bool) { return vd.GetInt64("int64") },
+			expected: int64(-1 << 40),
+		},
+		{
+			title:    "string",
+			get:      func() (any, bool) { return vd.GetString("string") },
+			expected: "platform team",
+		},
+		{
+			title:    "byte array",
+			get:      func() (any, bool) { return vd.GetByteArray("bytes") },
+			expected: []byte{0x01, 0x02, 0x03},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			value, ok := c.get()
+			if !ok {
+				t.Fatalf("Expected the value to be found")
+			}
+			if !reflect.DeepEqual(value, c.expected) {
+				t.Errorf("Expected %v, received %v", c.expected, value)
+			}
+		})
+	}
+
+	for _, item := range vd.Items {
+		if item.NameLength != int32(len(item.Name)) || item.ValueLength != int32(len(item.Value)) {
+			t.Errorf("Expected the lengths of %s to be set, received %d and %d", item.Name, item.NameLength, item.ValueLength)
+		}
+	}
+}
+
+func TestVariantDictionaryMismatch(t *testing.T) {
+	vd := NewVariantDictionary()
+	vd.SetUInt32("key", 1)
+
+	if _, ok := vd.GetUInt64("key"); ok {
+		t.Errorf("Expected a value of another type not to be found")
+	}
+	if _, ok := vd.GetString("missing"); ok {
+		t.Errorf("Expected a missing value not to be found")
+	}
+
+	var missing *VariantDictionary
+	if _, ok := missing.GetBool("key"); ok {
+		t.Errorf("Expected no value in a nil dictionary")
+	}
+}
+
+func TestVariantDictionarySet(t *testing.T) {
+	vd := &VariantDictionary{}
+	vd.SetString("first", "value")
+	vd.SetUInt32("second", 2)
+	vd.SetInt64("first", 1)
+
+	if vd.Version != variantDictionaryVersion {
+		t.Errorf("Expected the version to be set, received %x", vd.Version)
+	}
+	if keys := vd.Keys(); !reflect.DeepEqual(keys, []string{"first", "second"}) {
+		t.Errorf("Expected an existing item to be replaced in place, received %v", keys)
+	}
+	if value, ok := vd.GetInt64("first"); !ok || value != 1 {
+		t.Errorf("Expected the replaced value, received %d", value)
+	}
+	if item := vd.Get("first"); item.ValueLength != 8 {
+		t.Errorf("Expected the value length to be updated, received %d", item.ValueLength)
+	}
+
+	if !vd.Delete("first") {
+		t.Errorf("Expected the item to be deleted")
+	}
+	if vd.Delete("first") {
+		t.Errorf("Expected a deleted item not to be deleted again")
+	}
+	if keys := vd.Keys(); !reflect.DeepEqual(keys, []string{"second"}) {
+		t.Errorf("Expected only the remaining item, received %v", keys)
+	}
+}
+
+func TestKdfParametersUpdateRawData(t *testing.T) {
+	k := &KdfParameters{
+		UUID:        KdfArgon2,
+		Salt:        [32]byte{0x01},
+		Parallelism: 2,
+		Memory:      memorySize,
+		Iterations:  2,
+		Version:     defaultVersion,
+		SecretKey:   []byte{0x02},
+		AssocData:   []byte{0x03},
+	}
+	k.updateRawData()
+
+	var buffer bytes.Buffer
+	if err := writeTo4VariantDictionary(&buffer, headerIDKdfParameters, k.RawData); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	read := new(KdfParameters)
+	if err := read.readKdfParameters(buffer.Bytes()[5:]); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	read.RawData = k.RawData
+	if !reflect.DeepEqual(read, k) {
+		t.Errorf("Expected %v, received %v", k, read)
+	}
+}
+
+func TestKdfParametersAssocDataKey(t *testing.T) {
+	k := &KdfParameters{UUID: KdfArgon2, SecretKey: []byte{0x02}, AssocData: []byte{0x03}}
+	k.updateRawData()
+
+	// The associated data is written with the key A, the secret key with the key K
+	if value, ok := k.RawData.GetByteArray("A"); !ok || !bytes.Equal(value, k.AssocData) {
+		t.Errorf("Expected the associated data with the key A, received %x", value)
+	}
+	if value, ok := k.RawData.GetByteArray("K"); !ok || !bytes.Equal(value, k.SecretKey) {
+		t.Errorf("Expected the secret key with the key K, received %x", value)
+	}
+}
+
+func TestWriteEmptyVariantDictionary(t *testing.T) {
+	cases := []struct {
+		title string
+		dict  *VariantDictionary
+	}{
+		{title: "nil", dict: nil},
+		{title: "without items", dict: NewVariantDictionary()},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := writeTo4VariantDictionary(&buffer, headerIDPublicCustomData, c.dict); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if buffer.Len() != 0 {
+				t.Errorf("Expected no header field for an empty dictionary, received % x", buffer.Bytes())
+			}
+		})
+	}
+}
+
+func TestPublicCustomData(t *testing.T) {
+	publicData := NewVariantDictionary()
+	publicData.SetString("owner", "platform")
+	publicData.SetUInt64("rotation-policy", 7)
+
+	db := NewDatabase(WithDatabaseKDBXVersion4(WithFileHeadersPublicCustomData(publicData)))
+	db.Credentials = NewPasswordCredentials(password)
+
+	var buffer bytes.Buffer
+	if err := NewEncoder(&buffer).Encode(db); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	// The header is read before the credentials are needed
+	read := NewDatabase()
+	read.Credentials = nil
+	NewDecoder(bytes.NewReader(buffer.Bytes())).Decode(read)
+
+	data := read.Header.FileHeaders.PublicCustomData
+	if owner, ok := data.GetString("owner"); !ok || owner != "platform" {
+		t.Errorf("Expected the owner to be read, received %q", owner)
+	}
+	if policy, ok := data.GetUInt64("rotation-policy"); !ok || policy != 7 {
+		t.Errorf("Expected the rotation policy to be read, received %d", policy)
+	}
+}